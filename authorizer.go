@@ -0,0 +1,130 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+var xAuthorizers = struct {
+	mu sync.RWMutex
+	m  map[uintptr]AuthorizerFn
+}{
+	m: make(map[uintptr]AuthorizerFn),
+}
+
+// AuthResult is the verdict an authorizer callback returns for an action.
+type AuthResult int32
+
+// Authorizer verdicts, mapping to SQLITE_OK, SQLITE_DENY and SQLITE_IGNORE.
+// AuthDeny aborts the statement with an authorization error; AuthIgnore lets
+// the statement compile but treats the denied element as NULL (for reads) or
+// a no-op.
+const (
+	AuthAllow  = AuthResult(sqlite3.SQLITE_OK)
+	AuthDeny   = AuthResult(sqlite3.SQLITE_DENY)
+	AuthIgnore = AuthResult(sqlite3.SQLITE_IGNORE)
+)
+
+// Authorizer action codes, passed as the action argument of an AuthorizerFn.
+// The meaning of the two string arguments depends on the action; see
+// https://www.sqlite.org/c3ref/c_alter_table.html for the full table.
+const (
+	AuthCreateIndex       = int32(sqlite3.SQLITE_CREATE_INDEX)
+	AuthCreateTable       = int32(sqlite3.SQLITE_CREATE_TABLE)
+	AuthCreateTempIndex   = int32(sqlite3.SQLITE_CREATE_TEMP_INDEX)
+	AuthCreateTempTable   = int32(sqlite3.SQLITE_CREATE_TEMP_TABLE)
+	AuthCreateTempTrigger = int32(sqlite3.SQLITE_CREATE_TEMP_TRIGGER)
+	AuthCreateTempView    = int32(sqlite3.SQLITE_CREATE_TEMP_VIEW)
+	AuthCreateTrigger     = int32(sqlite3.SQLITE_CREATE_TRIGGER)
+	AuthCreateView        = int32(sqlite3.SQLITE_CREATE_VIEW)
+	AuthDelete            = int32(sqlite3.SQLITE_DELETE)
+	AuthDropIndex         = int32(sqlite3.SQLITE_DROP_INDEX)
+	AuthDropTable         = int32(sqlite3.SQLITE_DROP_TABLE)
+	AuthDropTempIndex     = int32(sqlite3.SQLITE_DROP_TEMP_INDEX)
+	AuthDropTempTable     = int32(sqlite3.SQLITE_DROP_TEMP_TABLE)
+	AuthDropTempTrigger   = int32(sqlite3.SQLITE_DROP_TEMP_TRIGGER)
+	AuthDropTempView      = int32(sqlite3.SQLITE_DROP_TEMP_VIEW)
+	AuthDropTrigger       = int32(sqlite3.SQLITE_DROP_TRIGGER)
+	AuthDropView          = int32(sqlite3.SQLITE_DROP_VIEW)
+	AuthInsert            = int32(sqlite3.SQLITE_INSERT)
+	AuthPragma            = int32(sqlite3.SQLITE_PRAGMA)
+	AuthRead              = int32(sqlite3.SQLITE_READ)
+	AuthSelect            = int32(sqlite3.SQLITE_SELECT)
+	AuthTransaction       = int32(sqlite3.SQLITE_TRANSACTION)
+	AuthUpdate            = int32(sqlite3.SQLITE_UPDATE)
+	AuthAttach            = int32(sqlite3.SQLITE_ATTACH)
+	AuthDetach            = int32(sqlite3.SQLITE_DETACH)
+	AuthAlterTable        = int32(sqlite3.SQLITE_ALTER_TABLE)
+	AuthReindex           = int32(sqlite3.SQLITE_REINDEX)
+	AuthAnalyze           = int32(sqlite3.SQLITE_ANALYZE)
+	AuthCreateVtable      = int32(sqlite3.SQLITE_CREATE_VTABLE)
+	AuthDropVtable        = int32(sqlite3.SQLITE_DROP_VTABLE)
+	AuthFunction          = int32(sqlite3.SQLITE_FUNCTION)
+	AuthSavepoint         = int32(sqlite3.SQLITE_SAVEPOINT)
+	AuthCopy              = int32(sqlite3.SQLITE_COPY)
+	AuthRecursive         = int32(sqlite3.SQLITE_RECURSIVE)
+)
+
+// AuthorizerFn is consulted while statements are compiled. action is one of
+// the Auth* action codes; arg1 and arg2 identify the affected object, e.g.
+// table and column for AuthRead, and are empty when not applicable to the
+// action. dbName names the attached database ("main", "temp", ...) and
+// trigger the inner-most trigger or view responsible for the access, if any.
+type AuthorizerFn func(action int32, arg1, arg2, dbName, trigger string) AuthResult
+
+// RegisterAuthorizer installs callback as the connection's authorizer via
+// sqlite3_set_authorizer, letting untrusted SQL be restricted to specific
+// tables or columns. Only one authorizer is active per connection; installing
+// one replaces any previous, including the one backing the _readonly DSN
+// parameter. Passing nil removes the authorizer.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterAuthorizer(callback AuthorizerFn) error {
+	if callback == nil {
+		xAuthorizers.mu.Lock()
+		delete(xAuthorizers.m, c.db)
+		xAuthorizers.mu.Unlock()
+		rc := sqlite3.Xsqlite3_set_authorizer(c.tls, c.db, uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)))
+		if rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+		return nil
+	}
+	xAuthorizers.mu.Lock()
+	xAuthorizers.m[c.db] = callback
+	xAuthorizers.mu.Unlock()
+
+	rc := sqlite3.Xsqlite3_set_authorizer(c.tls, c.db, cFuncPointer(authorizerTrampoline), c.db)
+	if rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+func authorizerTrampoline(tls *libc.TLS, handle uintptr, action int32, zArg1, zArg2, zArg3, zArg4 uintptr) int32 {
+	xAuthorizers.mu.RLock()
+	xAuthorizer := xAuthorizers.m[handle]
+	xAuthorizers.mu.RUnlock()
+
+	if xAuthorizer == nil {
+		return sqlite3.SQLITE_OK
+	}
+
+	return int32(xAuthorizer(action, goStringOrEmpty(zArg1), goStringOrEmpty(zArg2), goStringOrEmpty(zArg3), goStringOrEmpty(zArg4)))
+}
+
+// goStringOrEmpty is libc.GoString tolerating the NULL pointers SQLite passes
+// for authorizer arguments that do not apply to the action.
+func goStringOrEmpty(p uintptr) string {
+	if p == 0 {
+		return ""
+	}
+	return libc.GoString(p)
+}
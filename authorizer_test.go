@@ -0,0 +1,98 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestRegisterAuthorizer(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table public(v);
+		create table secret(v);
+		insert into public values (1);
+		insert into secret values (42);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	type authorizerRegistrar interface {
+		RegisterAuthorizer(AuthorizerFn) error
+	}
+	register := func(fn AuthorizerFn) {
+		t.Helper()
+		if err := cn.Raw(func(driverConn any) error {
+			return driverConn.(authorizerRegistrar).RegisterAuthorizer(fn)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	register(func(action int32, arg1, arg2, dbName, trigger string) AuthResult {
+		if action == AuthRead && arg1 == "secret" {
+			return AuthDeny
+		}
+		return AuthAllow
+	})
+
+	var v int
+	if err := cn.QueryRowContext(context.Background(), "select v from public").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != 1 {
+		t.Fatalf("got %d, want 1", v)
+	}
+
+	err = cn.QueryRowContext(context.Background(), "select v from secret").Scan(&v)
+	if err == nil || !strings.Contains(err.Error(), "prohibited") {
+		t.Fatalf("expected authorization error, got %v", err)
+	}
+
+	// AuthIgnore compiles the statement but reads NULL for the denied column.
+	register(func(action int32, arg1, arg2, dbName, trigger string) AuthResult {
+		if action == AuthRead && arg1 == "secret" {
+			return AuthIgnore
+		}
+		return AuthAllow
+	})
+
+	var nv sql.NullInt64
+	if err := cn.QueryRowContext(context.Background(), "select v from secret").Scan(&nv); err != nil {
+		t.Fatal(err)
+	}
+
+	if nv.Valid {
+		t.Fatalf("got %v, want NULL", nv)
+	}
+
+	// Removing the authorizer restores full access.
+	register(nil)
+
+	if err := cn.QueryRowContext(context.Background(), "select v from secret").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != 42 {
+		t.Fatalf("got %d, want 42", v)
+	}
+}
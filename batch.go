@@ -0,0 +1,145 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// Batch is a statement prepared once for repeated execution with many
+// argument sets, minimizing the per-call overhead of bulk loads. Create one
+// with Conn.NewBatch and release it with Close.
+type Batch struct {
+	c     *conn
+	psql  uintptr
+	pstmt uintptr
+	n     int // number of bind parameters
+}
+
+// NewBatch prepares a single statement for batch execution. Scripts with
+// more than one statement are rejected.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) NewBatch(query string) (*Batch, error) {
+	psql, err := libc.CString(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := psql
+	pstmt, err := c.prepareV2(&p)
+	if err != nil {
+		c.free(psql)
+		return nil, err
+	}
+
+	if pstmt == 0 || *(*byte)(unsafe.Pointer(p)) != 0 {
+		if pstmt != 0 {
+			c.finalize(pstmt)
+		}
+		c.free(psql)
+		return nil, fmt.Errorf("sqlite: batch requires exactly one statement")
+	}
+
+	n, err := c.bindParameterCount(pstmt)
+	if err != nil {
+		c.finalize(pstmt)
+		c.free(psql)
+		return nil, err
+	}
+
+	return &Batch{c: c, psql: psql, pstmt: pstmt, n: n}, nil
+}
+
+// Exec runs the prepared statement once per argument set, all inside a
+// savepoint: either every set is applied or, when one fails, none are. It
+// returns the total number of rows modified, inserted or deleted. The
+// savepoint nests inside any transaction already open on the connection.
+func (b *Batch) Exec(argSets ...[]driver.Value) (rowsAffected int64, err error) {
+	if b.pstmt == 0 {
+		return 0, fmt.Errorf("sqlite: batch is closed")
+	}
+
+	if _, err = b.c.exec(context.Background(), "savepoint sqlite_batch", nil); err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			b.c.exec(context.Background(), "rollback to sqlite_batch", nil)
+		}
+		if _, e := b.c.exec(context.Background(), "release sqlite_batch", nil); err == nil && e != nil {
+			rowsAffected, err = 0, e
+		}
+	}()
+
+	for i, args := range argSets {
+		if err = b.execOne(args); err != nil {
+			return 0, fmt.Errorf("argument set %d: %w", i+1, err)
+		}
+
+		rowsAffected += sqlite3.Xsqlite3_changes64(b.c.tls, b.c.db)
+	}
+	return rowsAffected, nil
+}
+
+func (b *Batch) execOne(args []driver.Value) (err error) {
+	defer func() {
+		if e := b.c.reset(b.pstmt); err == nil && e != nil {
+			err = e
+		}
+		if e := b.c.clearBindings(b.pstmt); err == nil && e != nil {
+			err = e
+		}
+	}()
+
+	if b.n != 0 {
+		allocs, err := b.c.bind(b.pstmt, b.n, toNamedValues(args))
+		if err != nil {
+			return err
+		}
+
+		if len(allocs) != 0 {
+			defer func() {
+				for _, v := range allocs {
+					b.c.free(v)
+				}
+			}()
+		}
+	}
+
+	rc, err := b.c.step(b.pstmt)
+	if err != nil {
+		return err
+	}
+
+	switch rc & 0xff {
+	case sqlite3.SQLITE_DONE, sqlite3.SQLITE_ROW:
+		return nil
+	default:
+		return b.c.errstr(int32(rc))
+	}
+}
+
+// Close finalizes the prepared statement. The batch must not be used
+// afterwards.
+func (b *Batch) Close() error {
+	var err error
+	if b.pstmt != 0 {
+		err = b.c.finalize(b.pstmt)
+		b.pstmt = 0
+	}
+	if b.psql != 0 {
+		b.c.free(b.psql)
+		b.psql = 0
+	}
+	return err
+}
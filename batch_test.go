@@ -0,0 +1,90 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(),
+		"create table t(id integer primary key, v)"); err != nil {
+		t.Fatal(err)
+	}
+
+	type batcher interface {
+		NewBatch(query string) (*Batch, error)
+	}
+
+	if err := cn.Raw(func(driverConn any) error {
+		b, err := driverConn.(batcher).NewBatch("insert into t values (?, ?)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer b.Close()
+
+		sets := make([][]driver.Value, 100)
+		for i := range sets {
+			sets[i] = []driver.Value{int64(i + 1), "v"}
+		}
+
+		n, err := b.Exec(sets...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != 100 {
+			t.Fatalf("rowsAffected = %d, want 100", n)
+		}
+
+		// A failing set rolls the whole batch back.
+		if _, err := b.Exec(
+			[]driver.Value{int64(200), "v"},
+			[]driver.Value{int64(1), "dup"}, // primary key conflict
+		); err == nil {
+			t.Fatal("expected constraint error")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(), "select count(*) from t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 100 {
+		t.Fatalf("got %d rows, want 100 (failed batch must leave no rows)", n)
+	}
+
+	// Multi-statement scripts are rejected up front.
+	if err := cn.Raw(func(driverConn any) error {
+		if _, err := driverConn.(batcher).NewBatch("select 1; select 2"); err == nil {
+			t.Fatal("expected error for multi-statement batch")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
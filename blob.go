@@ -0,0 +1,186 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// Blob is an open handle to a single BLOB (or TEXT) value, wrapping the
+// sqlite3_blob_open family of functions. It implements io.ReadWriteSeeker so
+// multi-megabyte columns can be streamed without materializing them in
+// memory. A Blob cannot change the size of its value; writes past the end
+// fail. Like the incremental I/O API it wraps, the handle expires when the
+// underlying row is modified, after which operations return an error.
+type Blob struct {
+	c     *conn
+	pBlob uintptr
+	off   int64
+	size  int64
+}
+
+// ZeroBlob represents a BLOB of that many zero bytes. Bound as a statement
+// parameter it maps to sqlite3_bind_zeroblob64 and returned from a
+// user-defined function it maps to sqlite3_result_zeroblob64, so the content
+// is not materialized in memory. Together with OpenBlob this enables the
+// allocate-then-stream pattern: insert a ZeroBlob placeholder of the final
+// size, then fill it through incremental blob I/O.
+type ZeroBlob int64
+
+// OpenBlob opens the BLOB in column of the given table row for incremental
+// I/O. schema is the attached database name, typically "main". With write
+// set, the blob is writable. The returned Blob must be closed; closing the
+// connection with open blob handles is an error.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) OpenBlob(schema, table, column string, rowid int64, write bool) (*Blob, error) {
+	zDb, err := libc.CString(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(zDb)
+
+	zTable, err := libc.CString(table)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(zTable)
+
+	zColumn, err := libc.CString(column)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(zColumn)
+
+	var wrFlag int32
+	if write {
+		wrFlag = 1
+	}
+
+	bp := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	if rc := sqlite3.Xsqlite3_blob_open(c.tls, c.db, zDb, zTable, zColumn, rowid, wrFlag, bp); rc != sqlite3.SQLITE_OK {
+		return nil, c.errstr(rc)
+	}
+
+	pBlob := *(*uintptr)(unsafe.Pointer(bp))
+	return &Blob{
+		c:     c,
+		pBlob: pBlob,
+		size:  int64(sqlite3.Xsqlite3_blob_bytes(c.tls, pBlob)),
+	}, nil
+}
+
+// Size returns the size of the blob in bytes. Incremental I/O cannot change
+// it; use an UPDATE with zeroblob() to resize the value first.
+func (b *Blob) Size() int64 { return b.size }
+
+// Read implements io.Reader, reading from the current offset.
+func (b *Blob) Read(p []byte) (n int, err error) {
+	if b.off >= b.size {
+		return 0, io.EOF
+	}
+	if rem := b.size - b.off; int64(len(p)) > rem {
+		p = p[:rem]
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	pBuf, err := b.c.malloc(len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	defer b.c.free(pBuf)
+
+	if rc := sqlite3.Xsqlite3_blob_read(b.c.tls, b.pBlob, pBuf, int32(len(p)), int32(b.off)); rc != sqlite3.SQLITE_OK {
+		return 0, b.c.errstr(rc)
+	}
+
+	copy(p, (*libc.RawMem)(unsafe.Pointer(pBuf))[:len(p):len(p)])
+	b.off += int64(len(p))
+	return len(p), nil
+}
+
+// Write implements io.Writer, writing at the current offset. Writing beyond
+// the end of the blob fails with an error: incremental I/O cannot grow the
+// value.
+func (b *Blob) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if b.off+int64(len(p)) > b.size {
+		return 0, fmt.Errorf("sqlite: write of %d bytes at offset %d exceeds blob size %d", len(p), b.off, b.size)
+	}
+
+	pBuf, err := b.c.malloc(len(p))
+	if err != nil {
+		return 0, err
+	}
+
+	defer b.c.free(pBuf)
+
+	copy((*libc.RawMem)(unsafe.Pointer(pBuf))[:len(p):len(p)], p)
+	if rc := sqlite3.Xsqlite3_blob_write(b.c.tls, b.pBlob, pBuf, int32(len(p)), int32(b.off)); rc != sqlite3.SQLITE_OK {
+		return 0, b.c.errstr(rc)
+	}
+
+	b.off += int64(len(p))
+	return len(p), nil
+}
+
+// Seek implements io.Seeker, setting the offset for subsequent Read and
+// Write calls.
+func (b *Blob) Seek(offset int64, whence int) (int64, error) {
+	var off int64
+	switch whence {
+	case io.SeekStart:
+		off = offset
+	case io.SeekCurrent:
+		off = b.off + offset
+	case io.SeekEnd:
+		off = b.size + offset
+	default:
+		return 0, fmt.Errorf("sqlite: invalid seek whence %d", whence)
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("sqlite: negative seek offset %d", off)
+	}
+
+	b.off = off
+	return off, nil
+}
+
+// Reopen moves the handle to a different row of the same table and column,
+// wrapping sqlite3_blob_reopen. It is faster than closing and reopening the
+// blob and resets the offset to the start.
+func (b *Blob) Reopen(rowid int64) error {
+	if rc := sqlite3.Xsqlite3_blob_reopen(b.c.tls, b.pBlob, rowid); rc != sqlite3.SQLITE_OK {
+		return b.c.errstr(rc)
+	}
+
+	b.off = 0
+	b.size = int64(sqlite3.Xsqlite3_blob_bytes(b.c.tls, b.pBlob))
+	return nil
+}
+
+// Close releases the blob handle. If the blob was written inside a
+// transaction that hit an error, Close reports it.
+func (b *Blob) Close() error {
+	if rc := sqlite3.Xsqlite3_blob_close(b.c.tls, b.pBlob); rc != sqlite3.SQLITE_OK {
+		return b.c.errstr(rc)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+func TestBlobIO(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 4096) // 64 KiB
+	if _, err := cn.ExecContext(context.Background(),
+		"create table t(id integer primary key, data blob)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(context.Background(),
+		"insert into t values (1, ?), (2, zeroblob(?))", payload, len(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	type blobOpener interface {
+		OpenBlob(schema, table, column string, rowid int64, write bool) (*Blob, error)
+	}
+	open := func(rowid int64, write bool) *Blob {
+		t.Helper()
+		var b *Blob
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			b, err = driverConn.(blobOpener).OpenBlob("main", "t", "data", rowid, write)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// Stream row 1 out and into row 2's zeroblob through small copies.
+	src := open(1, false)
+	dst := open(2, true)
+
+	if src.Size() != int64(len(payload)) {
+		t.Fatalf("got size %d, want %d", src.Size(), len(payload))
+	}
+
+	if n, err := io.CopyBuffer(dst, src, make([]byte, 4096)); err != nil || n != int64(len(payload)) {
+		t.Fatalf("copy: n %d, err %v", n, err)
+	}
+
+	if err := src.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	if err := cn.QueryRowContext(context.Background(),
+		"select data from t where id = 2").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, payload) {
+		t.Fatal("copied blob differs from source")
+	}
+
+	// Seek and partial reads.
+	b := open(1, false)
+
+	defer b.Close()
+
+	if _, err := b.Seek(-16, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+
+	tail := make([]byte, 32)
+	n, err := b.Read(tail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 16 || !bytes.Equal(tail[:n], []byte("0123456789abcdef")) {
+		t.Fatalf("got %d bytes %q", n, tail[:n])
+	}
+
+	if _, err := b.Read(tail); err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+
+	// Writes cannot grow the value.
+	w := open(2, true)
+
+	defer w.Close()
+
+	if _, err := w.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatal("expected error writing past end of blob")
+	}
+
+	// Reopen moves the handle to another row.
+	if err := b.Reopen(2); err != nil {
+		t.Fatal(err)
+	}
+
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(b, head); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(head, []byte("0123")) {
+		t.Fatalf("got %q after reopen", head)
+	}
+
+	// Read-only handles reject writes.
+	if _, err := b.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Write([]byte("nope")); err == nil {
+		t.Fatal("expected error writing to read-only blob")
+	}
+}
+
+func TestZeroBlob(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(),
+		"create table t(id integer primary key, v blob)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Allocate: insert a 16-byte zero-filled placeholder.
+	if _, err := cn.ExecContext(context.Background(),
+		"insert into t values (1, ?)", ZeroBlob(16)); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(),
+		"select length(v) from t where id = 1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 16 {
+		t.Fatalf("length = %d, want 16", n)
+	}
+
+	var eq int
+	if err := cn.QueryRowContext(context.Background(),
+		"select v = zeroblob(16) from t where id = 1").Scan(&eq); err != nil {
+		t.Fatal(err)
+	}
+
+	if eq != 1 {
+		t.Fatal("placeholder is not zero-filled")
+	}
+
+	// Stream: fill the placeholder through incremental blob I/O.
+	want := bytes.Repeat([]byte{0xab}, 16)
+	type blobOpener interface {
+		OpenBlob(schema, table, column string, rowid int64, write bool) (*Blob, error)
+	}
+	if err := cn.Raw(func(driverConn any) error {
+		b, err := driverConn.(blobOpener).OpenBlob("main", "t", "v", 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer b.Close()
+
+		if _, err := b.Write(want); err != nil {
+			t.Fatal(err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	if err := cn.QueryRowContext(context.Background(),
+		"select v from t where id = 1").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got % x, want % x", got, want)
+	}
+
+	// ZeroBlob is also a valid UDF result.
+	if err := RegisterScalarFunction("zeroblob_result_test", 1, func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+		return ZeroBlob(args[0].(int64)), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db2, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db2.Close()
+
+	if err := db2.QueryRow("select zeroblob_result_test(8) = zeroblob(8)").Scan(&eq); err != nil {
+		t.Fatal(err)
+	}
+
+	if eq != 1 {
+		t.Fatal("function result is not an 8-byte zero blob")
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+	"modernc.org/libc/sys/types"
+)
+
+// ChangesetConflictAction is the verdict a conflict handler returns for a
+// conflicting change.
+type ChangesetConflictAction int32
+
+// Conflict handler verdicts, mapping to SQLITE_CHANGESET_OMIT, _REPLACE and
+// _ABORT. ChangesetReplace is only valid for ChangesetData and
+// ChangesetConflict conflicts.
+const (
+	ChangesetOmit    = ChangesetConflictAction(sqlite3.SQLITE_CHANGESET_OMIT)
+	ChangesetReplace = ChangesetConflictAction(sqlite3.SQLITE_CHANGESET_REPLACE)
+	ChangesetAbort   = ChangesetConflictAction(sqlite3.SQLITE_CHANGESET_ABORT)
+)
+
+// Conflict types passed to a ChangesetConflictFn, mapping to
+// SQLITE_CHANGESET_DATA, _NOTFOUND, _CONFLICT, _CONSTRAINT and _FOREIGN_KEY.
+const (
+	ChangesetData       = int32(sqlite3.SQLITE_CHANGESET_DATA)
+	ChangesetNotfound   = int32(sqlite3.SQLITE_CHANGESET_NOTFOUND)
+	ChangesetConflict   = int32(sqlite3.SQLITE_CHANGESET_CONFLICT)
+	ChangesetConstraint = int32(sqlite3.SQLITE_CHANGESET_CONSTRAINT)
+	ChangesetForeignKey = int32(sqlite3.SQLITE_CHANGESET_FOREIGN_KEY)
+)
+
+// ChangesetConflictInfo describes one conflicting change presented to a
+// ChangesetConflictFn.
+type ChangesetConflictInfo struct {
+	// Type is one of the Changeset* conflict types.
+	Type int32
+
+	// Table is the name of the table the conflicting change applies to. It
+	// is empty for ChangesetForeignKey conflicts, which are not associated
+	// with a single change.
+	Table string
+
+	// Op is the conflicting operation: SQLITE_INSERT, SQLITE_UPDATE or
+	// SQLITE_DELETE.
+	Op int32
+
+	// NumCols is the number of columns in Table.
+	NumCols int
+
+	// Indirect reports whether the change was made indirectly, e.g. by a
+	// trigger or foreign key action.
+	Indirect bool
+}
+
+// ChangesetFilterFn decides per table whether changes are applied. Returning
+// false skips all changes for that table.
+type ChangesetFilterFn func(table string) bool
+
+// ChangesetConflictFn resolves a conflicting change by returning
+// ChangesetOmit, ChangesetReplace or ChangesetAbort.
+type ChangesetConflictFn func(conflict ChangesetConflictInfo) ChangesetConflictAction
+
+// changesetApply carries the callbacks of one ApplyChangeset call through the
+// trampolines.
+type changesetApply struct {
+	filter     ChangesetFilterFn
+	onConflict ChangesetConflictFn
+}
+
+var xChangesetApplies = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]*changesetApply
+	ids idGen
+}{
+	m: make(map[uintptr]*changesetApply),
+}
+
+// ApplyChangeset applies a changeset or patchset to the database, wrapping
+// sqlite3changeset_apply_v2. filter, when non-nil, is consulted once per
+// table and may skip its changes entirely. onConflict, when non-nil, decides
+// per conflicting row whether the change is omitted, replaces the existing
+// row or aborts the whole changeset; with a nil onConflict any conflict
+// aborts. The changes are applied inside a savepoint that is rolled back on
+// abort.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) ApplyChangeset(data []byte, filter ChangesetFilterFn, onConflict ChangesetConflictFn) error {
+	_, err := c.applyChangeset(data, filter, onConflict, false)
+	return err
+}
+
+// ApplyChangesetWithRebase is like ApplyChangeset but additionally returns
+// the rebase buffer accumulated from the conflict resolutions, for use with
+// RebaseChangeset. The buffer is empty when no conflicts occurred.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) ApplyChangesetWithRebase(data []byte, filter ChangesetFilterFn, onConflict ChangesetConflictFn) ([]byte, error) {
+	return c.applyChangeset(data, filter, onConflict, true)
+}
+
+func (c *conn) applyChangeset(data []byte, filter ChangesetFilterFn, onConflict ChangesetConflictFn, withRebase bool) ([]byte, error) {
+	pData, err := c.malloc(len(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(pData)
+
+	if len(data) != 0 {
+		copy((*libc.RawMem)(unsafe.Pointer(pData))[:len(data):len(data)], data)
+	}
+
+	xChangesetApplies.mu.Lock()
+	id := xChangesetApplies.ids.next()
+	xChangesetApplies.m[id] = &changesetApply{filter: filter, onConflict: onConflict}
+	xChangesetApplies.mu.Unlock()
+
+	defer func() {
+		xChangesetApplies.mu.Lock()
+		delete(xChangesetApplies.m, id)
+		xChangesetApplies.ids.reclaim(id)
+		xChangesetApplies.mu.Unlock()
+	}()
+
+	var xFilter uintptr
+	if filter != nil {
+		xFilter = cFuncPointer(changesetFilterTrampoline)
+	}
+
+	var ppRebase, pnRebase uintptr
+	if withRebase {
+		bp := c.tls.Alloc(16)
+		defer c.tls.Free(16)
+
+		ppRebase, pnRebase = bp, bp+8
+	}
+
+	rc := sqlite3.Xsqlite3changeset_apply_v2(
+		c.tls,
+		c.db,
+		int32(len(data)),
+		pData,
+		xFilter,
+		cFuncPointer(changesetConflictTrampoline),
+		id,
+		ppRebase,
+		pnRebase,
+		0,
+	)
+	if rc != sqlite3.SQLITE_OK {
+		return nil, c.errstr(rc)
+	}
+	if !withRebase {
+		return nil, nil
+	}
+	return changesetResult(c.tls, pnRebase, ppRebase), nil
+}
+
+// RebaseChangeset rewrites a local changeset so it can be applied on top of
+// the remote state that produced rebase, the buffer returned by
+// ApplyChangesetWithRebase. It wraps sqlite3rebaser_create/configure/rebase
+// and is the building block for offline-first synchronization.
+func RebaseChangeset(local, rebase []byte) ([]byte, error) {
+	tls := libc.NewTLS()
+	defer tls.Close()
+
+	bp := tls.Alloc(24)
+	defer tls.Free(24)
+
+	ppNew, pnOut, ppOut := bp, bp+8, bp+16
+	if rc := sqlite3.Xsqlite3rebaser_create(tls, ppNew); rc != sqlite3.SQLITE_OK {
+		return nil, changesetError(tls, rc)
+	}
+
+	pRebaser := *(*uintptr)(unsafe.Pointer(ppNew))
+
+	defer sqlite3.Xsqlite3rebaser_delete(tls, pRebaser)
+
+	pRebase, err := changesetMalloc(tls, rebase)
+	if err != nil {
+		return nil, err
+	}
+
+	defer libc.Xfree(tls, pRebase)
+
+	if rc := sqlite3.Xsqlite3rebaser_configure(tls, pRebaser, int32(len(rebase)), pRebase); rc != sqlite3.SQLITE_OK {
+		return nil, changesetError(tls, rc)
+	}
+
+	pLocal, err := changesetMalloc(tls, local)
+	if err != nil {
+		return nil, err
+	}
+
+	defer libc.Xfree(tls, pLocal)
+
+	rc := sqlite3.Xsqlite3rebaser_rebase(tls, pRebaser, int32(len(local)), pLocal, pnOut, ppOut)
+	if rc != sqlite3.SQLITE_OK {
+		return nil, changesetError(tls, rc)
+	}
+	return changesetResult(tls, pnOut, ppOut), nil
+}
+
+// InvertChangeset returns a changeset that undoes data: inserts become
+// deletes, deletes become inserts and updates swap their old and new values.
+// It wraps sqlite3changeset_invert. Patchsets cannot be inverted.
+func InvertChangeset(data []byte) ([]byte, error) {
+	tls := libc.NewTLS()
+	defer tls.Close()
+
+	pData, err := changesetMalloc(tls, data)
+	if err != nil {
+		return nil, err
+	}
+
+	defer libc.Xfree(tls, pData)
+
+	bp := tls.Alloc(16)
+	defer tls.Free(16)
+
+	pnOut, ppOut := bp, bp+8
+	rc := sqlite3.Xsqlite3changeset_invert(tls, int32(len(data)), pData, pnOut, ppOut)
+	if rc != sqlite3.SQLITE_OK {
+		return nil, changesetError(tls, rc)
+	}
+	return changesetResult(tls, pnOut, ppOut), nil
+}
+
+// ConcatChangesets merges two changesets into one equivalent to applying a
+// followed by b, wrapping sqlite3changeset_concat. This is how changesets are
+// batched up before shipping to replicas.
+func ConcatChangesets(a, b []byte) ([]byte, error) {
+	tls := libc.NewTLS()
+	defer tls.Close()
+
+	pA, err := changesetMalloc(tls, a)
+	if err != nil {
+		return nil, err
+	}
+
+	defer libc.Xfree(tls, pA)
+
+	pB, err := changesetMalloc(tls, b)
+	if err != nil {
+		return nil, err
+	}
+
+	defer libc.Xfree(tls, pB)
+
+	bp := tls.Alloc(16)
+	defer tls.Free(16)
+
+	pnOut, ppOut := bp, bp+8
+	rc := sqlite3.Xsqlite3changeset_concat(tls, int32(len(a)), pA, int32(len(b)), pB, pnOut, ppOut)
+	if rc != sqlite3.SQLITE_OK {
+		return nil, changesetError(tls, rc)
+	}
+	return changesetResult(tls, pnOut, ppOut), nil
+}
+
+// changesetMalloc copies data into C memory.
+func changesetMalloc(tls *libc.TLS, data []byte) (uintptr, error) {
+	p := libc.Xmalloc(tls, types.Size_t(len(data)))
+	if p == 0 && len(data) != 0 {
+		return 0, fmt.Errorf("sqlite: cannot allocate %d bytes of memory", len(data))
+	}
+	if len(data) != 0 {
+		copy((*libc.RawMem)(unsafe.Pointer(p))[:len(data):len(data)], data)
+	}
+	return p, nil
+}
+
+// changesetResult copies an (n, pointer) output pair produced by the
+// changeset functions into Go memory and releases the C buffer.
+func changesetResult(tls *libc.TLS, pnOut, ppOut uintptr) []byte {
+	n := *(*int32)(unsafe.Pointer(pnOut))
+	p := *(*uintptr)(unsafe.Pointer(ppOut))
+	if p == 0 {
+		return nil
+	}
+
+	defer sqlite3.Xsqlite3_free(tls, p)
+
+	if n <= 0 {
+		return nil
+	}
+	out := make([]byte, n)
+	copy(out, (*libc.RawMem)(unsafe.Pointer(p))[:n:n])
+	return out
+}
+
+// changesetError turns a changeset function's result code into an error.
+func changesetError(tls *libc.TLS, rc int32) error {
+	return fmt.Errorf("%s (%d)", libc.GoString(sqlite3.Xsqlite3_errstr(tls, rc)), rc)
+}
+
+func changesetFilterTrampoline(tls *libc.TLS, handle uintptr, zTab uintptr) int32 {
+	xChangesetApplies.mu.RLock()
+	apply := xChangesetApplies.m[handle]
+	xChangesetApplies.mu.RUnlock()
+
+	if apply == nil || apply.filter == nil || apply.filter(libc.GoString(zTab)) {
+		return 1
+	}
+	return 0
+}
+
+func changesetConflictTrampoline(tls *libc.TLS, handle uintptr, eConflict int32, pIter uintptr) int32 {
+	xChangesetApplies.mu.RLock()
+	apply := xChangesetApplies.m[handle]
+	xChangesetApplies.mu.RUnlock()
+
+	if apply == nil || apply.onConflict == nil {
+		return sqlite3.SQLITE_CHANGESET_ABORT
+	}
+
+	info := ChangesetConflictInfo{Type: eConflict}
+	if eConflict != ChangesetForeignKey {
+		bp := tls.Alloc(32)
+		defer tls.Free(32)
+
+		pzTab, pnCol, pOp, pbIndirect := bp, bp+8, bp+16, bp+24
+		if sqlite3.Xsqlite3changeset_op(tls, pIter, pzTab, pnCol, pOp, pbIndirect) == sqlite3.SQLITE_OK {
+			info.Table = libc.GoString(*(*uintptr)(unsafe.Pointer(pzTab)))
+			info.NumCols = int(*(*int32)(unsafe.Pointer(pnCol)))
+			info.Op = *(*int32)(unsafe.Pointer(pOp))
+			info.Indirect = *(*int32)(unsafe.Pointer(pbIndirect)) != 0
+		}
+	}
+
+	return int32(apply.onConflict(info))
+}
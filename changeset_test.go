@@ -0,0 +1,410 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// recordChangeset runs change on cn with a session recording all tables of
+// the main database and returns the resulting changeset.
+func recordChangeset(t *testing.T, cn *sql.Conn, change func()) []byte {
+	t.Helper()
+
+	var pSession uintptr
+	grabConn := func(driverConn any) *conn { return driverConn.(*conn) }
+
+	if err := cn.Raw(func(driverConn any) error {
+		c := grabConn(driverConn)
+		bp := c.tls.Alloc(8)
+		defer c.tls.Free(8)
+
+		zMain, err := libc.CString("main")
+		if err != nil {
+			return err
+		}
+
+		defer c.free(zMain)
+
+		if rc := sqlite3.Xsqlite3session_create(c.tls, c.db, zMain, bp); rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+
+		pSession = *(*uintptr)(unsafe.Pointer(bp))
+		if rc := sqlite3.Xsqlite3session_attach(c.tls, pSession, 0); rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	change()
+
+	var buf []byte
+	if err := cn.Raw(func(driverConn any) error {
+		c := grabConn(driverConn)
+
+		defer sqlite3.Xsqlite3session_delete(c.tls, pSession)
+
+		bp := c.tls.Alloc(16)
+		defer c.tls.Free(16)
+
+		pnChangeset, ppChangeset := bp, bp+8
+		if rc := sqlite3.Xsqlite3session_changeset(c.tls, pSession, pnChangeset, ppChangeset); rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+
+		n := *(*int32)(unsafe.Pointer(pnChangeset))
+		p := *(*uintptr)(unsafe.Pointer(ppChangeset))
+		if p != 0 {
+			defer sqlite3.Xsqlite3_free(c.tls, p)
+		}
+		if n > 0 {
+			buf = make([]byte, n)
+			copy(buf, (*libc.RawMem)(unsafe.Pointer(p))[:n:n])
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf
+}
+
+func TestApplyChangeset(t *testing.T) {
+	openConn := func() (*sql.DB, *sql.Conn) {
+		t.Helper()
+		db, err := sql.Open(driverName, "file::memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { db.Close() })
+		cn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { cn.Close() })
+		return db, cn
+	}
+
+	const schema = "create table t(id integer primary key, v text)"
+
+	_, src := openConn()
+	if _, err := src.ExecContext(context.Background(), schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := recordChangeset(t, src, func() {
+		if _, err := src.ExecContext(context.Background(),
+			"insert into t values (1, 'one'), (2, 'two')"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if len(cs) == 0 {
+		t.Fatal("empty changeset")
+	}
+
+	type changesetApplier interface {
+		ApplyChangeset([]byte, ChangesetFilterFn, ChangesetConflictFn) error
+	}
+	apply := func(cn *sql.Conn, filter ChangesetFilterFn, onConflict ChangesetConflictFn) error {
+		return cn.Raw(func(driverConn any) error {
+			return driverConn.(changesetApplier).ApplyChangeset(cs, filter, onConflict)
+		})
+	}
+
+	countRows := func(cn *sql.Conn) int {
+		t.Helper()
+		var n int
+		if err := cn.QueryRowContext(context.Background(), "select count(*) from t").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	t.Run("clean apply", func(t *testing.T) {
+		_, dst := openConn()
+		if _, err := dst.ExecContext(context.Background(), schema); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := apply(dst, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := countRows(dst); n != 2 {
+			t.Fatalf("got %d rows, want 2", n)
+		}
+	})
+
+	t.Run("conflict replace", func(t *testing.T) {
+		_, dst := openConn()
+		if _, err := dst.ExecContext(context.Background(),
+			schema+"; insert into t values (1, 'existing')"); err != nil {
+			t.Fatal(err)
+		}
+
+		var conflicts []ChangesetConflictInfo
+		if err := apply(dst, nil, func(conflict ChangesetConflictInfo) ChangesetConflictAction {
+			conflicts = append(conflicts, conflict)
+			return ChangesetReplace
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var v string
+		if err := dst.QueryRowContext(context.Background(), "select v from t where id = 1").Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+
+		if v != "one" {
+			t.Fatalf("got %q, want %q", v, "one")
+		}
+
+		if len(conflicts) != 1 {
+			t.Fatalf("got %d conflicts, want 1", len(conflicts))
+		}
+		if c := conflicts[0]; c.Type != ChangesetConflict || c.Table != "t" || c.Op != sqlite3.SQLITE_INSERT || c.NumCols != 2 {
+			t.Fatalf("unexpected conflict info: %+v", c)
+		}
+	})
+
+	t.Run("conflict abort", func(t *testing.T) {
+		_, dst := openConn()
+		if _, err := dst.ExecContext(context.Background(),
+			schema+"; insert into t values (1, 'existing')"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := apply(dst, nil, nil); err == nil {
+			t.Fatal("expected abort error")
+		}
+
+		// The savepoint rolled everything back, including row 2.
+		if n := countRows(dst); n != 1 {
+			t.Fatalf("got %d rows, want 1", n)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		_, dst := openConn()
+		if _, err := dst.ExecContext(context.Background(), schema); err != nil {
+			t.Fatal(err)
+		}
+
+		var filtered []string
+		if err := apply(dst, func(table string) bool {
+			filtered = append(filtered, table)
+			return false
+		}, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := countRows(dst); n != 0 {
+			t.Fatalf("got %d rows, want 0", n)
+		}
+
+		if len(filtered) == 0 || filtered[0] != "t" {
+			t.Fatalf("filter calls: %v, want t", filtered)
+		}
+	})
+}
+
+func TestInvertConcatChangesets(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(),
+		"create table t(id integer primary key, v text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	cs1 := recordChangeset(t, cn, func() {
+		if _, err := cn.ExecContext(context.Background(),
+			"insert into t values (1, 'one')"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	cs2 := recordChangeset(t, cn, func() {
+		if _, err := cn.ExecContext(context.Background(),
+			"insert into t values (2, 'two')"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	countRows := func() int {
+		t.Helper()
+		var n int
+		if err := cn.QueryRowContext(context.Background(), "select count(*) from t").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	type changesetApplier interface {
+		ApplyChangeset([]byte, ChangesetFilterFn, ChangesetConflictFn) error
+	}
+	apply := func(cs []byte) error {
+		return cn.Raw(func(driverConn any) error {
+			return driverConn.(changesetApplier).ApplyChangeset(cs, nil, nil)
+		})
+	}
+
+	// Applying the inverted concatenation undoes both inserts.
+	both, err := ConcatChangesets(cs1, cs2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	undo, err := InvertChangeset(both)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := apply(undo); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countRows(); n != 0 {
+		t.Fatalf("got %d rows after undo, want 0", n)
+	}
+
+	// And redoing the concatenation restores them.
+	if err := apply(both); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countRows(); n != 2 {
+		t.Fatalf("got %d rows after redo, want 2", n)
+	}
+
+	if _, err := InvertChangeset([]byte("garbage")); err == nil {
+		t.Fatal("expected error for corrupt changeset")
+	}
+}
+
+func TestRebaseChangeset(t *testing.T) {
+	openConn := func() *sql.Conn {
+		t.Helper()
+		db, err := sql.Open(driverName, "file::memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { db.Close() })
+		cn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { cn.Close() })
+		return cn
+	}
+
+	const (
+		schema = "create table t(id integer primary key, v text)"
+		base   = "insert into t values (1, 'base')"
+	)
+
+	remote, local := openConn(), openConn()
+	for _, cn := range []*sql.Conn{remote, local} {
+		if _, err := cn.ExecContext(context.Background(), schema+"; "+base); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The remote diverges before the local changes arrive.
+	if _, err := remote.ExecContext(context.Background(),
+		"update t set v = 'remote' where id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The local side records two consecutive changesets.
+	cs1 := recordChangeset(t, local, func() {
+		if _, err := local.ExecContext(context.Background(),
+			"update t set v = 'local' where id = 1"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	cs2 := recordChangeset(t, local, func() {
+		if _, err := local.ExecContext(context.Background(),
+			"update t set v = 'local2' where id = 1"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Apply cs1 on the remote; the update conflicts and the remote wins.
+	type rebaseApplier interface {
+		ApplyChangesetWithRebase([]byte, ChangesetFilterFn, ChangesetConflictFn) ([]byte, error)
+		ApplyChangeset([]byte, ChangesetFilterFn, ChangesetConflictFn) error
+	}
+
+	var rebase []byte
+	if err := remote.Raw(func(driverConn any) error {
+		var err error
+		rebase, err = driverConn.(rebaseApplier).ApplyChangesetWithRebase(cs1, nil,
+			func(conflict ChangesetConflictInfo) ChangesetConflictAction {
+				return ChangesetOmit
+			})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rebase) == 0 {
+		t.Fatal("empty rebase buffer despite conflicts")
+	}
+
+	// Rebase cs2 against the remote's resolutions and apply it cleanly.
+	rebased, err := RebaseChangeset(cs2, rebase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rebased) == 0 {
+		t.Fatal("empty rebased changeset")
+	}
+
+	if err := remote.Raw(func(driverConn any) error {
+		return driverConn.(rebaseApplier).ApplyChangeset(rebased, nil,
+			func(conflict ChangesetConflictInfo) ChangesetConflictAction {
+				return ChangesetReplace
+			})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var v string
+	if err := remote.QueryRowContext(context.Background(),
+		"select v from t where id = 1").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != "local2" {
+		t.Fatalf("got %q, want %q", v, "local2")
+	}
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+var xCollationNeededHandlers = struct {
+	mu sync.RWMutex
+	m  map[uintptr]CollationNeededFn
+}{
+	m: make(map[uintptr]CollationNeededFn),
+}
+
+// CollationNeededFn is called when a statement references a collation that is
+// not registered yet. name is the collation the statement asked for. The
+// callback is expected to register it, typically via RegisterCollation on the
+// same connection; if it does not, preparing the statement fails as usual.
+type CollationNeededFn func(name string)
+
+// RegisterCollationNeededHook wires callback into sqlite3_collation_needed so
+// collations can be registered lazily, on first use. This is useful when a
+// database created by other tools references many custom collations and
+// registering all of them up front is impractical. Passing nil removes the
+// hook.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterCollationNeededHook(callback CollationNeededFn) error {
+	if callback == nil {
+		xCollationNeededHandlers.mu.Lock()
+		delete(xCollationNeededHandlers.m, c.db)
+		xCollationNeededHandlers.mu.Unlock()
+		rc := sqlite3.Xsqlite3_collation_needed(c.tls, c.db, uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)))
+		if rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+		return nil
+	}
+	xCollationNeededHandlers.mu.Lock()
+	xCollationNeededHandlers.m[c.db] = callback
+	xCollationNeededHandlers.mu.Unlock()
+
+	rc := sqlite3.Xsqlite3_collation_needed(c.tls, c.db, c.db, cFuncPointer(collationNeededTrampoline))
+	if rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+func collationNeededTrampoline(tls *libc.TLS, handle uintptr, db uintptr, eTextRep int32, zName uintptr) {
+	xCollationNeededHandlers.mu.RLock()
+	xCollationNeededHandler := xCollationNeededHandlers.m[handle]
+	xCollationNeededHandlers.mu.RUnlock()
+
+	if xCollationNeededHandler == nil {
+		return
+	}
+
+	xCollationNeededHandler(libc.GoString(zName))
+}
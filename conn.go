@@ -8,6 +8,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +32,24 @@ type conn struct {
 	beginMode         string
 	intToTime         bool
 	integerTimeFormat string
+
+	// rawBytes makes TEXT and BLOB columns scan as []byte borrowing SQLite's
+	// buffer until the next row; set via the _rawbytes DSN parameter.
+	rawBytes bool
+
+	// timeLoc, when non-nil, is the location naive TEXT timestamps are
+	// interpreted in when scanning and that bound time.Time values are
+	// converted to before formatting. Set via the _time_location DSN
+	// parameter.
+	timeLoc *time.Location
+	// timeUTC normalizes all scanned and bound time.Time values to UTC. Set
+	// via the _time_utc DSN parameter; takes precedence over timeLoc for
+	// formatting.
+	timeUTC bool
+
+	// readOnlyEnforced is set when the connection was opened with the
+	// _readonly DSN parameter; see enforceReadOnly.
+	readOnlyEnforced bool
 }
 
 func newConn(dsn string) (*conn, error) {
@@ -53,19 +72,36 @@ func newConn(dsn string) (*conn, error) {
 		}
 	}
 
+	readOnly, err := getReadOnly(query)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheFlags, err := getSharedCache(query)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := int32(sqlite3.SQLITE_OPEN_READWRITE | sqlite3.SQLITE_OPEN_CREATE |
+		sqlite3.SQLITE_OPEN_FULLMUTEX |
+		sqlite3.SQLITE_OPEN_URI)
+	if readOnly {
+		flags = sqlite3.SQLITE_OPEN_READONLY |
+			sqlite3.SQLITE_OPEN_FULLMUTEX |
+			sqlite3.SQLITE_OPEN_URI
+	}
+	flags |= cacheFlags
+
 	c := &conn{tls: libc.NewTLS()}
-	db, err := c.openV2(
-		dsn,
-		vfsName,
-		sqlite3.SQLITE_OPEN_READWRITE|sqlite3.SQLITE_OPEN_CREATE|
-			sqlite3.SQLITE_OPEN_FULLMUTEX|
-			sqlite3.SQLITE_OPEN_URI,
-	)
+	db, err := c.openV2(dsn, vfsName, flags)
 	if err != nil {
 		return nil, err
 	}
 
 	c.db = db
+	xConns.mu.Lock()
+	xConns.m[db] = c
+	xConns.mu.Unlock()
 	if err = c.extendedResultCodes(true); err != nil {
 		c.Close()
 		return nil, err
@@ -76,6 +112,13 @@ func newConn(dsn string) (*conn, error) {
 		return nil, err
 	}
 
+	if readOnly {
+		if err = c.enforceReadOnly(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
 	return c, nil
 }
 
@@ -83,21 +126,39 @@ func newConn(dsn string) (*conn, error) {
 // recognized as a valid time encoding.
 func (c *conn) parseTime(s string) (interface{}, bool) {
 	if v, ok := c.parseTimeString(s, strings.Index(s, "m=")); ok {
+		if t, isTime := v.(time.Time); isTime {
+			return c.normalizeScannedTime(t), true
+		}
 		return v, true
 	}
 
 	ts := strings.TrimSuffix(s, "Z")
 
+	// Naive timestamps are interpreted in _time_location when set; a trailing
+	// Z means UTC explicitly. Formats carrying an offset are unaffected.
+	loc := time.UTC
+	if len(ts) == len(s) && c.timeLoc != nil {
+		loc = c.timeLoc
+	}
+
 	for _, f := range parseTimeFormats {
-		t, err := time.Parse(f, ts)
+		t, err := time.ParseInLocation(f, ts, loc)
 		if err == nil {
-			return t, true
+			return c.normalizeScannedTime(t), true
 		}
 	}
 
 	return s, false
 }
 
+// normalizeScannedTime applies the _time_utc DSN option to a scanned time.
+func (c *conn) normalizeScannedTime(t time.Time) time.Time {
+	if c.timeUTC {
+		return t.UTC()
+	}
+	return t
+}
+
 // Attempt to parse s as a time string produced by t.String().  If x > 0 it's
 // the index of substring "m=" within s.  Return (s, false) if s is
 // not recognized as a valid time encoding.
@@ -117,10 +178,34 @@ func (c *conn) parseTimeString(s0 string, x int) (interface{}, bool) {
 // writeTimeFormats are the names and formats supported
 // by the `_time_format` DSN query param.
 var writeTimeFormats = map[string]string{
-	"sqlite": parseTimeFormats[0],
+	"sqlite":  parseTimeFormats[0],
+	"rfc3339": time.RFC3339Nano,
+}
+
+// unixEpochJulianDay is the julian day number of the unix epoch, the pivot
+// between SQLite's REAL time representation and Go's.
+const unixEpochJulianDay = 2440587.5
+
+// timeToJulianDay converts t to the fractional julian day number SQLite uses
+// for REAL time values.
+func timeToJulianDay(t time.Time) float64 {
+	return float64(t.UnixMilli())/86400000.0 + unixEpochJulianDay
+}
+
+// julianDayToTime is the inverse of timeToJulianDay, at millisecond
+// resolution.
+func julianDayToTime(jd float64) time.Time {
+	return time.UnixMilli(int64(math.Round((jd - unixEpochJulianDay) * 86400000.0))).UTC()
 }
 
 func (c *conn) formatTime(t time.Time) string {
+	switch {
+	case c.timeUTC:
+		t = t.UTC()
+	case c.timeLoc != nil:
+		t = t.In(c.timeLoc)
+	}
+
 	// Before configurable write time formats were supported,
 	// time.Time.String was used. Maintain that default to
 	// keep existing driver users formatting times the same.
@@ -176,6 +261,24 @@ func (c *conn) columnText(pstmt uintptr, iCol int) (v string, err error) {
 	return string(b), nil
 }
 
+// columnRawBytes returns the column's TEXT or BLOB payload as a slice
+// aliasing SQLite's own buffer, avoiding the per-row copy. The slice is only
+// valid until the statement is stepped, reset or finalized; connections opt
+// into this behavior with the _rawbytes DSN parameter.
+func (c *conn) columnRawBytes(pstmt uintptr, iCol int) (v []byte, err error) {
+	p := sqlite3.Xsqlite3_column_blob(c.tls, pstmt, int32(iCol))
+	len, err := c.columnBytes(pstmt, iCol)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == 0 || len == 0 {
+		return nil, nil
+	}
+
+	return (*libc.RawMem)(unsafe.Pointer(p))[:len:len], nil
+}
+
 // C documentation
 //
 //	double sqlite3_column_double(sqlite3_stmt*, int iCol);
@@ -238,6 +341,57 @@ func (c *conn) changes() (int, error) {
 	return int(v), nil
 }
 
+// LastInsertRowID returns the rowid of the most recent successful INSERT on
+// the connection, wrapping sqlite3_last_insert_rowid. It lets code on the
+// low-level path, such as hooks and virtual tables, read the value without
+// issuing extra SQL.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) LastInsertRowID() int64 {
+	return sqlite3.Xsqlite3_last_insert_rowid(c.tls, c.db)
+}
+
+// Changes returns the number of rows modified, inserted or deleted by the
+// most recently completed INSERT, UPDATE or DELETE on the connection,
+// wrapping sqlite3_changes64.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) Changes() int64 {
+	return sqlite3.Xsqlite3_changes64(c.tls, c.db)
+}
+
+// TotalChanges returns the number of rows modified, inserted or deleted since
+// the connection was opened, wrapping sqlite3_total_changes64. Unlike
+// Changes, the count includes rows changed by triggers and foreign key
+// actions, so comparing it before and after a statement reveals indirect
+// modifications.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) TotalChanges() int64 {
+	return sqlite3.Xsqlite3_total_changes64(c.tls, c.db)
+}
+
+// Filename returns the resolved filesystem path of the database backing the
+// named schema, wrapping sqlite3_db_filename. schema is usually "main" but
+// may name any ATTACHed database. The result is "" for in-memory and
+// temporary databases, or when no schema of that name is attached.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) Filename(schema string) (string, error) {
+	zDbName, err := libc.CString(schema)
+	if err != nil {
+		return "", err
+	}
+
+	defer c.free(zDbName)
+
+	p := sqlite3.Xsqlite3_db_filename(c.tls, c.db, zDbName)
+	if p == 0 {
+		return "", nil
+	}
+	return libc.GoString(p), nil
+}
+
 // C documentation
 //
 //	int sqlite3_step(sqlite3_stmt*);
@@ -386,6 +540,10 @@ func (c *conn) bind(pstmt uintptr, n int, args []driver.NamedValue) (allocs []ui
 				if err := c.bindInt64(pstmt, i, x.UnixNano()); err != nil {
 					return allocs, err
 				}
+			case "julian":
+				if err := c.bindDouble(pstmt, i, timeToJulianDay(x)); err != nil {
+					return allocs, err
+				}
 			default:
 				if p, err = c.bindText(pstmt, i, c.formatTime(x)); err != nil {
 					return allocs, err
@@ -396,6 +554,14 @@ func (c *conn) bind(pstmt uintptr, n int, args []driver.NamedValue) (allocs []ui
 			if p, err = c.bindNull(pstmt, i); err != nil {
 				return allocs, err
 			}
+		case PointerValue:
+			if err := c.bindPointer(pstmt, i, x); err != nil {
+				return allocs, err
+			}
+		case ZeroBlob:
+			if rc := sqlite3.Xsqlite3_bind_zeroblob64(c.tls, pstmt, int32(i), sqlite3.Tsqlite3_uint64(x)); rc != sqlite3.SQLITE_OK {
+				return allocs, c.errstr(rc)
+			}
 		default:
 			return allocs, fmt.Errorf("sqlite: invalid driver.Value type %T", x)
 		}
@@ -550,6 +716,46 @@ func (c *conn) interrupt(pdb uintptr) (err error) {
 	return nil
 }
 
+// clearInterrupt resets a pending interrupt on the connection. SQLite only
+// clears the flag when the next statement is prepared or stepped while no
+// other statement is active, so after an interrupted statement has been
+// finalized the flag would linger and usable() would report the connection as
+// broken. Preparing an empty statement runs the parser, which performs
+// exactly that reset without any other side effect.
+func (c *conn) clearInterrupt() {
+	c.Lock() // Defend against race with .Close invoked by context handling.
+
+	defer c.Unlock()
+
+	if c.tls == nil || c.db == 0 {
+		return
+	}
+
+	psql, err := libc.CString("")
+	if err != nil {
+		return
+	}
+
+	defer c.free(psql)
+
+	// prepareV2 advances the pointer it is given; keep psql for the free above.
+	p := psql
+	if pstmt, err := c.prepareV2(&p); err == nil && pstmt != 0 {
+		c.finalize(pstmt)
+	}
+}
+
+// Interrupt aborts any long-running query currently executing on the
+// connection, wrapping sqlite3_interrupt. The interrupted statement fails
+// with SQLITE_INTERRUPT; statements started after the call are unaffected.
+// Unlike cancelling a context, this acts on the single connection only.
+//
+// It is safe to call from another goroutine while the connection is busy.
+// Access it through sql.Conn.Raw.
+func (c *conn) Interrupt() error {
+	return c.interrupt(c.db)
+}
+
 // C documentation
 //
 //	int sqlite3_extended_result_codes(sqlite3*, int onoff);
@@ -631,12 +837,28 @@ func (c *conn) errstr(rc int32) error {
 	if rc == sqlite3.SQLITE_BUSY {
 		s = " (SQLITE_BUSY)"
 	}
+	offset := int(sqlite3.Xsqlite3_error_offset(c.tls, c.db))
+	extended := int(sqlite3.Xsqlite3_extended_errcode(c.tls, c.db))
+	if extended&0xff != int(rc)&0xff {
+		// The connection has already moved on to a different error; don't
+		// attribute its details to this one.
+		extended = int(rc)
+	}
+	errno := int(sqlite3.Xsqlite3_system_errno(c.tls, c.db))
+	var e *Error
 	switch msg := libc.GoString(p); {
 	case msg == str:
-		return &Error{msg: fmt.Sprintf("%s (%v)%s", str, rc, s), code: int(rc)}
+		e = &Error{msg: fmt.Sprintf("%s (%v)%s", str, rc, s), code: int(rc), extended: extended, errno: errno, offset: offset}
 	default:
-		return &Error{msg: fmt.Sprintf("%s: %s (%v)%s", str, msg, rc, s), code: int(rc)}
+		e = &Error{msg: fmt.Sprintf("%s: %s (%v)%s", str, msg, rc, s), code: int(rc), extended: extended, errno: errno, offset: offset}
 	}
+	if c.readOnlyEnforced {
+		switch rc & 0xff {
+		case sqlite3.SQLITE_AUTH, sqlite3.SQLITE_READONLY:
+			return &ReadOnlyError{err: e}
+		}
+	}
+	return e
 }
 
 // Begin starts a transaction.
@@ -672,6 +894,9 @@ func (c *conn) Close() (err error) {
 	defer c.Unlock()
 
 	if c.db != 0 {
+		xConns.mu.Lock()
+		delete(xConns.m, c.db)
+		xConns.mu.Unlock()
 		if err := c.closeV2(c.db); err != nil {
 			return err
 		}
@@ -765,6 +990,99 @@ func (c *conn) createFunctionInternal(fun *userDefinedFunction) error {
 	return nil
 }
 
+// RegisterCollation makes a Go function available as a collation named zName
+// on this connection only, wiring it into sqlite3_create_collation_v2. impl
+// receives two UTF-8 strings and must return a negative, zero or positive
+// value, following the contract documented on RegisterCollationUtf8. Unlike
+// the driver-level registration, the collation is usable immediately and does
+// not affect other connections.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterCollation(zName string, impl func(left, right string) int) (err error) {
+	if dmesgs {
+		defer func() {
+			dmesg("conn %p, name %q: err %v", c, zName, err)
+		}()
+	}
+
+	name, err := libc.CString(zName)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(name)
+
+	xCollations.mu.Lock()
+	id := xCollations.ids.next()
+	xCollations.m[id] = impl
+	xCollations.mu.Unlock()
+
+	return c.createCollationInternal(&collation{
+		zName: name,
+		pApp:  id,
+		enc:   sqlite3.SQLITE_UTF8,
+	})
+}
+
+// UnregisterFunction removes the function with the given name and argument
+// count from this connection only, wrapping sqlite3_create_function with all
+// callbacks NULL. nArg must match the value the function was registered with,
+// -1 for variadic. Other connections and the driver-level registry are
+// unaffected; see the package-level UnregisterFunction for those.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) UnregisterFunction(zFuncName string, nArg int32) error {
+	name, err := libc.CString(zFuncName)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(name)
+
+	if rc := sqlite3.Xsqlite3_create_function(
+		c.tls,
+		c.db,
+		name,
+		nArg,
+		sqlite3.SQLITE_UTF8,
+		0,
+		0,
+		0,
+		0,
+	); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// UnregisterCollation removes the collation with the given name from this
+// connection only, wrapping sqlite3_create_collation_v2 with a NULL
+// comparator. Other connections and the driver-level registry are unaffected;
+// see the package-level UnregisterCollation for those.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) UnregisterCollation(zName string) error {
+	name, err := libc.CString(zName)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(name)
+
+	if rc := sqlite3.Xsqlite3_create_collation_v2(
+		c.tls,
+		c.db,
+		name,
+		sqlite3.SQLITE_UTF8,
+		0,
+		0,
+		0,
+	); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
 func (c *conn) createCollationInternal(coll *collation) error {
 	rc := sqlite3.Xsqlite3_create_collation_v2(
 		c.tls,
@@ -799,6 +1117,20 @@ func (c *conn) Exec(query string, args []driver.Value) (dr driver.Result, err er
 }
 
 func (c *conn) exec(ctx context.Context, query string, args []driver.NamedValue) (r driver.Result, err error) {
+	// Apply per-query settings (see WithPragma) before preparing: some pragma
+	// reads are resolved at prepare time.
+	restore, err := c.applyQuerySettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restore != nil {
+		defer func() {
+			if e := restore(); e != nil && err == nil {
+				r, err = nil, e
+			}
+		}()
+	}
+
 	s, err := c.prepare(ctx, query)
 	if err != nil {
 		return nil, err
@@ -846,6 +1178,25 @@ func (c *conn) Query(query string, args []driver.Value) (dr driver.Rows, err err
 }
 
 func (c *conn) query(ctx context.Context, query string, args []driver.NamedValue) (r driver.Rows, err error) {
+	// Apply per-query settings (see WithPragma) before preparing: some pragma
+	// reads are resolved at prepare time. The returned rows keep the settings
+	// in effect until closed.
+	restore, err := c.applyQuerySettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restore != nil {
+		defer func() {
+			if err != nil || r == nil {
+				if e := restore(); e != nil && err == nil {
+					r, err = nil, e
+				}
+				return
+			}
+			r.(*rows).restore = restore
+		}()
+	}
+
 	// Use newStmt directly. c.prepare wraps this, but we need the concrete *stmt type
 	// to manipulate the handle ownership below.
 	s, err := newStmt(c, query)
@@ -889,14 +1240,31 @@ func (c *conn) query(ctx context.Context, query string, args []driver.NamedValue
 // database or a "TEMP" database, the serialization is the same sequence of bytes
 // which would be written to disk if that database where backed up to disk.
 func (c *conn) Serialize() (v []byte, err error) {
-	pLen := c.tls.Alloc(8)
-	defer c.tls.Free(8)
-
 	zSchema := sqlite3.Xsqlite3_db_name(c.tls, c.db, 0)
 	if zSchema == 0 {
 		return nil, fmt.Errorf("failed to get main db name")
 	}
 
+	return c.serialize(zSchema)
+}
+
+// SerializeSchema is like Serialize for a specific attached database, e.g.
+// "main", "temp" or the name given to ATTACH.
+func (c *conn) SerializeSchema(schema string) (v []byte, err error) {
+	zSchema, err := libc.CString(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(zSchema)
+
+	return c.serialize(zSchema)
+}
+
+func (c *conn) serialize(zSchema uintptr) (v []byte, err error) {
+	pLen := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
 	pBuf := sqlite3.Xsqlite3_serialize(c.tls, c.db, zSchema, pLen, 0)
 	bufLen := *(*sqlite3.Sqlite3_int64)(unsafe.Pointer(pLen))
 	if pBuf != 0 {
@@ -915,16 +1283,39 @@ func (c *conn) Serialize() (v []byte, err error) {
 
 // Deserialize restore a database from the content returned by Serialize.
 func (c *conn) Deserialize(buf []byte) (err error) {
-	bufLen := len(buf)
-	pBuf := c.tls.Alloc(bufLen) // free will be done if it fails or on close, must not be freed here
-
-	copy((*libc.RawMem)(unsafe.Pointer(pBuf))[:bufLen:bufLen], buf)
-
 	zSchema := sqlite3.Xsqlite3_db_name(c.tls, c.db, 0)
 	if zSchema == 0 {
 		return fmt.Errorf("failed to get main db name")
 	}
 
+	return c.deserialize(zSchema, buf)
+}
+
+// DeserializeSchema is like Deserialize for a specific attached database,
+// e.g. "main", "temp" or the name given to ATTACH.
+func (c *conn) DeserializeSchema(schema string, buf []byte) (err error) {
+	zSchema, err := libc.CString(schema)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(zSchema)
+
+	return c.deserialize(zSchema, buf)
+}
+
+func (c *conn) deserialize(zSchema uintptr, buf []byte) (err error) {
+	bufLen := len(buf)
+	// SQLITE_DESERIALIZE_FREEONCLOSE hands the buffer to SQLite, which frees
+	// it with sqlite3_free, so it must come from sqlite3_malloc. Must not be
+	// freed here, even if deserializing fails.
+	pBuf := sqlite3.Xsqlite3_malloc64(c.tls, uint64(bufLen))
+	if pBuf == 0 && bufLen > 0 {
+		return fmt.Errorf("sqlite: cannot allocate %d bytes of memory", bufLen)
+	}
+
+	copy((*libc.RawMem)(unsafe.Pointer(pBuf))[:bufLen:bufLen], buf)
+
 	rc := sqlite3.Xsqlite3_deserialize(c.tls, c.db, zSchema, pBuf, int64(bufLen), int64(bufLen), sqlite3.SQLITE_DESERIALIZE_RESIZEABLE|sqlite3.SQLITE_DESERIALIZE_FREEONCLOSE)
 	if rc != sqlite3.SQLITE_OK {
 		return c.errstr(rc)
@@ -992,6 +1383,17 @@ func (c *conn) limit(id int, newVal int) int {
 	return int(sqlite3.Xsqlite3_limit(c.tls, c.db, int32(id), int32(newVal)))
 }
 
+// Limit changes a per-connection runtime limit and returns its prior value,
+// wrapping sqlite3_limit. id is one of the Limit* constants; pass -1 as
+// newVal to query a limit without changing it. Lowering limits such as
+// LimitLength or LimitVdbeOp is a cheap hardening measure before executing
+// untrusted SQL.
+//
+// Access it through sql.Conn.Raw, or use the package-level Limit helper.
+func (c *conn) Limit(id int, newVal int) int {
+	return c.limit(id, newVal)
+}
+
 // C documentation
 //
 //	int sqlite3_bind_blob(sqlite3_stmt*, int, const void*, int n, void(*)(void*));
@@ -1069,6 +1471,19 @@ func (c *conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 	return c.query(ctx, query, args)
 }
 
+// CheckNamedValue implements driver.NamedValueChecker, so statements executed
+// directly on the connection get the same extended argument conversions as
+// prepared statements; see stmt.CheckNamedValue.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	v, err := convertBindValue(nv.Value)
+	if err != nil {
+		return err
+	}
+
+	nv.Value = v
+	return nil
+}
+
 // IsReadOnly reports whether the database schema specified by dbName is read-only.
 //
 // dbName is the internal name of the attached database, not the filename.
@@ -1098,3 +1513,65 @@ func (c *conn) IsReadOnly(schema string) (bool, error) {
 		return false, fmt.Errorf("unexpected sqlite3_db_readonly(%q) return value: %v", schema, r)
 	}
 }
+
+// AutoCommit reports whether the connection is in autocommit mode, i.e. not
+// inside an explicit transaction. It wraps sqlite3_get_autocommit and can be
+// used to detect leaked transactions before returning a connection to a pool.
+func (c *conn) AutoCommit() bool {
+	if dmesgs {
+		defer func() {
+			dmesg("conn %p", c)
+		}()
+	}
+	return sqlite3.Xsqlite3_get_autocommit(c.tls, c.db) != 0
+}
+
+// TxnState describes the transaction state of a schema as reported by
+// Conn.TxnState.
+type TxnState int32
+
+// Transaction states returned by Conn.TxnState, mirroring the SQLITE_TXN_*
+// constants.
+const (
+	TxnNone  = TxnState(sqlite3.SQLITE_TXN_NONE)
+	TxnRead  = TxnState(sqlite3.SQLITE_TXN_READ)
+	TxnWrite = TxnState(sqlite3.SQLITE_TXN_WRITE)
+)
+
+// TxnState returns the transaction state of the named schema, wrapping
+// sqlite3_txn_state. Pass "" to report the highest state across all attached
+// schemas. Where AutoCommit only distinguishes in-transaction from not,
+// TxnState additionally tells read transactions from write transactions.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) TxnState(schema string) (TxnState, error) {
+	var zSchema uintptr
+	if schema != "" {
+		var err error
+		if zSchema, err = libc.CString(schema); err != nil {
+			return TxnNone, err
+		}
+
+		defer c.free(zSchema)
+	}
+
+	r := sqlite3.Xsqlite3_txn_state(c.tls, c.db, zSchema)
+	if r < 0 {
+		return TxnNone, fmt.Errorf("not a name of a database on connection: '%s'", schema)
+	}
+	return TxnState(r), nil
+}
+
+// SetLastInsertRowID sets the value returned by sqlite3_last_insert_rowid,
+// and hence by sql.Result.LastInsertId, without inserting a row. It wraps
+// sqlite3_set_last_insert_rowid and is intended for frameworks that emulate
+// inserts through virtual tables or triggers and want LastInsertId to reflect
+// the logical row.
+func (c *conn) SetLastInsertRowID(rowid int64) {
+	if dmesgs {
+		defer func() {
+			dmesg("conn %p, rowid %v", c, rowid)
+		}()
+	}
+	sqlite3.Xsqlite3_set_last_insert_rowid(c.tls, c.db, sqlite3.Tsqlite3_int64(rowid))
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// DBConfigOption identifies one of the boolean sqlite3_db_config options.
+type DBConfigOption int32
+
+// Boolean sqlite3_db_config options, mapping to the SQLITE_DBCONFIG_*
+// constants. See https://www.sqlite.org/c3ref/c_dbconfig_defensive.html for
+// the semantics of each option. Several of them — DBConfigDefensive,
+// DBConfigDQSDML, DBConfigDQSDDL, DBConfigTrustedSchema — are the recommended
+// hardening switches for handling untrusted databases or SQL and have no
+// PRAGMA equivalent.
+const (
+	DBConfigEnableFKey          = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_FKEY)
+	DBConfigEnableTrigger       = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_TRIGGER)
+	DBConfigEnableView          = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_VIEW)
+	DBConfigEnableFTS3Tokenizer = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_FTS3_TOKENIZER)
+	DBConfigEnableLoadExtension = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_LOAD_EXTENSION)
+	DBConfigEnableQPSG          = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_QPSG)
+	DBConfigTriggerEQP          = DBConfigOption(sqlite3.SQLITE_DBCONFIG_TRIGGER_EQP)
+	DBConfigResetDatabase       = DBConfigOption(sqlite3.SQLITE_DBCONFIG_RESET_DATABASE)
+	DBConfigDefensive           = DBConfigOption(sqlite3.SQLITE_DBCONFIG_DEFENSIVE)
+	DBConfigWritableSchema      = DBConfigOption(sqlite3.SQLITE_DBCONFIG_WRITABLE_SCHEMA)
+	DBConfigLegacyAlterTable    = DBConfigOption(sqlite3.SQLITE_DBCONFIG_LEGACY_ALTER_TABLE)
+	DBConfigDQSDML              = DBConfigOption(sqlite3.SQLITE_DBCONFIG_DQS_DML)
+	DBConfigDQSDDL              = DBConfigOption(sqlite3.SQLITE_DBCONFIG_DQS_DDL)
+	DBConfigLegacyFileFormat    = DBConfigOption(sqlite3.SQLITE_DBCONFIG_LEGACY_FILE_FORMAT)
+	DBConfigTrustedSchema       = DBConfigOption(sqlite3.SQLITE_DBCONFIG_TRUSTED_SCHEMA)
+	DBConfigStmtScanstatus      = DBConfigOption(sqlite3.SQLITE_DBCONFIG_STMT_SCANSTATUS)
+	DBConfigReverseScanorder    = DBConfigOption(sqlite3.SQLITE_DBCONFIG_REVERSE_SCANORDER)
+	DBConfigEnableAttachCreate  = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_ATTACH_CREATE)
+	DBConfigEnableAttachWrite   = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_ATTACH_WRITE)
+	DBConfigEnableComments      = DBConfigOption(sqlite3.SQLITE_DBCONFIG_ENABLE_COMMENTS)
+)
+
+// DBConfig sets a boolean sqlite3_db_config option on the connection and
+// returns its resulting state, which can differ from the requested one if
+// the option is not recognized or cannot change.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) DBConfig(op DBConfigOption, enable bool) (bool, error) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	return c.dbConfig(op, v)
+}
+
+// DBConfigQuery reads the current state of a boolean sqlite3_db_config
+// option without changing it.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) DBConfigQuery(op DBConfigOption) (bool, error) {
+	return c.dbConfig(op, -1)
+}
+
+// dbConfig invokes sqlite3_db_config with the (int, int*) argument shape
+// shared by all boolean options. v is 0 or 1 to set the option, or -1 to
+// query it.
+func (c *conn) dbConfig(op DBConfigOption, v int32) (bool, error) {
+	pResult := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	const vaSize = 16
+	p := sqlite3.Xsqlite3_malloc(c.tls, vaSize)
+	if p == 0 {
+		return false, fmt.Errorf("sqlite: out of memory")
+	}
+
+	defer sqlite3.Xsqlite3_free(c.tls, p)
+
+	libc.VaList(p, v, pResult)
+	if rc := sqlite3.Xsqlite3_db_config(c.tls, c.db, int32(op), p); rc != sqlite3.SQLITE_OK {
+		return false, c.errstr(rc)
+	}
+	return *(*int32)(unsafe.Pointer(pResult)) != 0, nil
+}
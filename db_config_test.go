@@ -0,0 +1,99 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestDBConfig(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), "create table t(v text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	type dbConfigurer interface {
+		DBConfig(op DBConfigOption, enable bool) (bool, error)
+		DBConfigQuery(op DBConfigOption) (bool, error)
+	}
+	configure := func(op DBConfigOption, enable bool) bool {
+		t.Helper()
+		var state bool
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			state, err = driverConn.(dbConfigurer).DBConfig(op, enable)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+	query := func(op DBConfigOption) bool {
+		t.Helper()
+		var state bool
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			state, err = driverConn.(dbConfigurer).DBConfigQuery(op)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+
+	// Double-quoted string literals in DML are accepted by default for
+	// historical compatibility; disabling DQS_DML makes them a hard error.
+	if _, err := cn.ExecContext(context.Background(), `insert into t values ("dq")`); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := configure(DBConfigDQSDML, false); state {
+		t.Fatal("DQS_DML still enabled after disabling")
+	}
+
+	if !query(DBConfigDQSDDL) {
+		// Just exercising the query path; the default is build-dependent.
+		t.Log("DQS_DDL disabled by default")
+	}
+
+	if _, err := cn.ExecContext(context.Background(), `insert into t values ("dq")`); err == nil {
+		t.Fatal("expected error for double-quoted string with DQS_DML disabled")
+	}
+
+	if state := configure(DBConfigDQSDML, true); !state {
+		t.Fatal("DQS_DML still disabled after enabling")
+	}
+
+	if _, err := cn.ExecContext(context.Background(), `insert into t values ("dq")`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Defensive mode blocks writes to the schema table.
+	if state := configure(DBConfigDefensive, true); !state {
+		t.Fatal("defensive mode not enabled")
+	}
+
+	if _, err := cn.ExecContext(context.Background(),
+		"pragma writable_schema=on; update sqlite_schema set name='x'"); err == nil {
+		t.Fatal("expected error writing schema in defensive mode")
+	}
+
+	configure(DBConfigDefensive, false)
+}
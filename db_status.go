@@ -0,0 +1,107 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+)
+
+// DBStatus is a snapshot of a connection's sqlite3_db_status counters,
+// collected by Conn.Status. All sizes are in bytes.
+type DBStatus struct {
+	// CacheUsed is the heap memory used by the connection's page caches.
+	CacheUsed int64
+
+	// SchemaUsed is the heap memory used to hold the schemas of all
+	// attached databases.
+	SchemaUsed int64
+
+	// StmtUsed is the heap memory used by prepared statements.
+	StmtUsed int64
+
+	// CacheHit, CacheMiss and CacheWrite count page cache hits, misses and
+	// write-backs since the connection was opened or the counters were
+	// reset.
+	CacheHit   int64
+	CacheMiss  int64
+	CacheWrite int64
+
+	// CacheSpill counts dirty cache pages written to disk mid-transaction.
+	CacheSpill int64
+
+	// LookasideUsed is the number of lookaside slots currently in use;
+	// LookasideHit and the two miss counters describe lookaside allocator
+	// efficiency.
+	LookasideUsed     int64
+	LookasideHit      int64
+	LookasideMissSize int64
+	LookasideMissFull int64
+}
+
+// Status collects the connection's sqlite3_db_status counters. With reset
+// set, the resettable counters (cache hit/miss/write/spill and the lookaside
+// statistics) restart from zero after being read.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) Status(reset bool) (*DBStatus, error) {
+	bp := c.tls.Alloc(16)
+	defer c.tls.Free(16)
+
+	pCurrent, pHighwtr := bp, bp+8
+
+	var resetFlag int32
+	if reset {
+		resetFlag = 1
+	}
+
+	var s DBStatus
+	for _, x := range []struct {
+		op   int32
+		dest *int64
+	}{
+		{sqlite3.SQLITE_DBSTATUS_CACHE_USED, &s.CacheUsed},
+		{sqlite3.SQLITE_DBSTATUS_SCHEMA_USED, &s.SchemaUsed},
+		{sqlite3.SQLITE_DBSTATUS_STMT_USED, &s.StmtUsed},
+		{sqlite3.SQLITE_DBSTATUS_CACHE_HIT, &s.CacheHit},
+		{sqlite3.SQLITE_DBSTATUS_CACHE_MISS, &s.CacheMiss},
+		{sqlite3.SQLITE_DBSTATUS_CACHE_WRITE, &s.CacheWrite},
+		{sqlite3.SQLITE_DBSTATUS_CACHE_SPILL, &s.CacheSpill},
+		{sqlite3.SQLITE_DBSTATUS_LOOKASIDE_USED, &s.LookasideUsed},
+		{sqlite3.SQLITE_DBSTATUS_LOOKASIDE_HIT, &s.LookasideHit},
+		{sqlite3.SQLITE_DBSTATUS_LOOKASIDE_MISS_SIZE, &s.LookasideMissSize},
+		{sqlite3.SQLITE_DBSTATUS_LOOKASIDE_MISS_FULL, &s.LookasideMissFull},
+	} {
+		if rc := sqlite3.Xsqlite3_db_status(c.tls, c.db, x.op, pCurrent, pHighwtr, resetFlag); rc != sqlite3.SQLITE_OK {
+			return nil, c.errstr(rc)
+		}
+
+		*x.dest = int64(*(*int32)(unsafe.Pointer(pCurrent)))
+	}
+	return &s, nil
+}
+
+// StatusHighwater reads the high-water mark of a single sqlite3_db_status
+// counter, identified by one of the sqlite3.SQLITE_DBSTATUS_* opcodes. Most
+// opcodes track no high-water mark and report zero. With reset set, the mark
+// is reset to the counter's current value.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) StatusHighwater(op int32, reset bool) (int64, error) {
+	bp := c.tls.Alloc(16)
+	defer c.tls.Free(16)
+
+	pCurrent, pHighwtr := bp, bp+8
+
+	var resetFlag int32
+	if reset {
+		resetFlag = 1
+	}
+	if rc := sqlite3.Xsqlite3_db_status(c.tls, c.db, op, pCurrent, pHighwtr, resetFlag); rc != sqlite3.SQLITE_OK {
+		return 0, c.errstr(rc)
+	}
+	return int64(*(*int32)(unsafe.Pointer(pHighwtr))), nil
+}
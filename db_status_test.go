@@ -0,0 +1,71 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestDBStatus(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(v);
+		insert into t values (1), (2), (3);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(), "select count(*) from t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	type statuser interface {
+		Status(reset bool) (*DBStatus, error)
+	}
+	status := func(reset bool) *DBStatus {
+		t.Helper()
+		var s *DBStatus
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			s, err = driverConn.(statuser).Status(reset)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	s := status(true)
+	if s.SchemaUsed <= 0 {
+		t.Errorf("SchemaUsed = %d, want > 0", s.SchemaUsed)
+	}
+	if s.CacheUsed <= 0 {
+		t.Errorf("CacheUsed = %d, want > 0", s.CacheUsed)
+	}
+	if s.CacheHit <= 0 {
+		t.Errorf("CacheHit = %d, want > 0", s.CacheHit)
+	}
+
+	// The read above reset the cache counters.
+	if s = status(false); s.CacheHit != 0 {
+		t.Errorf("CacheHit = %d after reset, want 0", s.CacheHit)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// ObjectStat summarizes the dbstat virtual table rows of one btree: a table
+// or an index. The library is built with SQLITE_ENABLE_DBSTAT_VTAB, so the
+// underlying dbstat table can also be queried directly for per-page detail.
+type ObjectStat struct {
+	// Name of the table or index.
+	Name string
+	// Type is "table" or "index", from the schema table.
+	Type string
+	// Pages is the number of btree pages of the object.
+	Pages int64
+	// Cells is the total number of cells (entries) over all pages.
+	Cells int64
+	// Payload is the total bytes of payload stored.
+	Payload int64
+	// Unused is the total bytes unused within the allocated pages.
+	Unused int64
+	// MaxPayload is the largest payload of a single cell.
+	MaxPayload int64
+	// Size is the total bytes allocated to the object's pages.
+	Size int64
+	// Fragmentation is the fraction of allocated bytes that is unused, in
+	// the range [0, 1]. Rebuilding fragmented objects with VACUUM or REINDEX
+	// reclaims that space.
+	Fragmentation float64
+}
+
+// DBStat aggregates the dbstat virtual table of the named schema (typically
+// "main") into one ObjectStat per table and index, ordered by name. It
+// answers where the bytes of a database file go and which objects would
+// benefit from a VACUUM.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) DBStat(schema string) ([]ObjectStat, error) {
+	if schema == "" {
+		schema = "main"
+	}
+	if !validPragmaName(schema) {
+		return nil, fmt.Errorf("invalid schema name %q", schema)
+	}
+
+	r, err := c.query(context.Background(), fmt.Sprintf(
+		`select d.name, coalesce(s.type, ''), count(*), sum(d.ncell), sum(d.payload),
+			sum(d.unused), max(d.mx_payload), sum(d.pgsize)
+		from %[1]q.dbstat d left join %[1]q.sqlite_master s on s.name = d.name
+		group by d.name order by d.name`, schema), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	rr := r.(*rows)
+	var stats []ObjectStat
+	dest := make([]driver.Value, len(rr.columns))
+	for {
+		if err := rr.Next(dest); err != nil {
+			if err == io.EOF {
+				return stats, nil
+			}
+			return nil, err
+		}
+
+		st := ObjectStat{
+			Name:       asString(dest[0]),
+			Type:       asString(dest[1]),
+			Pages:      asInt64(dest[2]),
+			Cells:      asInt64(dest[3]),
+			Payload:    asInt64(dest[4]),
+			Unused:     asInt64(dest[5]),
+			MaxPayload: asInt64(dest[6]),
+			Size:       asInt64(dest[7]),
+		}
+		if st.Size > 0 {
+			st.Fragmentation = float64(st.Unused) / float64(st.Size)
+		}
+		stats = append(stats, st)
+	}
+}
+
+func asInt64(v driver.Value) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	}
+	return 0
+}
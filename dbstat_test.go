@@ -0,0 +1,96 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestDBStat(t *testing.T) {
+	db, err := sql.Open(driverName, t.TempDir()+"/dbstat.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`create table t(a, b); create index t_a on t(a)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 500; i++ {
+		if _, err := db.Exec("insert into t values (?, ?)", i, strings.Repeat("x", 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	cn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	// The raw dbstat table is queryable directly.
+	var pages int
+	if err := cn.QueryRowContext(ctx, "select count(*) from dbstat where name = 't'").Scan(&pages); err != nil {
+		t.Fatal(err)
+	}
+
+	if pages == 0 {
+		t.Fatal("dbstat reports no pages for table t")
+	}
+
+	type dbstatter interface {
+		DBStat(schema string) ([]ObjectStat, error)
+	}
+	var stats []ObjectStat
+	if err := cn.Raw(func(driverConn interface{}) error {
+		var err error
+		stats, err = driverConn.(dbstatter).DBStat("")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]ObjectStat)
+	for _, st := range stats {
+		byName[st.Name] = st
+	}
+
+	tbl, ok := byName["t"]
+	if !ok {
+		t.Fatalf("no entry for table t in %v", stats)
+	}
+
+	if tbl.Type != "table" || tbl.Cells < 500 || tbl.Pages < 1 || tbl.Payload == 0 || tbl.Size < tbl.Payload {
+		t.Fatalf("implausible table stats: %+v", tbl)
+	}
+
+	if tbl.Fragmentation < 0 || tbl.Fragmentation > 1 {
+		t.Fatalf("fragmentation out of range: %v", tbl.Fragmentation)
+	}
+
+	idx, ok := byName["t_a"]
+	if !ok {
+		t.Fatalf("no entry for index t_a in %v", stats)
+	}
+
+	if idx.Type != "index" || idx.Cells < 500 {
+		t.Fatalf("implausible index stats: %+v", idx)
+	}
+
+	// Bogus schema names are rejected, not interpolated.
+	if err := cn.Raw(func(driverConn interface{}) error {
+		_, err := driverConn.(dbstatter).DBStat("main; drop table t")
+		return err
+	}); err == nil {
+		t.Fatal("expected an error for an invalid schema name")
+	}
+}
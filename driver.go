@@ -7,7 +7,9 @@ package sqlite // import "github.com/syralon/sqlite"
 import (
 	"database/sql/driver"
 	"fmt"
+	"sync"
 
+	sqlite3 "github.com/syralon/sqlite/lib"
 	"github.com/syralon/sqlite/vtab"
 )
 
@@ -22,6 +24,15 @@ type Driver struct {
 	// modules holds registered virtual table modules that should be added to
 	// every new connection on Open.
 	modules map[string]vtab.Module
+	// fts5Tokenizers holds registered FTS5 tokenizers that are added to every
+	// new connection on Open.
+	fts5Tokenizers map[string]*fts5TokenizerDesc
+	// profiler, when set, is the slow-statement profile callback installed on
+	// every new connection on Open.
+	profiler *profilerDesc
+	// walAutoCheckpoint, when set, is the WAL autocheckpoint frame threshold
+	// applied to every new connection on Open.
+	walAutoCheckpoint *int32
 }
 
 var d = &Driver{
@@ -29,6 +40,7 @@ var d = &Driver{
 	collations:      make(map[string]*collation, 0),
 	connectionHooks: make([]ConnectionHookFn, 0),
 	modules:         make(map[string]vtab.Module, 0),
+	fts5Tokenizers:  make(map[string]*fts5TokenizerDesc, 0),
 }
 
 func NewDriver() *Driver { return d }
@@ -52,10 +64,11 @@ func NewDriver() *Driver { return d }
 // information on supported PRAGMAs see: https://www.sqlite.org/pragma.html
 //
 // _time_format: The name of a format to use when writing time values to the
-// database. Currently the only supported value is "sqlite" for YYYY-MM-DD HH:MM:SS[+-]HH:MM,
+// database. The supported values are "sqlite" for YYYY-MM-DD HH:MM:SS[+-]HH:MM,
 // which corresponds to format 4 from https://www.sqlite.org/lang_datefunc.html#time_values,
-// including the timezone specifier. If this parameter is not specified, then
-// the default String() format will be used.
+// including the timezone specifier, and "rfc3339" for RFC 3339 with
+// nanosecond precision. If this parameter is not specified, then the default
+// String() format will be used.
 //
 // _time_integer_format: The name of a integer format to use when writing time values.
 // By default, the time is stored as string and the format can be set with _time_format
@@ -65,11 +78,23 @@ func NewDriver() *Driver { return d }
 // converted as integer and the _time_format value will be ignored.
 // Currently the supported value are "unix","unix_milli", "unix_micro" and "unix_nano",
 // which corresponds to seconds, milliseconds, microseconds or nanoseconds
-// since unixepoch (1 January 1970 00:00:00 UTC).
+// since unixepoch (1 January 1970 00:00:00 UTC), and "julian", which stores
+// the fractional julian day number as a REAL.
 //
 // _inttotime: Enable conversion of time column (DATE, DATETIME,TIMESTAMP) from integer
 // to time if the field contain integer (int64).
 //
+// _rawbytes: Scan TEXT and BLOB columns as []byte that borrows the statement's
+// buffer until the next row is fetched, avoiding a per-row copy. Intended for
+// read-heavy workloads that parse the data immediately, typically scanning
+// into sql.RawBytes; destinations that outlive the row must copy.
+//
+// _sharedcache: "1", "true" or "on" opens the connection in shared-cache mode
+// (SQLITE_OPEN_SHAREDCACHE); "0", "false" or "off" forces a private cache.
+// When absent, the process-wide default set by EnableSharedCache applies. On
+// URI filenames the standard cache=shared/cache=private parameter works as
+// well. See https://www.sqlite.org/sharedcache.html
+//
 // _txlock: The locking behavior to use when beginning a transaction. May be
 // "deferred" (the default), "immediate", or "exclusive" (case insensitive). See:
 // https://www.sqlite.org/lang_transaction.html#deferred_immediate_and_exclusive_transactions
@@ -84,6 +109,12 @@ func (d *Driver) Open(name string) (conn driver.Conn, err error) {
 		return nil, err
 	}
 
+	// Package-wide auto registrations come first so driver-level ones can
+	// override them.
+	if err = c.applyAutoRegistrations(); err != nil {
+		c.Close()
+		return nil, err
+	}
 	for _, udf := range d.udfs {
 		if err = c.createFunctionInternal(udf); err != nil {
 			c.Close()
@@ -96,6 +127,22 @@ func (d *Driver) Open(name string) (conn driver.Conn, err error) {
 			return nil, err
 		}
 	}
+	if err = c.registerFTS5Tokenizers(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if d.profiler != nil {
+		if err = c.registerProfiler(d.profiler); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if d.walAutoCheckpoint != nil {
+		if err = c.WalAutoCheckpoint(*d.walAutoCheckpoint); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
 	for _, connHookFn := range d.connectionHooks {
 		if err = connHookFn(c, name); err != nil {
 			c.Close()
@@ -118,3 +165,92 @@ func (d *Driver) Open(name string) (conn driver.Conn, err error) {
 func (d *Driver) RegisterConnectionHook(fn ConnectionHookFn) {
 	d.connectionHooks = append(d.connectionHooks, fn)
 }
+
+// autoRegistry holds vtab modules and collations that are installed on every
+// new connection regardless of which Driver instance opens it, in the spirit
+// of sqlite3_auto_extension. This reaches connections opened by Driver values
+// other than the package default, e.g. drivers registered under custom names
+// by code built on this package. Registrations only affect connections opened
+// afterwards.
+var autoRegistry struct {
+	mu         sync.Mutex
+	modules    map[string]vtab.Module
+	collations map[string]*collation
+}
+
+// AutoRegisterModule arranges for the vtab module m to be installed under
+// name on every connection opened after the call, by any Driver built on this
+// package. It fails when the name is already auto-registered.
+func AutoRegisterModule(name string, m vtab.Module) error {
+	autoRegistry.mu.Lock()
+	defer autoRegistry.mu.Unlock()
+
+	if _, ok := autoRegistry.modules[name]; ok {
+		return fmt.Errorf("sqlite: module %q already auto-registered", name)
+	}
+
+	if autoRegistry.modules == nil {
+		autoRegistry.modules = map[string]vtab.Module{}
+	}
+	autoRegistry.modules[name] = m
+	return nil
+}
+
+// AutoRegisterCollationUtf8 arranges for the collation to be created on every
+// connection opened after the call, by any Driver built on this package. The
+// impl contract is that of RegisterCollationUtf8. It fails when the name is
+// already auto-registered.
+func AutoRegisterCollationUtf8(zName string, impl func(left, right string) int) error {
+	autoRegistry.mu.Lock()
+	defer autoRegistry.mu.Unlock()
+
+	if _, ok := autoRegistry.collations[zName]; ok {
+		return fmt.Errorf("sqlite: collation %q already auto-registered", zName)
+	}
+
+	coll, err := newCollation(zName, impl, sqlite3.SQLITE_UTF8)
+	if err != nil {
+		return err
+	}
+
+	if autoRegistry.collations == nil {
+		autoRegistry.collations = map[string]*collation{}
+	}
+	autoRegistry.collations[zName] = coll
+	return nil
+}
+
+// applyAutoRegistrations installs the contents of autoRegistry on a freshly
+// opened connection.
+func (c *conn) applyAutoRegistrations() error {
+	autoRegistry.mu.Lock()
+	colls := make([]*collation, 0, len(autoRegistry.collations))
+	for _, coll := range autoRegistry.collations {
+		colls = append(colls, coll)
+	}
+	mods := make(map[string]vtab.Module, len(autoRegistry.modules))
+	for name, m := range autoRegistry.modules {
+		mods[name] = m
+	}
+	autoRegistry.mu.Unlock()
+
+	for _, coll := range colls {
+		if err := c.createCollationInternal(coll); err != nil {
+			return err
+		}
+	}
+	for name, m := range mods {
+		if err := c.registerSingleModule(name, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetWalAutoCheckpoint arranges for every connection subsequently opened by
+// the driver to have its WAL autocheckpoint threshold set to nFrames, as if
+// Conn.WalAutoCheckpoint had been called on it. Zero or a negative value
+// disables automatic checkpointing.
+func (d *Driver) SetWalAutoCheckpoint(nFrames int32) {
+	d.walAutoCheckpoint = &nFrames
+}
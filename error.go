@@ -10,8 +10,11 @@ import (
 
 // Error represents sqlite library error code.
 type Error struct {
-	msg  string
-	code int
+	msg      string
+	code     int
+	extended int
+	errno    int
+	offset   int
 }
 
 // Error implements error.
@@ -20,6 +23,44 @@ func (e *Error) Error() string { return e.msg }
 // Code returns the sqlite result code for this error.
 func (e *Error) Code() int { return e.code }
 
+// ExtendedCode returns the extended result code for this error, e.g.
+// SQLITE_BUSY_SNAPSHOT or SQLITE_IOERR_FSYNC, as reported by
+// sqlite3_extended_errcode. The low byte equals Code().
+func (e *Error) ExtendedCode() int { return e.extended }
+
+// SystemErrno returns the value of errno set by the operating system during
+// the most recent failed I/O operation, as reported by sqlite3_system_errno,
+// or 0 when the error did not originate in a system call.
+func (e *Error) SystemErrno() int { return e.errno }
+
+// IsLockContention reports whether the error means another connection holds a
+// conflicting lock (the SQLITE_BUSY and SQLITE_LOCKED families), i.e. the
+// operation may well succeed when retried.
+func (e *Error) IsLockContention() bool {
+	switch e.code & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
+
+// IsIOError reports whether the error originated in the storage layer (the
+// SQLITE_IOERR family, SQLITE_FULL or SQLITE_CANTOPEN), i.e. retrying is
+// unlikely to help without operator intervention.
+func (e *Error) IsIOError() bool {
+	switch e.code & 0xff {
+	case sqlite3.SQLITE_IOERR, sqlite3.SQLITE_FULL, sqlite3.SQLITE_CANTOPEN:
+		return true
+	}
+	return false
+}
+
+// Offset returns the byte offset into the SQL text at which the error
+// occurred, as reported by sqlite3_error_offset, or -1 when the error has no
+// associated position. Tools that accept user-written SQL can use it to point
+// at the exact character where parsing failed.
+func (e *Error) Offset() int { return e.offset }
+
 var (
 	// ErrorCodeString maps Error.Code() to its string representation.
 	ErrorCodeString = map[int]string{
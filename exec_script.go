@@ -0,0 +1,80 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// StatementResult reports the outcome of a single statement of a script
+// executed with Conn.ExecScript.
+type StatementResult struct {
+	// SQL is the text of the statement, as prepared.
+	SQL string
+	// LastInsertID is the connection's last insert rowid after the statement
+	// finished.
+	LastInsertID int64
+	// RowsAffected is the number of rows the statement modified, inserted or
+	// deleted.
+	RowsAffected int64
+}
+
+// ExecScript runs every statement of a multi-statement SQL script and returns
+// a result per statement, unlike Exec which only reports the outcome of the
+// last one. Execution stops at the first failing statement; the results of
+// the statements that already completed are returned alongside the error, so
+// callers such as migration runners can tell how far the script got.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) ExecScript(script string) ([]StatementResult, error) {
+	psql, err := libc.CString(script)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(psql)
+
+	var results []StatementResult
+	for p := psql; *(*byte)(unsafe.Pointer(p)) != 0; {
+		pstmt, err := c.prepareV2(&p)
+		if err != nil {
+			return results, fmt.Errorf("statement %d: %w", len(results)+1, err)
+		}
+
+		if pstmt == 0 { // comments or whitespace only
+			continue
+		}
+
+		sql := libc.GoString(sqlite3.Xsqlite3_sql(c.tls, pstmt))
+		rc, err := c.step(pstmt)
+		if err == nil {
+			switch rc & 0xff {
+			case sqlite3.SQLITE_DONE, sqlite3.SQLITE_ROW:
+				// nop
+			default:
+				err = c.errstr(int32(rc))
+			}
+		}
+
+		if e := c.finalize(pstmt); err == nil && e != nil {
+			err = e
+		}
+
+		if err != nil {
+			return results, fmt.Errorf("statement %d (%s): %w", len(results)+1, sql, err)
+		}
+
+		results = append(results, StatementResult{
+			SQL:          sql,
+			LastInsertID: sqlite3.Xsqlite3_last_insert_rowid(c.tls, c.db),
+			RowsAffected: sqlite3.Xsqlite3_changes64(c.tls, c.db),
+		})
+	}
+	return results, nil
+}
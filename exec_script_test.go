@@ -0,0 +1,106 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type scriptExecer interface {
+		ExecScript(script string) ([]StatementResult, error)
+	}
+	execScript := func(script string) ([]StatementResult, error) {
+		var results []StatementResult
+		err := cn.Raw(func(driverConn any) error {
+			var err error
+			results, err = driverConn.(scriptExecer).ExecScript(script)
+			return err
+		})
+		return results, err
+	}
+
+	results, err := execScript(`
+		create table t(id integer primary key, v);
+		-- a comment between statements
+		insert into t values (7, 'a');
+		insert into t values (8, 'b'), (9, 'c');
+		update t set v = 'x';
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+
+	for i, want := range []struct {
+		sqlPrefix    string
+		lastInsertID int64
+		rowsAffected int64
+	}{
+		{"create table", 0, 0},
+		{"insert", 7, 1},
+		{"insert", 9, 2},
+		{"update", 9, 3},
+	} {
+		got := results[i]
+		if !strings.Contains(got.SQL, want.sqlPrefix) {
+			t.Errorf("result %d: SQL %q, want it to contain %q", i, got.SQL, want.sqlPrefix)
+		}
+		if got.LastInsertID != want.lastInsertID {
+			t.Errorf("result %d: LastInsertID %d, want %d", i, got.LastInsertID, want.lastInsertID)
+		}
+		if got.RowsAffected != want.rowsAffected {
+			t.Errorf("result %d: RowsAffected %d, want %d", i, got.RowsAffected, want.rowsAffected)
+		}
+	}
+
+	// A failing statement stops the script; completed results are returned
+	// with the error.
+	results, err = execScript(`
+		insert into t values (10, 'd');
+		insert into nosuch values (1);
+		insert into t values (11, 'e');
+	`)
+	if err == nil {
+		t.Fatal("expected error from failing statement")
+	}
+
+	if !strings.Contains(err.Error(), "statement 2") {
+		t.Errorf("error %q does not identify the failing statement", err)
+	}
+
+	if len(results) != 1 || results[0].LastInsertID != 10 {
+		t.Fatalf("got %+v, want the one completed insert", results)
+	}
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(), "select count(*) from t where id = 11").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatal("statement after the failure was executed")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package export streams the results of a database/sql query to an io.Writer
+// as a JSON array, newline delimited JSON (NDJSON), or CSV. Rows are encoded
+// one at a time as they are fetched, so memory use stays flat and the
+// consumer's write speed provides natural backpressure; HTTP data endpoints
+// can hand a query straight to a response body without hand-rolling encoders.
+package export // import "github.com/syralon/sqlite/export"
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// BlobEncoding selects how BLOB column values are rendered.
+type BlobEncoding int
+
+const (
+	// Base64 renders blobs using standard base64 encoding. It is the default.
+	Base64 BlobEncoding = iota
+	// Hex renders blobs as lower case hexadecimal.
+	Hex
+)
+
+// Options control value rendering. The zero value (and a nil *Options) uses
+// base64 blobs, RFC 3339 timestamps with nanoseconds, an empty string for
+// NULL in CSV output, and a CSV header row.
+type Options struct {
+	// BlobEncoding selects the rendering of []byte values.
+	BlobEncoding BlobEncoding
+	// TimeFormat is the time.Time layout used for temporal values. Empty
+	// means time.RFC3339Nano.
+	TimeFormat string
+	// NullValue is the text written for NULL values in CSV output. JSON
+	// formats always use null.
+	NullValue string
+	// NoHeader suppresses the CSV header row.
+	NoHeader bool
+}
+
+func (o *Options) timeFormat() string {
+	if o != nil && o.TimeFormat != "" {
+		return o.TimeFormat
+	}
+	return time.RFC3339Nano
+}
+
+func (o *Options) blob(b []byte) string {
+	if o != nil && o.BlobEncoding == Hex {
+		return hex.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// jsonValue converts a scanned driver value into a value the encoding/json
+// package renders the way the options ask for.
+func (o *Options) jsonValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return o.blob(v)
+	case time.Time:
+		return v.Format(o.timeFormat())
+	default:
+		return v
+	}
+}
+
+// csvValue converts a scanned driver value into its CSV cell text.
+func (o *Options) csvValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		if o != nil {
+			return o.NullValue
+		}
+		return ""
+	case []byte:
+		return o.blob(v)
+	case time.Time:
+		return v.Format(o.timeFormat())
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// scanRow scans the current row of rows into a fresh []any.
+func scanRow(rows *sql.Rows, n int) ([]any, error) {
+	vals := make([]any, n)
+	ptrs := make([]any, n)
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// JSON writes all rows as one JSON array of objects keyed by column name.
+// Rows are encoded and written one at a time. Note that duplicate column
+// names collapse to a single JSON member; alias columns in the query if that
+// matters.
+func JSON(w io.Writer, rows *sql.Rows, opts *Options) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+	for rows.Next() {
+		vals, err := scanRow(rows, len(cols))
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		obj := make(map[string]any, len(cols))
+		for i, c := range cols {
+			obj[c] = opts.jsonValue(vals[i])
+		}
+		// json.Encoder terminates each value with a newline, which doubles as
+		// the array's element separator whitespace.
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
+
+// NDJSON writes each row as one JSON object per line, keyed by column name.
+// The same duplicate column name caveat as for JSON applies.
+func NDJSON(w io.Writer, rows *sql.Rows, opts *Options) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for rows.Next() {
+		vals, err := scanRow(rows, len(cols))
+		if err != nil {
+			return err
+		}
+
+		obj := make(map[string]any, len(cols))
+		for i, c := range cols {
+			obj[c] = opts.jsonValue(vals[i])
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CSV writes all rows as CSV, preceded by a header row of column names unless
+// Options.NoHeader is set.
+func CSV(w io.Writer, rows *sql.Rows, opts *Options) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if opts == nil || !opts.NoHeader {
+		if err := cw.Write(cols); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(cols))
+	for rows.Next() {
+		vals, err := scanRow(rows, len(cols))
+		if err != nil {
+			return err
+		}
+
+		for i := range vals {
+			record[i] = opts.csvValue(vals[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
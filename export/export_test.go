@@ -0,0 +1,117 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export // import "github.com/syralon/sqlite/export"
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/export.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`
+		create table t(id integer primary key, name text, score real, data blob);
+		insert into t values (1, 'alice', 1.5, x'00ff');
+		insert into t values (2, null, null, null);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func query(t *testing.T, db *sql.DB) *sql.Rows {
+	t.Helper()
+
+	rows, err := db.Query("select id, name, score, data from t order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestJSON(t *testing.T) {
+	db := testDB(t)
+	rows := query(t, db)
+
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	if err := JSON(&buf, rows, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d rows, want 2", len(got))
+	}
+
+	if got[0]["name"] != "alice" || got[0]["data"] != "AP8=" {
+		t.Fatalf("unexpected first row: %v", got[0])
+	}
+
+	if got[1]["name"] != nil || got[1]["score"] != nil {
+		t.Fatalf("expected nulls in second row: %v", got[1])
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	db := testDB(t)
+	rows := query(t, db)
+
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	if err := NDJSON(&buf, rows, &Options{BlobEncoding: Hex}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid NDJSON line %q: %v", lines[0], err)
+	}
+
+	if first["data"] != "00ff" {
+		t.Fatalf("got blob %v, want hex 00ff", first["data"])
+	}
+}
+
+func TestCSV(t *testing.T) {
+	db := testDB(t)
+	rows := query(t, db)
+
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	if err := CSV(&buf, rows, &Options{NullValue: "NULL"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,name,score,data\n1,alice,1.5,AP8=\n2,NULL,NULL,NULL\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
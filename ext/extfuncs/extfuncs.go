@@ -0,0 +1,463 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package extfuncs registers the classic "extension-functions.c" function
+// pack as Go user-defined functions, for compatibility with databases and
+// queries written against that widely deployed C extension: math functions
+// like floor, ceil and power, string helpers like reverse, proper, padl and
+// charindex, and the stdev/variance/median family of aggregates.
+//
+// Call Register once before opening connections:
+//
+//	extfuncs.Register()
+//	db, _ := sql.Open("sqlite3", ...)
+//
+// Like all driver-level registrations, the functions are available on
+// connections opened after the call.
+package extfuncs // import "github.com/syralon/sqlite/ext/extfuncs"
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/syralon/sqlite"
+)
+
+var registerOnce sync.Once
+
+// Register registers the function pack on the driver. It is safe to call
+// more than once; only the first call takes effect.
+func Register() {
+	registerOnce.Do(register)
+}
+
+func register() {
+	for name, f := range map[string]func(x float64) float64{
+		"acos":    math.Acos,
+		"asin":    math.Asin,
+		"atan":    math.Atan,
+		"acosh":   math.Acosh,
+		"asinh":   math.Asinh,
+		"atanh":   math.Atanh,
+		"cos":     math.Cos,
+		"sin":     math.Sin,
+		"tan":     math.Tan,
+		"cosh":    math.Cosh,
+		"sinh":    math.Sinh,
+		"tanh":    math.Tanh,
+		"cot":     func(x float64) float64 { return 1 / math.Tan(x) },
+		"coth":    func(x float64) float64 { return 1 / math.Tanh(x) },
+		"exp":     math.Exp,
+		"log":     math.Log,
+		"log10":   math.Log10,
+		"sqrt":    math.Sqrt,
+		"ceil":    math.Ceil,
+		"floor":   math.Floor,
+		"degrees": func(x float64) float64 { return x * 180 / math.Pi },
+		"radians": func(x float64) float64 { return x * math.Pi / 180 },
+		"square":  func(x float64) float64 { return x * x },
+		"sign": func(x float64) float64 {
+			switch {
+			case x > 0:
+				return 1
+			case x < 0:
+				return -1
+			}
+			return 0
+		},
+	} {
+		mustRegisterMath1(name, f)
+	}
+
+	sqlite.MustRegisterDeterministicScalarFunction("pi", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return math.Pi, nil
+	})
+
+	for name, f := range map[string]func(x, y float64) float64{
+		"power": math.Pow,
+		"atn2":  math.Atan2,
+		"atan2": math.Atan2,
+	} {
+		mustRegisterMath2(name, f)
+	}
+
+	mustRegisterString1("reverse", reverse)
+	mustRegisterString1("proper", proper)
+
+	sqlite.MustRegisterDeterministicScalarFunction("replicate", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := toString(args[0])
+		if !ok {
+			return nil, nil
+		}
+		n, ok := toInt(args[1])
+		if !ok || n < 0 {
+			return nil, nil
+		}
+		return strings.Repeat(s, int(n)), nil
+	})
+
+	sqlite.MustRegisterDeterministicScalarFunction("charindex", -1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("wrong number of arguments to function charindex()")
+		}
+		sub, ok1 := toString(args[0])
+		s, ok2 := toString(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		start := 0
+		if len(args) == 3 {
+			n, ok := toInt(args[2])
+			if !ok {
+				return nil, nil
+			}
+			if start = int(n) - 1; start < 0 {
+				start = 0
+			}
+		}
+		r := []rune(s)
+		if start > len(r) {
+			return int64(0), nil
+		}
+		i := strings.Index(string(r[start:]), sub)
+		if i < 0 {
+			return int64(0), nil
+		}
+		return int64(start + len([]rune(string(r[start:])[:i])) + 1), nil
+	})
+
+	sqlite.MustRegisterDeterministicScalarFunction("leftstr", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok1 := toString(args[0])
+		n, ok2 := toInt(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		r := []rune(s)
+		if n < 0 {
+			n = 0
+		}
+		if int(n) > len(r) {
+			n = int64(len(r))
+		}
+		return string(r[:n]), nil
+	})
+
+	sqlite.MustRegisterDeterministicScalarFunction("rightstr", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok1 := toString(args[0])
+		n, ok2 := toInt(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		r := []rune(s)
+		if n < 0 {
+			n = 0
+		}
+		if int(n) > len(r) {
+			n = int64(len(r))
+		}
+		return string(r[len(r)-int(n):]), nil
+	})
+
+	sqlite.MustRegisterDeterministicScalarFunction("padl", 2, padFunc(func(s, pad string) string { return pad + s }))
+	sqlite.MustRegisterDeterministicScalarFunction("padr", 2, padFunc(func(s, pad string) string { return s + pad }))
+	sqlite.MustRegisterDeterministicScalarFunction("padc", 2, padFunc(func(s, pad string) string {
+		left := len(pad) / 2
+		return pad[:left] + s + pad[left:]
+	}))
+
+	sqlite.MustRegisterDeterministicScalarFunction("strfilter", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok1 := toString(args[0])
+		keep, ok2 := toString(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune(keep, r) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String(), nil
+	})
+
+	sqlite.MustRegisterFunction("stdev", &sqlite.FunctionImpl{
+		NArgs:         1,
+		Deterministic: true,
+		MakeAggregate: makeVarianceAggregate(true),
+	})
+	sqlite.MustRegisterFunction("variance", &sqlite.FunctionImpl{
+		NArgs:         1,
+		Deterministic: true,
+		MakeAggregate: makeVarianceAggregate(false),
+	})
+	for name, q := range map[string]float64{
+		"median":         0.5,
+		"lower_quartile": 0.25,
+		"upper_quartile": 0.75,
+	} {
+		q := q
+		sqlite.MustRegisterFunction(name, &sqlite.FunctionImpl{
+			NArgs:         1,
+			Deterministic: true,
+			MakeAggregate: func(ctx sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+				return &quantileAggregate{q: q}, nil
+			},
+		})
+	}
+	sqlite.MustRegisterFunction("mode", &sqlite.FunctionImpl{
+		NArgs:         1,
+		Deterministic: true,
+		MakeAggregate: func(ctx sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+			return &modeAggregate{counts: make(map[float64]int)}, nil
+		},
+	})
+}
+
+func mustRegisterMath1(name string, f func(x float64) float64) {
+	sqlite.MustRegisterDeterministicScalarFunction(name, 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		x, ok := toFloat(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return f(x), nil
+	})
+}
+
+func mustRegisterMath2(name string, f func(x, y float64) float64) {
+	sqlite.MustRegisterDeterministicScalarFunction(name, 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		x, ok1 := toFloat(args[0])
+		y, ok2 := toFloat(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		return f(x, y), nil
+	})
+}
+
+func mustRegisterString1(name string, f func(s string) string) {
+	sqlite.MustRegisterDeterministicScalarFunction(name, 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := toString(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return f(s), nil
+	})
+}
+
+// padFunc builds a pad* implementation from the way combine places the
+// padding relative to the string.
+func padFunc(combine func(s, pad string) string) func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	return func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok1 := toString(args[0])
+		n, ok2 := toInt(args[1])
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		if pad := int(n) - len([]rune(s)); pad > 0 {
+			return combine(s, strings.Repeat(" ", pad)), nil
+		}
+		return s, nil
+	}
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// proper capitalizes the first letter of every word and lowercases the rest,
+// like the PROPER() spreadsheet function.
+func proper(s string) string {
+	var b strings.Builder
+	startOfWord := true
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z' && startOfWord:
+			b.WriteRune(r - 'a' + 'A')
+			startOfWord = false
+		case r >= 'A' && r <= 'Z' && !startOfWord:
+			b.WriteRune(r - 'A' + 'a')
+		default:
+			b.WriteRune(r)
+			startOfWord = !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}
+	}
+	return b.String()
+}
+
+// varianceAggregate accumulates sample variance using Welford's method, so a
+// single pass is numerically stable. With stdev set, WindowValue reports the
+// square root.
+type varianceAggregate struct {
+	n     int64
+	mean  float64
+	m2    float64
+	stdev bool
+}
+
+func makeVarianceAggregate(stdev bool) func(ctx sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+	return func(ctx sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+		return &varianceAggregate{stdev: stdev}, nil
+	}
+}
+
+func (a *varianceAggregate) Step(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	x, ok := toFloat(args[0])
+	if !ok {
+		return nil
+	}
+	a.n++
+	d := x - a.mean
+	a.mean += d / float64(a.n)
+	a.m2 += d * (x - a.mean)
+	return nil
+}
+
+func (a *varianceAggregate) WindowInverse(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	x, ok := toFloat(args[0])
+	if !ok {
+		return nil
+	}
+	a.n--
+	if a.n == 0 {
+		a.mean, a.m2 = 0, 0
+		return nil
+	}
+	d := x - a.mean
+	a.mean -= d / float64(a.n)
+	a.m2 -= d * (x - a.mean)
+	return nil
+}
+
+func (a *varianceAggregate) WindowValue(ctx *sqlite.FunctionContext) (driver.Value, error) {
+	if a.n < 2 {
+		return nil, nil
+	}
+	v := a.m2 / float64(a.n-1)
+	if a.stdev {
+		v = math.Sqrt(v)
+	}
+	return v, nil
+}
+
+func (a *varianceAggregate) Final(ctx *sqlite.FunctionContext) {}
+
+// quantileAggregate collects all values and reports the q-quantile with
+// linear interpolation between the two nearest ranks, matching the median,
+// lower_quartile and upper_quartile functions of extension-functions.c.
+type quantileAggregate struct {
+	q    float64
+	vals []float64
+}
+
+func (a *quantileAggregate) Step(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	if x, ok := toFloat(args[0]); ok {
+		a.vals = append(a.vals, x)
+	}
+	return nil
+}
+
+func (a *quantileAggregate) WindowInverse(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	if x, ok := toFloat(args[0]); ok {
+		for i, v := range a.vals {
+			if v == x {
+				a.vals = append(a.vals[:i], a.vals[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (a *quantileAggregate) WindowValue(ctx *sqlite.FunctionContext) (driver.Value, error) {
+	if len(a.vals) == 0 {
+		return nil, nil
+	}
+	s := append([]float64(nil), a.vals...)
+	sort.Float64s(s)
+	pos := a.q * float64(len(s)-1)
+	i := int(pos)
+	if i == len(s)-1 {
+		return s[i], nil
+	}
+	frac := pos - float64(i)
+	return s[i] + frac*(s[i+1]-s[i]), nil
+}
+
+func (a *quantileAggregate) Final(ctx *sqlite.FunctionContext) {}
+
+// modeAggregate reports the most frequent value; ties resolve to the
+// smallest.
+type modeAggregate struct {
+	counts map[float64]int
+}
+
+func (a *modeAggregate) Step(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	if x, ok := toFloat(args[0]); ok {
+		a.counts[x]++
+	}
+	return nil
+}
+
+func (a *modeAggregate) WindowInverse(ctx *sqlite.FunctionContext, args []driver.Value) error {
+	if x, ok := toFloat(args[0]); ok {
+		if a.counts[x]--; a.counts[x] <= 0 {
+			delete(a.counts, x)
+		}
+	}
+	return nil
+}
+
+func (a *modeAggregate) WindowValue(ctx *sqlite.FunctionContext) (driver.Value, error) {
+	if len(a.counts) == 0 {
+		return nil, nil
+	}
+	var best float64
+	bestN := -1
+	for v, n := range a.counts {
+		if n > bestN || n == bestN && v < best {
+			best, bestN = v, n
+		}
+	}
+	return best, nil
+}
+
+func (a *modeAggregate) Final(ctx *sqlite.FunctionContext) {}
+
+func toFloat(v driver.Value) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func toInt(v driver.Value) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case float64:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+func toString(v driver.Value) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	}
+	return "", false
+}
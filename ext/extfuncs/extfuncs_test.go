@@ -0,0 +1,111 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package extfuncs // import "github.com/syralon/sqlite/ext/extfuncs"
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestExtensionFunctions(t *testing.T) {
+	Register()
+	Register() // idempotent
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/extfuncs.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	for _, test := range []struct {
+		query string
+		want  string
+	}{
+		{"select floor(3.7)", "3"},
+		{"select ceil(3.2)", "4"},
+		{"select power(2, 10)", "1024"},
+		{"select sign(-5)", "-1"},
+		{"select square(9)", "81"},
+		{"select reverse('abcde')", "edcba"},
+		{"select proper('hello WORLD')", "Hello World"},
+		{"select padl('ab', 5)", "   ab"},
+		{"select padr('ab', 5)", "ab   "},
+		{"select padc('ab', 6)", "  ab  "},
+		{"select replicate('ab', 3)", "ababab"},
+		{"select charindex('cd', 'abcdcd')", "3"},
+		{"select charindex('cd', 'abcdcd', 4)", "5"},
+		{"select leftstr('abcdef', 2)", "ab"},
+		{"select rightstr('abcdef', 2)", "ef"},
+		{"select strfilter('a1b2c3', 'abc')", "abc"},
+	} {
+		var got string
+		if err := db.QueryRow(test.query).Scan(&got); err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		}
+
+		if got != test.want {
+			t.Fatalf("%s: got %q, want %q", test.query, got, test.want)
+		}
+	}
+
+	// NULL propagation.
+	var null any
+	if err := db.QueryRow("select floor(null)").Scan(&null); err != nil {
+		t.Fatal(err)
+	}
+
+	if null != nil {
+		t.Fatalf("floor(null) = %v, want NULL", null)
+	}
+
+	if _, err := db.Exec("create table nums(x); insert into nums values (2), (4), (4), (4), (5), (5), (7), (9)"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		query string
+		want  float64
+	}{
+		{"select stdev(x) from nums", 2.138089935299395},
+		{"select variance(x) from nums", 4.571428571428571},
+		{"select median(x) from nums", 4.5},
+		{"select lower_quartile(x) from nums", 4},
+		{"select upper_quartile(x) from nums", 5.5},
+		{"select mode(x) from nums", 4},
+	} {
+		var got float64
+		if err := db.QueryRow(test.query).Scan(&got); err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		}
+
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Fatalf("%s: got %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	// Aggregates work as window functions too.
+	rows, err := db.Query("select stdev(x) over (order by rowid rows between 1 preceding and current row) from nums")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 8 {
+		t.Fatalf("window query returned %d rows, want 8", n)
+	}
+}
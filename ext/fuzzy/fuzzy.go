@@ -0,0 +1,456 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fuzzy provides a spellfix1-style fuzzy search virtual table
+// implemented in Go, so typo-tolerant lookups work without the C spellfix1
+// extension.
+//
+// A fuzzy table stores (word, rank) pairs. Querying it with MATCH returns the
+// stored words that are closest to the query term by weighted edit distance,
+// with a phonetic hash used as a secondary signal, best matches first:
+//
+//	CREATE VIRTUAL TABLE dict USING fuzzy;
+//	INSERT INTO dict(word, rank) VALUES ('kennedy', 100);
+//	SELECT word, distance, score FROM dict WHERE word MATCH 'kenedy';
+//
+// The hidden top column limits the number of candidates returned, e.g.
+// WHERE word MATCH 'kenedy' AND top = 5. The default is 20.
+package fuzzy // import "github.com/syralon/sqlite/ext/fuzzy"
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// defaultTop is the number of candidates returned by a MATCH query when the
+// hidden top column is not constrained.
+const defaultTop = 20
+
+// CostTable configures the weighted edit distance used to rank candidates.
+// Costs are in centi-units, following spellfix1's convention where 100 is the
+// cost of an ordinary insertion or deletion.
+type CostTable struct {
+	// Insert is the cost of inserting a rune.
+	Insert int
+	// Delete is the cost of deleting a rune.
+	Delete int
+	// Substitute is the cost of substituting one rune for another.
+	Substitute int
+	// Pairs optionally overrides Substitute for specific rune pairs. The key
+	// is a two-rune string "xy" meaning "x in the query matched against y in
+	// the stored word". Use it to make common confusions cheap, e.g.
+	// Pairs["sz"] = 40.
+	Pairs map[string]int
+}
+
+// DefaultCosts returns the cost table used when a Module is created without
+// one. The values match spellfix1's defaults.
+func DefaultCosts() *CostTable {
+	return &CostTable{Insert: 100, Delete: 100, Substitute: 150}
+}
+
+func (ct *CostTable) insCost() int { return ct.Insert }
+func (ct *CostTable) delCost() int { return ct.Delete }
+
+func (ct *CostTable) subCost(a, b rune) int {
+	if a == b {
+		return 0
+	}
+	if ct.Pairs != nil {
+		if c, ok := ct.Pairs[string([]rune{a, b})]; ok {
+			return c
+		}
+	}
+	return ct.Substitute
+}
+
+// Module implements vtab.Module for fuzzy search tables. The zero value is
+// ready to use and applies DefaultCosts.
+type Module struct {
+	// Costs configures the edit-distance weights shared by all tables created
+	// through this module instance. If nil, DefaultCosts() is used.
+	Costs *CostTable
+}
+
+// Register registers a fuzzy search module named name. Registration applies to
+// new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string, m *Module) error {
+	if m == nil {
+		m = &Module{}
+	}
+	return vtab.RegisterModule(db, name, m)
+}
+
+func (m *Module) costs() *CostTable {
+	if m.Costs != nil {
+		return m.Costs
+	}
+	return DefaultCosts()
+}
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(
+		word TEXT,
+		rank INTEGER,
+		distance INTEGER,
+		score INTEGER,
+		matchlen INTEGER,
+		phonehash TEXT,
+		top INTEGER HIDDEN
+	)`); err != nil {
+		return nil, err
+	}
+	return &table{costs: m.costs(), entries: make(map[int64]*entry)}, nil
+}
+
+// Connect implements vtab.Module. The index lives in memory, so Connect is
+// the same as Create.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+// Column indexes of the declared schema.
+const (
+	colWord = iota
+	colRank
+	colDistance
+	colScore
+	colMatchlen
+	colPhonehash
+	colTop
+)
+
+// Index plan numbers chosen in BestIndex.
+const (
+	planFullScan = 0
+	planMatch    = 1 // argv[0] = MATCH rhs
+	planMatchTop = 3 // argv[0] = MATCH rhs, argv[1] = top
+)
+
+type entry struct {
+	word string
+	rank int64
+	hash string
+}
+
+type table struct {
+	costs *CostTable
+
+	mu        sync.RWMutex
+	entries   map[int64]*entry
+	nextRowid int64
+}
+
+// BestIndex implements vtab.Table. It recognizes MATCH on the word column and
+// an optional EQ constraint on the hidden top column.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	match, top := -1, -1
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable {
+			continue
+		}
+		if c.Column == colWord && c.Op == vtab.OpMATCH && match < 0 {
+			match = i
+		}
+		if c.Column == colTop && c.Op == vtab.OpEQ && top < 0 {
+			top = i
+		}
+	}
+	plan := planFullScan
+	if match >= 0 {
+		info.Constraints[match].ArgIndex = 0
+		info.Constraints[match].Omit = true
+		plan = planMatch
+		if top >= 0 {
+			info.Constraints[top].ArgIndex = 1
+			info.Constraints[top].Omit = true
+			plan = planMatchTop
+		}
+	}
+	info.IdxNum = int64(plan)
+	if plan != planFullScan {
+		info.EstimatedCost = 1e4
+		info.EstimatedRows = defaultTop
+	} else {
+		info.EstimatedCost = 1e6
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+// Insert implements vtab.Updater.
+func (t *table) Insert(cols []vtab.Value, rowid *int64) error {
+	word, err := wordValue(cols)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if *rowid == 0 {
+		t.nextRowid++
+		*rowid = t.nextRowid
+	} else if *rowid > t.nextRowid {
+		t.nextRowid = *rowid
+	}
+	t.entries[*rowid] = &entry{word: word, rank: rankValue(cols), hash: phoneticHash(word)}
+	return nil
+}
+
+// Update implements vtab.Updater.
+func (t *table) Update(oldRowid int64, cols []vtab.Value, newRowid *int64) error {
+	word, err := wordValue(cols)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.entries[oldRowid]; !ok {
+		return fmt.Errorf("fuzzy: no row %d", oldRowid)
+	}
+	rowid := oldRowid
+	if *newRowid != 0 && *newRowid != oldRowid {
+		delete(t.entries, oldRowid)
+		rowid = *newRowid
+	}
+	t.entries[rowid] = &entry{word: word, rank: rankValue(cols), hash: phoneticHash(word)}
+	return nil
+}
+
+// Delete implements vtab.Updater.
+func (t *table) Delete(oldRowid int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, oldRowid)
+	return nil
+}
+
+func wordValue(cols []vtab.Value) (string, error) {
+	if len(cols) <= colWord {
+		return "", fmt.Errorf("fuzzy: missing word column")
+	}
+	word, ok := cols[colWord].(string)
+	if !ok || word == "" {
+		return "", fmt.Errorf("fuzzy: word must be a non-empty string, got %T", cols[colWord])
+	}
+	return word, nil
+}
+
+func rankValue(cols []vtab.Value) int64 {
+	if len(cols) > colRank {
+		if rank, ok := cols[colRank].(int64); ok {
+			return rank
+		}
+	}
+	return 1
+}
+
+// result is one row produced by a MATCH query (or a full scan, in which case
+// the match related columns are zero).
+type result struct {
+	rowid    int64
+	word     string
+	rank     int64
+	distance int
+	score    int
+	matchlen int
+	hash     string
+}
+
+type cursor struct {
+	t    *table
+	rows []result
+	pos  int
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.pos = 0
+	c.rows = c.rows[:0]
+
+	if idxNum == planFullScan {
+		c.t.mu.RLock()
+		for rowid, e := range c.t.entries {
+			c.rows = append(c.rows, result{rowid: rowid, word: e.word, rank: e.rank, hash: e.hash})
+		}
+		c.t.mu.RUnlock()
+		sort.Slice(c.rows, func(i, j int) bool { return c.rows[i].rowid < c.rows[j].rowid })
+		return nil
+	}
+
+	if len(vals) == 0 {
+		return fmt.Errorf("fuzzy: MATCH argument missing")
+	}
+	query, ok := vals[0].(string)
+	if !ok {
+		return fmt.Errorf("fuzzy: MATCH argument must be a string, got %T", vals[0])
+	}
+	top := defaultTop
+	if idxNum == planMatchTop && len(vals) > 1 {
+		if n, ok := vals[1].(int64); ok && n > 0 {
+			top = int(n)
+		}
+	}
+
+	query = strings.ToLower(query)
+	qHash := phoneticHash(query)
+
+	c.t.mu.RLock()
+	for rowid, e := range c.t.entries {
+		dWord := editDistance(query, strings.ToLower(e.word), c.t.costs)
+		dPhone := editDistance(qHash, e.hash, c.t.costs)
+		// Words that sound alike rank ahead of words that merely look alike,
+		// matching spellfix1's behavior of scoring over the phonetic hash.
+		score := dWord + 2*dPhone
+		c.rows = append(c.rows, result{
+			rowid:    rowid,
+			word:     e.word,
+			rank:     e.rank,
+			distance: dWord,
+			score:    score,
+			matchlen: len(e.word),
+			hash:     e.hash,
+		})
+	}
+	c.t.mu.RUnlock()
+
+	sort.Slice(c.rows, func(i, j int) bool {
+		a, b := &c.rows[i], &c.rows[j]
+		if a.score != b.score {
+			return a.score < b.score
+		}
+		if a.rank != b.rank {
+			return a.rank > b.rank // higher rank wins ties
+		}
+		return a.word < b.word
+	})
+	if len(c.rows) > top {
+		c.rows = c.rows[:top]
+	}
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	if c.pos < len(c.rows) {
+		c.pos++
+	}
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.pos >= len(c.rows) }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	if c.pos >= len(c.rows) {
+		return nil, nil
+	}
+	r := &c.rows[c.pos]
+	switch col {
+	case colWord:
+		return r.word, nil
+	case colRank:
+		return r.rank, nil
+	case colDistance:
+		return int64(r.distance), nil
+	case colScore:
+		return int64(r.score), nil
+	case colMatchlen:
+		return int64(r.matchlen), nil
+	case colPhonehash:
+		return r.hash, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) {
+	if c.pos >= len(c.rows) {
+		return 0, nil
+	}
+	return c.rows[c.pos].rowid, nil
+}
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
+
+// editDistance computes the weighted Levenshtein distance between a and b
+// using ct's costs.
+func editDistance(a, b string, ct *CostTable) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := 1; j <= len(rb); j++ {
+		prev[j] = prev[j-1] + ct.insCost()
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = prev[0] + ct.delCost()
+		for j := 1; j <= len(rb); j++ {
+			del := prev[j] + ct.delCost()
+			ins := cur[j-1] + ct.insCost()
+			sub := prev[j-1] + ct.subCost(ra[i-1], rb[j-1])
+			cur[j] = min(del, min(ins, sub))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// phoneticHash reduces a word to a soundex-like class string so words that
+// sound alike hash alike: vowels and the silent h/w/y collapse to 'A',
+// consonants map to one of a few sound classes, and runs of the same class
+// are collapsed.
+func phoneticHash(word string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range strings.ToLower(word) {
+		var class rune
+		switch r {
+		case 'a', 'e', 'i', 'o', 'u', 'h', 'w', 'y':
+			class = 'A'
+		case 'b', 'p', 'v', 'f':
+			class = 'B'
+		case 'c', 'g', 'j', 'k', 'q', 's', 'x', 'z':
+			class = 'C'
+		case 'd', 't':
+			class = 'D'
+		case 'l':
+			class = 'L'
+		case 'm', 'n':
+			class = 'M'
+		case 'r':
+			class = 'R'
+		default:
+			class = r
+		}
+		if class != last {
+			b.WriteRune(class)
+			last = class
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzzy // import "github.com/syralon/sqlite/ext/fuzzy"
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+"/fuzzy.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "fuzzy", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table dict using fuzzy"); err != nil {
+		t.Fatal(err)
+	}
+
+	words := []string{"kennedy", "keynes", "canada", "grenada", "kenneth"}
+	for _, w := range words {
+		if _, err := db.Exec("insert into dict(word, rank) values (?, 1)", w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The best match for a misspelling must come first.
+	var got string
+	if err := db.QueryRow("select word from dict where word match 'kenedy'").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "kennedy" {
+		t.Fatalf("got %q, want %q", got, "kennedy")
+	}
+
+	// The hidden top column limits the number of candidates.
+	rows, err := db.Query("select word from dict where word match 'kenedy' and top = 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d rows with top = 2, want 2", n)
+	}
+
+	// Deletes are honored.
+	if _, err := db.Exec("delete from dict where word = 'kennedy'"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("select word from dict where word match 'kenedy'").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got == "kennedy" {
+		t.Fatal("deleted word still returned")
+	}
+}
+
+func TestEditDistanceCostTable(t *testing.T) {
+	ct := DefaultCosts()
+	if got := editDistance("abc", "abc", ct); got != 0 {
+		t.Fatalf("identical words: got %d, want 0", got)
+	}
+
+	if got := editDistance("abc", "abd", ct); got != 150 {
+		t.Fatalf("one substitution: got %d, want 150", got)
+	}
+
+	ct.Pairs = map[string]int{"cd": 40}
+	if got := editDistance("abc", "abd", ct); got != 40 {
+		t.Fatalf("pair override: got %d, want 40", got)
+	}
+}
+
+func TestPhoneticHash(t *testing.T) {
+	if a, b := phoneticHash("kennedy"), phoneticHash("kenedy"); a != b {
+		t.Fatalf("hash mismatch for homophones: %q vs %q", a, b)
+	}
+
+	if a, b := phoneticHash("smith"), phoneticHash("smythe"); a != b {
+		t.Fatalf("hash mismatch for homophones: %q vs %q", a, b)
+	}
+}
@@ -0,0 +1,524 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// FTS5 tokenize flags, passed to FTS5Tokenizer.Tokenize. They mirror the
+// FTS5_TOKENIZE_* constants from fts5.h.
+const (
+	FTS5TokenizeQuery    = sqlite3.FTS5_TOKENIZE_QUERY
+	FTS5TokenizePrefix   = sqlite3.FTS5_TOKENIZE_PREFIX
+	FTS5TokenizeDocument = sqlite3.FTS5_TOKENIZE_DOCUMENT
+	FTS5TokenizeAux      = sqlite3.FTS5_TOKENIZE_AUX
+)
+
+// FTS5TokenFn is invoked by an FTS5Tokenizer once per token. token is the
+// normalized token text, start and end are byte offsets of the token within
+// the original input text.
+type FTS5TokenFn func(token string, start, end int) error
+
+// FTS5Tokenizer splits text into tokens for the FTS5 full-text engine. An
+// instance is created per "tokenize=..." clause via the factory passed to
+// RegisterFTS5Tokenizer.
+type FTS5Tokenizer interface {
+	// Tokenize splits text and calls emit once per token, in order. flags is
+	// one of the FTS5Tokenize* constants, possibly OR-ed with
+	// FTS5TokenizePrefix.
+	Tokenize(flags int, text string, emit FTS5TokenFn) error
+}
+
+// fts5TokenizerDesc describes a tokenizer registered on the driver, analogous
+// to userDefinedFunction.
+type fts5TokenizerDesc struct {
+	zName uintptr
+	pApp  uintptr
+}
+
+var (
+	xFTS5TokenizerFactories = struct {
+		mu  sync.RWMutex
+		m   map[uintptr]func(args []string) (FTS5Tokenizer, error)
+		ids idGen
+	}{
+		m: make(map[uintptr]func(args []string) (FTS5Tokenizer, error)),
+	}
+
+	xFTS5Tokenizers = struct {
+		mu  sync.RWMutex
+		m   map[uintptr]FTS5Tokenizer
+		ids idGen
+	}{
+		m: make(map[uintptr]FTS5Tokenizer),
+	}
+
+	// fts5NativeTokenizer is the fts5_tokenizer descriptor shared by all Go
+	// tokenizers. It lives in Go memory so its address remains stable for the
+	// C layer; the factory is recovered from pUserData in the xCreate
+	// trampoline.
+	fts5NativeTokenizer     sqlite3.Tfts5_tokenizer
+	fts5NativeTokenizerOnce sync.Once
+)
+
+// RegisterFTS5Tokenizer registers a custom FTS5 tokenizer named zName. factory
+// is invoked once per tokenizer instance with the arguments following the
+// tokenizer name in the "tokenize=..." clause of CREATE VIRTUAL TABLE.
+//
+// The new tokenizer will be available to all new connections opened after
+// executing RegisterFTS5Tokenizer.
+func RegisterFTS5Tokenizer(zName string, factory func(args []string) (FTS5Tokenizer, error)) error {
+	if factory == nil {
+		return fmt.Errorf("sqlite: FTS5 tokenizer %q: factory is nil", zName)
+	}
+	if _, ok := d.fts5Tokenizers[zName]; ok {
+		return fmt.Errorf("an FTS5 tokenizer %q is already registered", zName)
+	}
+
+	// dont free, tokenizers registered on the driver live as long as the program
+	name, err := libc.CString(zName)
+	if err != nil {
+		return err
+	}
+
+	xFTS5TokenizerFactories.mu.Lock()
+	id := xFTS5TokenizerFactories.ids.next()
+	xFTS5TokenizerFactories.m[id] = factory
+	xFTS5TokenizerFactories.mu.Unlock()
+
+	d.fts5Tokenizers[zName] = &fts5TokenizerDesc{
+		zName: name,
+		pApp:  id,
+	}
+
+	return nil
+}
+
+// MustRegisterFTS5Tokenizer is like RegisterFTS5Tokenizer but panics on error.
+func MustRegisterFTS5Tokenizer(zName string, factory func(args []string) (FTS5Tokenizer, error)) {
+	if err := RegisterFTS5Tokenizer(zName, factory); err != nil {
+		panic(err)
+	}
+}
+
+// fts5API returns the fts5_api pointer for this connection using the
+// 'fts5_api_ptr' pointer-passing convention, see
+// https://www.sqlite.org/fts5.html#extending_fts5.
+func (c *conn) fts5API() (api uintptr, err error) {
+	zSQL, err := libc.CString("select fts5(?1)")
+	if err != nil {
+		return 0, err
+	}
+
+	defer c.free(zSQL)
+
+	zType, err := libc.CString("fts5_api_ptr")
+	if err != nil {
+		return 0, err
+	}
+
+	defer c.free(zType)
+
+	ppStmt, err := c.malloc(int(2 * ptrSize))
+	if err != nil {
+		return 0, err
+	}
+
+	defer c.free(ppStmt)
+
+	pAPI := ppStmt + ptrSize
+	*(*uintptr)(unsafe.Pointer(ppStmt)) = 0
+	*(*uintptr)(unsafe.Pointer(pAPI)) = 0
+
+	if rc := sqlite3.Xsqlite3_prepare_v2(c.tls, c.db, zSQL, -1, ppStmt, 0); rc != sqlite3.SQLITE_OK {
+		return 0, c.errstr(rc)
+	}
+
+	pstmt := *(*uintptr)(unsafe.Pointer(ppStmt))
+
+	defer sqlite3.Xsqlite3_finalize(c.tls, pstmt)
+
+	if rc := sqlite3.Xsqlite3_bind_pointer(c.tls, pstmt, 1, pAPI, zType, 0); rc != sqlite3.SQLITE_OK {
+		return 0, c.errstr(rc)
+	}
+
+	if rc := sqlite3.Xsqlite3_step(c.tls, pstmt); rc != sqlite3.SQLITE_ROW {
+		return 0, c.errstr(rc)
+	}
+
+	if api = *(*uintptr)(unsafe.Pointer(pAPI)); api == 0 {
+		return 0, fmt.Errorf("sqlite: fts5_api not available on this connection")
+	}
+
+	return api, nil
+}
+
+// registerFTS5Tokenizers installs all driver-registered FTS5 tokenizers on
+// this connection via fts5_api.xCreateTokenizer.
+func (c *conn) registerFTS5Tokenizers() error {
+	if len(d.fts5Tokenizers) == 0 {
+		return nil
+	}
+
+	fts5NativeTokenizerOnce.Do(func() {
+		fts5NativeTokenizer.FxCreate = cFuncPointer(fts5TokenizerCreateTrampoline)
+		fts5NativeTokenizer.FxDelete = cFuncPointer(fts5TokenizerDeleteTrampoline)
+		fts5NativeTokenizer.FxTokenize = cFuncPointer(fts5TokenizerTokenizeTrampoline)
+	})
+
+	api, err := c.fts5API()
+	if err != nil {
+		return err
+	}
+
+	xCreateTokenizer := (*sqlite3.Tfts5_api)(unsafe.Pointer(api)).FxCreateTokenizer
+	if xCreateTokenizer == 0 {
+		return fmt.Errorf("sqlite: fts5_api.xCreateTokenizer not available")
+	}
+
+	for zName, desc := range d.fts5Tokenizers {
+		rc := (*(*func(*libc.TLS, uintptr, uintptr, uintptr, uintptr, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{xCreateTokenizer})))(
+			c.tls,
+			api,
+			desc.zName,
+			desc.pApp,
+			uintptr(unsafe.Pointer(&fts5NativeTokenizer)),
+			0,
+		)
+		if rc != sqlite3.SQLITE_OK {
+			return fmt.Errorf("create_tokenizer %q: %w", zName, c.errstr(rc))
+		}
+	}
+	return nil
+}
+
+// fts5TokenizerCreateTrampoline is the fts5_tokenizer.xCreate callback. It
+// invokes the registered factory and hands out an ID that stands in for the
+// Fts5Tokenizer* instance pointer.
+func fts5TokenizerCreateTrampoline(tls *libc.TLS, pUserData uintptr, azArg uintptr, nArg int32, ppOut uintptr) int32 {
+	xFTS5TokenizerFactories.mu.RLock()
+	factory := xFTS5TokenizerFactories.m[pUserData]
+	xFTS5TokenizerFactories.mu.RUnlock()
+	if factory == nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	args := make([]string, nArg)
+	for i := int32(0); i < nArg; i++ {
+		cstr := *(*uintptr)(unsafe.Pointer(azArg + uintptr(i)*ptrSize))
+		args[i] = libc.GoString(cstr)
+	}
+
+	tok, err := factory(args)
+	if err != nil || tok == nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	xFTS5Tokenizers.mu.Lock()
+	id := xFTS5Tokenizers.ids.next()
+	xFTS5Tokenizers.m[id] = tok
+	xFTS5Tokenizers.mu.Unlock()
+
+	*(*uintptr)(unsafe.Pointer(ppOut)) = id
+	return sqlite3.SQLITE_OK
+}
+
+// fts5TokenizerDeleteTrampoline is the fts5_tokenizer.xDelete callback.
+func fts5TokenizerDeleteTrampoline(tls *libc.TLS, pTok uintptr) {
+	xFTS5Tokenizers.mu.Lock()
+	defer xFTS5Tokenizers.mu.Unlock()
+	if _, ok := xFTS5Tokenizers.m[pTok]; ok {
+		delete(xFTS5Tokenizers.m, pTok)
+		xFTS5Tokenizers.ids.reclaim(pTok)
+	}
+}
+
+// fts5TokenizerTokenizeTrampoline is the fts5_tokenizer.xTokenize callback. It
+// converts the input to a Go string and forwards each emitted token to the
+// xToken callback supplied by FTS5.
+func fts5TokenizerTokenizeTrampoline(tls *libc.TLS, pTok uintptr, pCtx uintptr, flags int32, pText uintptr, nText int32, xToken uintptr) int32 {
+	xFTS5Tokenizers.mu.RLock()
+	tok := xFTS5Tokenizers.m[pTok]
+	xFTS5Tokenizers.mu.RUnlock()
+	if tok == nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	var text string
+	if pText != 0 && nText > 0 {
+		text = string(libc.GoBytes(pText, int(nText)))
+	}
+
+	callToken := (*(*func(*libc.TLS, uintptr, int32, uintptr, int32, int32, int32) int32)(unsafe.Pointer(&struct{ uintptr }{xToken})))
+
+	err := tok.Tokenize(int(flags), text, func(token string, start, end int) error {
+		z, cerr := libc.CString(token)
+		if cerr != nil {
+			return cerr
+		}
+		defer libc.Xfree(tls, z)
+		if rc := callToken(tls, pCtx, 0, z, int32(len(token)), int32(start), int32(end)); rc != sqlite3.SQLITE_OK {
+			return &Error{msg: fmt.Sprintf("xToken: rc=%d", rc), code: int(rc)}
+		}
+		return nil
+	})
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			return int32(e.Code())
+		}
+		return sqlite3.SQLITE_ERROR
+	}
+	return sqlite3.SQLITE_OK
+}
+
+// FTS5Function is an FTS5 auxiliary function: a scalar function that may only
+// be invoked from within a full-text MATCH query, where it additionally
+// receives an FTS5Context describing the current match. Custom rank or
+// snippet implementations are the typical use. args holds the SQL arguments
+// after the implicit first one naming the FTS5 table.
+type FTS5Function func(ctx *FunctionContext, fts *FTS5Context, args []driver.Value) (driver.Value, error)
+
+// FTS5Context gives an auxiliary function access to the row the containing
+// MATCH query is currently positioned on, wrapping the Fts5ExtensionApi
+// callbacks. It is only valid for the duration of the call it was passed to.
+type FTS5Context struct {
+	tls  *libc.TLS
+	pApi uintptr // Fts5ExtensionApi*
+	pFts uintptr // Fts5Context*
+}
+
+// xFTS5Funcs maps the user-data handle passed to fts5_api.xCreateFunction
+// back to the registered Go implementation.
+var xFTS5Funcs = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]FTS5Function
+	ids idGen
+}{
+	m: make(map[uintptr]FTS5Function),
+}
+
+// RegisterFTS5Function registers f as the FTS5 auxiliary function zFuncName
+// on this connection, wrapping fts5_api.xCreateFunction. The function can
+// then be called from MATCH queries against any FTS5 table of the
+// connection, with the table name as its implicit first argument:
+//
+//	select zFuncName(tbl, ...) from tbl where tbl match ?
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterFTS5Function(zFuncName string, f FTS5Function) error {
+	if f == nil {
+		return fmt.Errorf("sqlite: FTS5 function %q: implementation is nil", zFuncName)
+	}
+
+	api, err := c.fts5API()
+	if err != nil {
+		return err
+	}
+
+	xCreateFunction := (*sqlite3.Tfts5_api)(unsafe.Pointer(api)).FxCreateFunction
+	if xCreateFunction == 0 {
+		return fmt.Errorf("sqlite: fts5_api.xCreateFunction not available")
+	}
+
+	zName, err := libc.CString(zFuncName)
+	if err != nil {
+		return err
+	}
+
+	// xCreateFunction copies the name.
+	defer c.free(zName)
+
+	xFTS5Funcs.mu.Lock()
+	id := xFTS5Funcs.ids.next()
+	xFTS5Funcs.m[id] = f
+	xFTS5Funcs.mu.Unlock()
+
+	rc := (*(*func(*libc.TLS, uintptr, uintptr, uintptr, sqlite3.Tfts5_extension_function, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{xCreateFunction})))(
+		c.tls,
+		api,
+		zName,
+		id,
+		cFuncPointer(fts5FuncTrampoline),
+		cFuncPointer(fts5FuncDestroyTrampoline),
+	)
+	if rc != sqlite3.SQLITE_OK {
+		xFTS5Funcs.mu.Lock()
+		delete(xFTS5Funcs.m, id)
+		xFTS5Funcs.ids.reclaim(id)
+		xFTS5Funcs.mu.Unlock()
+		return fmt.Errorf("create_function %q: %w", zFuncName, c.errstr(rc))
+	}
+
+	return nil
+}
+
+// fts5FuncTrampoline is the fts5_extension_function callback shared by all Go
+// auxiliary functions. The implementation is recovered from the xUserData
+// handle of the current call.
+func fts5FuncTrampoline(tls *libc.TLS, pApi uintptr, pFts uintptr, ctx uintptr, argc int32, argv uintptr) {
+	xUserData := (*sqlite3.TFts5ExtensionApi)(unsafe.Pointer(pApi)).FxUserData
+	id := (*(*func(*libc.TLS, uintptr) uintptr)(unsafe.Pointer(&struct{ uintptr }{xUserData})))(tls, pFts)
+	xFTS5Funcs.mu.RLock()
+	f := xFTS5Funcs.m[id]
+	xFTS5Funcs.mu.RUnlock()
+
+	setErrorResult := errorResultFunction(tls, ctx)
+	if f == nil {
+		setErrorResult(fmt.Errorf("sqlite: unknown FTS5 function"))
+		return
+	}
+
+	fctx := &FunctionContext{tls: tls, ctx: ctx, argc: argc, argv: argv}
+	fts := &FTS5Context{tls: tls, pApi: pApi, pFts: pFts}
+	res, err := f(fctx, fts, functionArgs(tls, argc, argv))
+	if err != nil {
+		setErrorResult(err)
+		return
+	}
+
+	if err = functionReturnValue(tls, ctx, res); err != nil {
+		setErrorResult(err)
+		return
+	}
+
+	if fctx.subtypeSet {
+		sqlite3.Xsqlite3_result_subtype(tls, ctx, fctx.subtype)
+	}
+}
+
+// fts5FuncDestroyTrampoline is the xDestroy callback of an auxiliary
+// function, invoked when FTS5 discards the registration.
+func fts5FuncDestroyTrampoline(tls *libc.TLS, pUserData uintptr) {
+	xFTS5Funcs.mu.Lock()
+	defer xFTS5Funcs.mu.Unlock()
+	if _, ok := xFTS5Funcs.m[pUserData]; ok {
+		delete(xFTS5Funcs.m, pUserData)
+		xFTS5Funcs.ids.reclaim(pUserData)
+	}
+}
+
+// err turns a non-OK Fts5ExtensionApi return code into an error.
+func (f *FTS5Context) err(rc int32) error {
+	return fmt.Errorf("%s (%d)", libc.GoString(sqlite3.Xsqlite3_errstr(f.tls, rc)), rc)
+}
+
+func (f *FTS5Context) api() *sqlite3.TFts5ExtensionApi {
+	return (*sqlite3.TFts5ExtensionApi)(unsafe.Pointer(f.pApi))
+}
+
+// ColumnCount returns the number of columns of the FTS5 table, wrapping
+// xColumnCount.
+func (f *FTS5Context) ColumnCount() int {
+	return int((*(*func(*libc.TLS, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxColumnCount})))(f.tls, f.pFts))
+}
+
+// RowCount returns the number of rows of the FTS5 table, wrapping xRowCount.
+func (f *FTS5Context) RowCount() (int64, error) {
+	bp := f.tls.Alloc(8)
+	defer f.tls.Free(8)
+
+	if rc := (*(*func(*libc.TLS, uintptr, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxRowCount})))(f.tls, f.pFts, bp); rc != sqlite3.SQLITE_OK {
+		return 0, f.err(rc)
+	}
+
+	return *(*int64)(unsafe.Pointer(bp)), nil
+}
+
+// Rowid returns the rowid of the current row, wrapping xRowid.
+func (f *FTS5Context) Rowid() int64 {
+	return int64((*(*func(*libc.TLS, uintptr) sqlite3.Tsqlite3_int64)(unsafe.Pointer(&struct{ uintptr }{f.api().FxRowid})))(f.tls, f.pFts))
+}
+
+// ColumnText returns the text of column col of the current row, wrapping
+// xColumnText.
+func (f *FTS5Context) ColumnText(col int) (string, error) {
+	bp := f.tls.Alloc(16)
+	defer f.tls.Free(16)
+
+	if rc := (*(*func(*libc.TLS, uintptr, int32, uintptr, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxColumnText})))(f.tls, f.pFts, int32(col), bp, bp+8); rc != sqlite3.SQLITE_OK {
+		return "", f.err(rc)
+	}
+
+	z := *(*uintptr)(unsafe.Pointer(bp))
+	n := *(*int32)(unsafe.Pointer(bp + 8))
+	if z == 0 || n <= 0 {
+		return "", nil
+	}
+
+	return string((*libc.RawMem)(unsafe.Pointer(z))[:n:n]), nil
+}
+
+// ColumnSize returns the number of tokens in column col of the current row,
+// wrapping xColumnSize. A negative col reports the total across all columns.
+func (f *FTS5Context) ColumnSize(col int) (int, error) {
+	bp := f.tls.Alloc(4)
+	defer f.tls.Free(4)
+
+	if rc := (*(*func(*libc.TLS, uintptr, int32, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxColumnSize})))(f.tls, f.pFts, int32(col), bp); rc != sqlite3.SQLITE_OK {
+		return 0, f.err(rc)
+	}
+
+	return int(*(*int32)(unsafe.Pointer(bp))), nil
+}
+
+// ColumnTotalSize returns the total number of tokens in column col across the
+// whole table, wrapping xColumnTotalSize. A negative col reports the total
+// across all columns.
+func (f *FTS5Context) ColumnTotalSize(col int) (int64, error) {
+	bp := f.tls.Alloc(8)
+	defer f.tls.Free(8)
+
+	if rc := (*(*func(*libc.TLS, uintptr, int32, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxColumnTotalSize})))(f.tls, f.pFts, int32(col), bp); rc != sqlite3.SQLITE_OK {
+		return 0, f.err(rc)
+	}
+
+	return *(*int64)(unsafe.Pointer(bp)), nil
+}
+
+// PhraseCount returns the number of phrases in the current query expression,
+// wrapping xPhraseCount.
+func (f *FTS5Context) PhraseCount() int {
+	return int((*(*func(*libc.TLS, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxPhraseCount})))(f.tls, f.pFts))
+}
+
+// PhraseSize returns the number of tokens in phrase i of the current query
+// expression, wrapping xPhraseSize.
+func (f *FTS5Context) PhraseSize(i int) int {
+	return int((*(*func(*libc.TLS, uintptr, int32) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxPhraseSize})))(f.tls, f.pFts, int32(i)))
+}
+
+// InstCount returns the number of phrase hits within the current row,
+// wrapping xInstCount.
+func (f *FTS5Context) InstCount() (int, error) {
+	bp := f.tls.Alloc(4)
+	defer f.tls.Free(4)
+
+	if rc := (*(*func(*libc.TLS, uintptr, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxInstCount})))(f.tls, f.pFts, bp); rc != sqlite3.SQLITE_OK {
+		return 0, f.err(rc)
+	}
+
+	return int(*(*int32)(unsafe.Pointer(bp))), nil
+}
+
+// Inst describes phrase hit i within the current row, wrapping xInst. It
+// reports the index of the matched phrase, the column it was found in and
+// its token offset within that column.
+func (f *FTS5Context) Inst(i int) (phrase, col, off int, err error) {
+	bp := f.tls.Alloc(12)
+	defer f.tls.Free(12)
+
+	if rc := (*(*func(*libc.TLS, uintptr, int32, uintptr, uintptr, uintptr) int32)(unsafe.Pointer(&struct{ uintptr }{f.api().FxInst})))(f.tls, f.pFts, int32(i), bp, bp+4, bp+8); rc != sqlite3.SQLITE_OK {
+		return 0, 0, 0, f.err(rc)
+	}
+
+	return int(*(*int32)(unsafe.Pointer(bp))), int(*(*int32)(unsafe.Pointer(bp + 4))), int(*(*int32)(unsafe.Pointer(bp + 8))), nil
+}
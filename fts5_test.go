@@ -0,0 +1,190 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestUnicodeWordsTokenizer(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/fts5.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`create virtual table doc using fts5(body,
+		tokenize = 'unicode_words cjk_bigrams 1 stopwords ''the,a,of''')`); err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []string{
+		"the Crème brûlée of Paris",
+		"日本語の全文検索",
+		"plain ascii words only",
+	}
+	for _, doc := range docs {
+		if _, err := db.Exec("insert into doc(body) values (?)", doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		match string
+		want  string
+	}{
+		// Diacritics folded: query without accents finds the accented document.
+		{"creme", "the Crème brûlée of Paris"},
+		// CJK bigram matching without word separators.
+		{"全文", "日本語の全文検索"},
+		{"ascii", "plain ascii words only"},
+	} {
+		var got string
+		if err := db.QueryRow("select body from doc where doc match ?", test.match).Scan(&got); err != nil {
+			t.Fatalf("match %q: %v", test.match, err)
+		}
+
+		if got != test.want {
+			t.Fatalf("match %q: got %q, want %q", test.match, got, test.want)
+		}
+	}
+
+	// Stop words are dropped from the index.
+	var n int
+	if err := db.QueryRow("select count(*) from doc where doc match 'paris'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %d matches for 'paris', expected 1", n)
+	}
+
+	rows, err := db.Query("select body from doc where doc match 'the'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		t.Fatal("stop word 'the' unexpectedly matched a document")
+	}
+}
+
+func TestFTS5AuxiliaryFunction(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/fts5aux.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+	cn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type fts5registerer interface {
+		RegisterFTS5Function(zFuncName string, f FTS5Function) error
+	}
+	if err := cn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(fts5registerer)
+		// matchinfo_test reports the row and its hits:
+		// rowid:columns:instances:first matched column text.
+		if err := c.RegisterFTS5Function("matchinfo_test", func(fctx *FunctionContext, fts *FTS5Context, args []driver.Value) (driver.Value, error) {
+			nInst, err := fts.InstCount()
+			if err != nil {
+				return nil, err
+			}
+
+			col := -1
+			if nInst > 0 {
+				if _, col, _, err = fts.Inst(0); err != nil {
+					return nil, err
+				}
+			}
+
+			text := ""
+			if col >= 0 {
+				if text, err = fts.ColumnText(col); err != nil {
+					return nil, err
+				}
+			}
+
+			return fmt.Sprintf("%d:%d:%d:%s", fts.Rowid(), fts.ColumnCount(), nInst, text), nil
+		}); err != nil {
+			return err
+		}
+
+		// simple_rank_test orders by the number of hits, a minimal custom
+		// rank function.
+		return c.RegisterFTS5Function("simple_rank_test", func(fctx *FunctionContext, fts *FTS5Context, args []driver.Value) (driver.Value, error) {
+			nInst, err := fts.InstCount()
+			if err != nil {
+				return nil, err
+			}
+			return -float64(nInst), nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(ctx, "create virtual table doc using fts5(title, body)"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, doc := range [][2]string{
+		{"alpha", "one fish"},
+		{"beta", "fish two fish"},
+		{"gamma", "no match here"},
+	} {
+		if _, err := cn.ExecContext(ctx, "insert into doc(title, body) values (?, ?)", doc[0], doc[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got string
+	if err := cn.QueryRowContext(ctx, "select matchinfo_test(doc) from doc where doc match 'one'").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "1:2:1:one fish"; got != want {
+		t.Fatalf("matchinfo_test = %q, want %q", got, want)
+	}
+
+	rows, err := cn.QueryContext(ctx, "select title from doc where doc match 'fish' order by simple_rank_test(doc)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	var titles []string
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, title)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(titles, []string{"beta", "alpha"}) {
+		t.Fatalf("got order %v, want [beta alpha]", titles)
+	}
+}
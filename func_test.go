@@ -16,11 +16,16 @@ import (
 	"io"
 	"os"
 	"path"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
 )
 
 var finalCalled bool
@@ -215,6 +220,202 @@ func init() {
 			return nil, nil
 		},
 	})
+
+	MustRegisterAggregateFunction("test_median", 1,
+		func(ctx FunctionContext) (AggregateFunction, error) {
+			return &medianFunction{}, nil
+		})
+
+	MustRegisterDeterministicScalarFunction(
+		"test_concat_all",
+		-1,
+		func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			var b strings.Builder
+			for _, arg := range args {
+				switch v := arg.(type) {
+				case string:
+					b.WriteString(v)
+				case int64:
+					fmt.Fprintf(&b, "%d", v)
+				case float64:
+					fmt.Fprintf(&b, "%g", v)
+				case nil:
+					// NULL arguments contribute nothing.
+				default:
+					return nil, fmt.Errorf("test_concat_all: unsupported argument type %T", v)
+				}
+			}
+			return b.String(), nil
+		},
+	)
+
+	MustRegisterAggregateFunction("test_moving_avg", 1,
+		func(ctx FunctionContext) (AggregateFunction, error) {
+			return &movingAvgFunction{}, nil
+		})
+}
+
+// medianFunction is a Step/Final style aggregate collecting its float64
+// arguments and reporting their median.
+type medianFunction struct {
+	vals []float64
+}
+
+func (f *medianFunction) Step(ctx *FunctionContext, args []driver.Value) error {
+	switch v := args[0].(type) {
+	case int64:
+		f.vals = append(f.vals, float64(v))
+	case float64:
+		f.vals = append(f.vals, v)
+	case nil:
+		// NULLs are ignored, like the built-in aggregates do.
+	default:
+		return fmt.Errorf("test_median: unsupported argument type %T", v)
+	}
+	return nil
+}
+
+func (f *medianFunction) WindowInverse(ctx *FunctionContext, args []driver.Value) error {
+	return fmt.Errorf("test_median: not usable as a window function")
+}
+
+func (f *medianFunction) WindowValue(ctx *FunctionContext) (driver.Value, error) {
+	if len(f.vals) == 0 {
+		return nil, nil
+	}
+	s := append([]float64(nil), f.vals...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2], nil
+	}
+	return (s[n/2-1] + s[n/2]) / 2, nil
+}
+
+func (f *medianFunction) Final(ctx *FunctionContext) {}
+
+// movingAvgFunction is a window-capable aggregate averaging the values
+// currently inside the frame. WindowInverse drops the oldest row when the
+// frame slides, so it works with ROWS BETWEEN ... PRECEDING frames.
+type movingAvgFunction struct {
+	sum float64
+	n   int64
+}
+
+func (f *movingAvgFunction) step(args []driver.Value, sign float64) error {
+	switch v := args[0].(type) {
+	case int64:
+		f.sum += sign * float64(v)
+	case float64:
+		f.sum += sign * v
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("test_moving_avg: unsupported argument type %T", v)
+	}
+	f.n += int64(sign)
+	return nil
+}
+
+func (f *movingAvgFunction) Step(ctx *FunctionContext, args []driver.Value) error {
+	return f.step(args, 1)
+}
+
+func (f *movingAvgFunction) WindowInverse(ctx *FunctionContext, args []driver.Value) error {
+	return f.step(args, -1)
+}
+
+func (f *movingAvgFunction) WindowValue(ctx *FunctionContext) (driver.Value, error) {
+	if f.n == 0 {
+		return nil, nil
+	}
+	return f.sum / float64(f.n), nil
+}
+
+func (f *movingAvgFunction) Final(ctx *FunctionContext) {}
+
+func TestWindowMovingAverage(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		create table t(day int, v);
+		insert into t values (1, 10), (2, 20), (3, 60), (4, 20), (5, 0);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query(`
+		select test_moving_avg(v) over (
+			order by day rows between 1 preceding and current row
+		) from t order by day
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	want := []float64{10, 15, 40, 40, 10}
+	for i := 0; rows.Next(); i++ {
+		var avg float64
+		if err := rows.Scan(&avg); err != nil {
+			t.Fatal(err)
+		}
+
+		if i >= len(want) {
+			t.Fatalf("got more than %d rows", len(want))
+		}
+
+		if avg != want[i] {
+			t.Fatalf("row %d: got %v, want %v", i, avg, want[i])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAggregateMedian(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		create table t(v);
+		insert into t values (1), (5), (2), (10), (3);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var m float64
+	if err := db.QueryRow("select test_median(v) from t").Scan(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m != 3 {
+		t.Fatalf("got median %v, want 3", m)
+	}
+
+	// Even row count averages the middle pair.
+	if _, err := db.Exec("insert into t values (4)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("select test_median(v) from t").Scan(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m != 3.5 {
+		t.Fatalf("got median %v, want 3.5", m)
+	}
 }
 
 func TestRegisteredFunctions(t *testing.T) {
@@ -1002,3 +1203,574 @@ func TestRegisteredFunctions(t *testing.T) {
 		})
 	})
 }
+
+func TestVariadicScalarFunction(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"select test_concat_all()", ""},
+		{"select test_concat_all('a')", "a"},
+		{"select test_concat_all('a', 1, 2.5)", "a12.5"},
+		{"select test_concat_all('a', null, 'b', 'c')", "abc"},
+	} {
+		var got string
+		if err := db.QueryRow(tc.query).Scan(&got); err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+
+		if got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestConnRegisterCollation(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if err := cn.Raw(func(driverConn any) error {
+		type collationRegistrar interface {
+			RegisterCollation(string, func(left, right string) int) error
+		}
+		return driverConn.(collationRegistrar).RegisterCollation("test_nocase_rev",
+			func(left, right string) int {
+				return -strings.Compare(strings.ToLower(left), strings.ToLower(right))
+			})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(s);
+		insert into t values ('B'), ('a'), ('C');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := cn.QueryContext(context.Background(), "select s from t order by s collate test_nocase_rev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, s)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"C", "B", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCollationNeededHook(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	var requested []string
+	if err := cn.Raw(func(driverConn any) error {
+		type lazyCollations interface {
+			RegisterCollationNeededHook(CollationNeededFn) error
+			RegisterCollation(string, func(left, right string) int) error
+		}
+		lc := driverConn.(lazyCollations)
+		return lc.RegisterCollationNeededHook(func(name string) {
+			requested = append(requested, name)
+			if name != "lazy_nocase" {
+				return
+			}
+
+			lc.RegisterCollation(name, func(left, right string) int {
+				return strings.Compare(strings.ToLower(left), strings.ToLower(right))
+			})
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(s);
+		insert into t values ('b'), ('A');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := cn.QueryRowContext(context.Background(),
+		"select s from t order by s collate lazy_nocase limit 1",
+	).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "A" {
+		t.Fatalf("got %q, want %q", got, "A")
+	}
+
+	if len(requested) == 0 || requested[0] != "lazy_nocase" {
+		t.Fatalf("hook requests: %v, want lazy_nocase first", requested)
+	}
+}
+
+func TestSerializeSchema(t *testing.T) {
+	type schemaSerializer interface {
+		SerializeSchema(string) ([]byte, error)
+		DeserializeSchema(string, []byte) error
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		attach ':memory:' as aux;
+		create table aux.t(v);
+		insert into aux.t values (1), (2), (3);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf []byte
+	if err := cn.Raw(func(driverConn any) error {
+		var err error
+		buf, err = driverConn.(schemaSerializer).SerializeSchema("aux")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(buf) == 0 {
+		t.Fatal("empty serialization")
+	}
+
+	// Unknown schemas fail instead of silently serializing main.
+	if err := cn.Raw(func(driverConn any) error {
+		_, err := driverConn.(schemaSerializer).SerializeSchema("nosuch")
+		return err
+	}); err == nil {
+		t.Fatal("expected error for unknown schema")
+	}
+
+	// Restore the snapshot into a fresh attached database on a new
+	// connection.
+	cn2, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn2.Close()
+
+	if _, err := cn2.ExecContext(context.Background(), "attach ':memory:' as restored"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cn2.Raw(func(driverConn any) error {
+		return driverConn.(schemaSerializer).DeserializeSchema("restored", buf)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := cn2.QueryRowContext(context.Background(), "select count(*) from restored.t").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 3 {
+		t.Fatalf("got %d rows, want 3", count)
+	}
+}
+
+func TestUnregisterFunctionAndCollation(t *testing.T) {
+	if err := RegisterScalarFunction("unreg_test_fn", 0, func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+		return int64(7), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterCollationUtf8("unreg_test_coll", func(left, right string) int {
+		return strings.Compare(left, right)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(), "select unreg_test_fn()").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cn.QueryRowContext(context.Background(), "select 1 order by 'a' collate unreg_test_coll").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	// Per-connection removal affects only this connection.
+	type unregisterer interface {
+		UnregisterFunction(zFuncName string, nArg int32) error
+		UnregisterCollation(zName string) error
+	}
+	if err := cn.Raw(func(driverConn any) error {
+		u := driverConn.(unregisterer)
+		if err := u.UnregisterFunction("unreg_test_fn", 0); err != nil {
+			t.Fatal(err)
+		}
+		return u.UnregisterCollation("unreg_test_coll")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cn.QueryRowContext(context.Background(), "select unreg_test_fn()").Scan(&n); err == nil || !strings.Contains(err.Error(), "no such function") {
+		t.Fatalf("expected no such function, got %v", err)
+	}
+
+	if err := cn.QueryRowContext(context.Background(), "select 1 order by 'a' collate unreg_test_coll").Scan(&n); err == nil || !strings.Contains(err.Error(), "no such collation") {
+		t.Fatalf("expected no such collation, got %v", err)
+	}
+
+	// Close every connection before releasing the driver-level state.
+	cn.Close()
+	db.Close()
+
+	if err := UnregisterFunction("unreg_test_fn"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnregisterFunction("unreg_test_fn"); err == nil {
+		t.Fatal("expected error unregistering twice")
+	}
+
+	if err := UnregisterCollation("unreg_test_coll"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnregisterCollation("unreg_test_coll"); err == nil {
+		t.Fatal("expected error unregistering twice")
+	}
+
+	// Connections opened after the removal no longer see either one.
+	db2, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db2.Close()
+
+	if err := db2.QueryRow("select unreg_test_fn()").Scan(&n); err == nil || !strings.Contains(err.Error(), "no such function") {
+		t.Fatalf("expected no such function, got %v", err)
+	}
+
+	if err := db2.QueryRow("select 1 order by 'a' collate unreg_test_coll").Scan(&n); err == nil || !strings.Contains(err.Error(), "no such collation") {
+		t.Fatalf("expected no such collation, got %v", err)
+	}
+}
+
+func TestFunctionSubtypes(t *testing.T) {
+	if err := RegisterFunction("subtype_mark_test", &FunctionImpl{
+		NArgs:         1,
+		ResultSubtype: true,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			ctx.SetResultSubtype('J')
+			return args[0], nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterFunction("subtype_read_test", &FunctionImpl{
+		NArgs:       1,
+		UsesSubtype: true,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			return int64(ctx.ArgSubtype(0)), nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	var n int64
+	if err := db.QueryRow("select subtype_read_test(subtype_mark_test('{}'))").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 'J' {
+		t.Fatalf("subtype = %d, want %d", n, 'J')
+	}
+
+	// JSON1 interop: the json() function tags its results with subtype 'J'.
+	if err := db.QueryRow(`select subtype_read_test(json('{"a":1}'))`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 'J' {
+		t.Fatalf("json() subtype = %d, want %d", n, 'J')
+	}
+
+	// Untagged values carry no subtype.
+	if err := db.QueryRow("select subtype_read_test('plain')").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("subtype = %d, want 0", n)
+	}
+}
+
+func TestFunctionAuxData(t *testing.T) {
+	// A stand-in for a regexp() style function: the "compilation" of the
+	// first argument is cached per call-site via auxiliary data.
+	var compiles int32
+	if err := RegisterFunction("auxdata_match_test", &FunctionImpl{
+		NArgs: 2,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			pat, ok := ctx.AuxData(0).(string)
+			if !ok {
+				atomic.AddInt32(&compiles, 1)
+				pat = fmt.Sprint(args[0])
+				ctx.SetAuxData(0, pat)
+			}
+			return int64(len(pat) + len(fmt.Sprint(args[1]))), nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		create table t(v);
+		insert into t values ('a'), ('bb'), ('ccc'), ('dddd');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from t where auxdata_match_test('pat', v) > 0").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 4 {
+		t.Fatalf("got %d rows, want 4", n)
+	}
+
+	// The constant pattern argument must have been "compiled" exactly once
+	// for the whole scan, not once per row.
+	if got := atomic.LoadInt32(&compiles); got != 1 {
+		t.Fatalf("pattern compiled %d times, want 1", got)
+	}
+}
+
+func TestFunctionErrorCodes(t *testing.T) {
+	if err := RegisterScalarFunction("err_code_test", 1, func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch fmt.Sprint(args[0]) {
+		case "auth":
+			return nil, &FunctionError{Msg: "not allowed", Code: sqlite3.SQLITE_AUTH}
+		case "toobig":
+			return nil, &FunctionError{Code: sqlite3.SQLITE_TOOBIG}
+		case "nomem":
+			return nil, &FunctionError{Code: sqlite3.SQLITE_NOMEM}
+		default:
+			return nil, errors.New("plain failure")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	code := func(query string) (int, string) {
+		var n int
+		err := db.QueryRow(query).Scan(&n)
+		if err == nil {
+			t.Fatalf("%s: expected error", query)
+		}
+		var se *Error
+		if !errors.As(err, &se) {
+			t.Fatalf("%s: error is %T, want *Error", query, err)
+		}
+		return se.Code() & 0xff, err.Error()
+	}
+
+	if c, msg := code("select err_code_test('auth')"); c != sqlite3.SQLITE_AUTH || !strings.Contains(msg, "not allowed") {
+		t.Fatalf("got code %d msg %q, want SQLITE_AUTH with custom message", c, msg)
+	}
+
+	if c, _ := code("select err_code_test('toobig')"); c != sqlite3.SQLITE_TOOBIG {
+		t.Fatalf("got code %d, want SQLITE_TOOBIG", c)
+	}
+
+	if c, _ := code("select err_code_test('nomem')"); c != sqlite3.SQLITE_NOMEM {
+		t.Fatalf("got code %d, want SQLITE_NOMEM", c)
+	}
+
+	// Plain Go errors keep the existing generic behavior.
+	if c, msg := code("select err_code_test('other')"); c != sqlite3.SQLITE_ERROR || !strings.Contains(msg, "plain failure") {
+		t.Fatalf("got code %d msg %q, want SQLITE_ERROR with message", c, msg)
+	}
+}
+
+func TestFunctionUserDataAndConn(t *testing.T) {
+	if err := RegisterFunction("udata_test", &FunctionImpl{
+		NArgs:         0,
+		Deterministic: true,
+		UserData:      "tenant-config",
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, ok := ctx.UserData().(string)
+			if !ok {
+				return nil, fmt.Errorf("UserData() = %T, want string", ctx.UserData())
+			}
+			return s, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// conn_tenant_test reads per-connection state through ctx.Conn: each
+	// connection stores its tenant id in a temp table.
+	if err := RegisterFunction("conn_tenant_test", &FunctionImpl{
+		NArgs: 0,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			cn := ctx.Conn()
+			if cn == nil {
+				return nil, errors.New("Conn() = nil")
+			}
+
+			rows, err := cn.QueryContext(context.Background(), "select id from temp.tenant", nil)
+			if err != nil {
+				return nil, err
+			}
+
+			defer rows.Close()
+
+			dest := make([]driver.Value, 1)
+			if err := rows.Next(dest); err != nil {
+				return nil, err
+			}
+
+			return dest[0], nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	var got string
+	if err := db.QueryRow("select udata_test()").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "tenant-config" {
+		t.Fatalf("udata_test() = %q, want %q", got, "tenant-config")
+	}
+
+	// Two connections held open at once, each with its own tenant id in a
+	// temp table, prove the function sees per-connection state.
+	ctx := context.Background()
+	tenants := []int64{7, 42}
+	conns := make([]*sql.Conn, len(tenants))
+	for i, tenant := range tenants {
+		cn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer cn.Close()
+
+		conns[i] = cn
+		if _, err := cn.ExecContext(ctx, "create temp table tenant(id)"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := cn.ExecContext(ctx, "insert into tenant values(?)", tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, tenant := range tenants {
+		var id int64
+		if err := conns[i].QueryRowContext(ctx, "select conn_tenant_test()").Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+
+		if id != tenant {
+			t.Fatalf("conn_tenant_test() = %d, want %d", id, tenant)
+		}
+	}
+}
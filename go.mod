@@ -1,22 +1,34 @@
 module github.com/syralon/sqlite
 
-go 1.24.0
+go 1.25.0
 
 require (
 	github.com/google/pprof v0.0.0-20250317173921-a4b03ec1a45e
-	golang.org/x/sys v0.37.0
+	github.com/parquet-go/parquet-go v0.27.0
+	github.com/prometheus/client_model v0.6.2
+	github.com/prometheus/common v0.70.1
+	golang.org/x/sys v0.47.0
+	golang.org/x/text v0.40.0
 	modernc.org/fileutil v1.3.40
 	modernc.org/libc v1.67.6
 	modernc.org/mathutil v1.7.1
 )
 
 require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/ncruces/go-strftime v1.0.0 // indirect
+	github.com/parquet-go/bitpack v1.0.0 // indirect
+	github.com/parquet-go/jsonlite v1.0.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/twpayne/go-geom v1.6.1 // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
 	modernc.org/memory v1.11.0 // indirect
 )
 
@@ -0,0 +1,73 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+// ColumnInfo describes one column of a table, as reported by the
+// pragma_table_xinfo table-valued function. The library enables pragma
+// virtual tables, so schema tooling can also query pragma_table_info and
+// friends directly and join them against other tables.
+type ColumnInfo struct {
+	// Name of the column.
+	Name string
+	// Type is the declared type, e.g. "INTEGER"; empty when none was
+	// declared.
+	Type string
+	// NotNull reports whether the column has a NOT NULL constraint.
+	NotNull bool
+	// Default is the default value expression as written in the schema, or
+	// empty when there is none.
+	Default string
+	// PrimaryKey is the 1-based position of the column within the primary
+	// key, or 0 when the column is not part of it.
+	PrimaryKey int
+	// Hidden classifies the column: 0 normal, 1 hidden (virtual table), 2
+	// generated virtual, 3 generated stored.
+	Hidden int
+}
+
+// TableInfo returns the columns of the named table in declaration order,
+// wrapping the pragma_table_xinfo table-valued function. Unlike
+// pragma_table_info it includes hidden and generated columns; consult
+// ColumnInfo.Hidden to filter them. A missing table yields no columns and no
+// error, like the pragma itself.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) TableInfo(table string) ([]ColumnInfo, error) {
+	r, err := c.query(context.Background(),
+		`select name, type, "notnull", coalesce(dflt_value, ''), pk, hidden from pragma_table_xinfo(?)`,
+		[]driver.NamedValue{{Ordinal: 1, Value: table}})
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	rr := r.(*rows)
+	var cols []ColumnInfo
+	dest := make([]driver.Value, len(rr.columns))
+	for {
+		if err := rr.Next(dest); err != nil {
+			if err == io.EOF {
+				return cols, nil
+			}
+			return nil, err
+		}
+
+		cols = append(cols, ColumnInfo{
+			Name:       asString(dest[0]),
+			Type:       asString(dest[1]),
+			NotNull:    asInt64(dest[2]) != 0,
+			Default:    asString(dest[3]),
+			PrimaryKey: int(asInt64(dest[4])),
+			Hidden:     int(asInt64(dest[5])),
+		})
+	}
+}
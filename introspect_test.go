@@ -0,0 +1,121 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestPragmaTableValuedFunctions(t *testing.T) {
+	db, err := sql.Open(driverName, t.TempDir()+"/introspect.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		create table people(
+			id integer primary key,
+			name text not null,
+			age integer default 42,
+			initials text generated always as (substr(name, 1, 1)) virtual
+		);
+		create table pets(id integer primary key, owner references people(id))`); err != nil {
+		t.Fatal(err)
+	}
+
+	// pragma TVFs join against ordinary tables.
+	rows, err := db.Query(`
+		select m.name, p.name
+		from sqlite_master m join pragma_table_info(m.name) p
+		where m.type = 'table' order by m.name, p.cid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var tbl, col string
+		if err := rows.Scan(&tbl, &col); err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 5 { // people: id, name, age; pets: id, owner (generated cols excluded)
+		t.Fatalf("join returned %d columns, want 5", n)
+	}
+
+	// pragma_foreign_key_list works as a TVF too.
+	var fkTable string
+	if err := db.QueryRow(`select "table" from pragma_foreign_key_list('pets')`).Scan(&fkTable); err != nil {
+		t.Fatal(err)
+	}
+
+	if fkTable != "people" {
+		t.Fatalf("foreign key references %q, want people", fkTable)
+	}
+
+	ctx := context.Background()
+	cn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type tableInfoer interface {
+		TableInfo(table string) ([]ColumnInfo, error)
+	}
+	var cols []ColumnInfo
+	if err := cn.Raw(func(driverConn interface{}) error {
+		var err error
+		cols, err = driverConn.(tableInfoer).TableInfo("people")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cols) != 4 {
+		t.Fatalf("got %d columns, want 4: %+v", len(cols), cols)
+	}
+
+	if c := cols[0]; c.Name != "id" || c.PrimaryKey != 1 || c.Hidden != 0 {
+		t.Fatalf("unexpected id column: %+v", c)
+	}
+
+	if c := cols[1]; c.Name != "name" || !c.NotNull || c.Type != "TEXT" {
+		t.Fatalf("unexpected name column: %+v", c)
+	}
+
+	if c := cols[2]; c.Name != "age" || c.Default != "42" {
+		t.Fatalf("unexpected age column: %+v", c)
+	}
+
+	if c := cols[3]; c.Name != "initials" || c.Hidden != 2 {
+		t.Fatalf("unexpected initials column: %+v", c)
+	}
+
+	// Missing tables yield no columns, like the pragma.
+	if err := cn.Raw(func(driverConn interface{}) error {
+		var err error
+		cols, err = driverConn.(tableInfoer).TableInfo("nosuch")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cols) != 0 {
+		t.Fatalf("got %d columns for a missing table, want 0", len(cols))
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// EnableLoadExtension turns run-time loadable extension support on or off
+// for the connection, wrapping sqlite3_enable_load_extension. It enables
+// both the C API and the load_extension() SQL function; use
+// DBConfigEnableLoadExtension to enable only the C API, which is the safer
+// option when the connection executes untrusted SQL.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) EnableLoadExtension(on bool) error {
+	var onoff int32
+	if on {
+		onoff = 1
+	}
+	if rc := sqlite3.Xsqlite3_enable_load_extension(c.tls, c.db, onoff); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// LoadExtension loads the shared library at path into the connection,
+// wrapping sqlite3_load_extension. entry names the extension's init
+// function; pass "" to use the default sqlite3_extension_init derived from
+// the file name. Extension loading must first be enabled with
+// EnableLoadExtension or DBConfigEnableLoadExtension.
+//
+// Loading runs the library's initialization through the libc dynamic loader,
+// so it is only available on platforms where that loader supports dlopen.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) LoadExtension(path, entry string) error {
+	zFile, err := libc.CString(path)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(zFile)
+
+	var zProc uintptr
+	if entry != "" {
+		if zProc, err = libc.CString(entry); err != nil {
+			return err
+		}
+
+		defer c.free(zProc)
+	}
+
+	pzErrMsg := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	*(*uintptr)(unsafe.Pointer(pzErrMsg)) = 0
+	if rc := sqlite3.Xsqlite3_load_extension(c.tls, c.db, zFile, zProc, pzErrMsg); rc != sqlite3.SQLITE_OK {
+		if p := *(*uintptr)(unsafe.Pointer(pzErrMsg)); p != 0 {
+			defer sqlite3.Xsqlite3_free(c.tls, p)
+
+			return fmt.Errorf("sqlite: load extension %s: %s", path, libc.GoString(p))
+		}
+		return c.errstr(rc)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestLoadExtension(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type extensionLoader interface {
+		EnableLoadExtension(on bool) error
+		LoadExtension(path, entry string) error
+	}
+	enable := func(on bool) {
+		t.Helper()
+		if err := cn.Raw(func(driverConn any) error {
+			return driverConn.(extensionLoader).EnableLoadExtension(on)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func(path, entry string) error {
+		return cn.Raw(func(driverConn any) error {
+			return driverConn.(extensionLoader).LoadExtension(path, entry)
+		})
+	}
+
+	// Loading is disabled by default.
+	err = load("/nonexistent/extension", "")
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("expected authorization error, got %v", err)
+	}
+
+	// Once enabled the load is attempted; a missing file fails with a
+	// loader error instead of an authorization one.
+	enable(true)
+
+	err = load("/nonexistent/extension", "")
+	if err == nil || strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+
+	// And it can be turned off again.
+	enable(false)
+
+	err = load("/nonexistent/extension", "")
+	if err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Fatalf("expected authorization error, got %v", err)
+	}
+}
@@ -1,12 +1,16 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"testing"
 
+	sqlite3 "github.com/syralon/sqlite/lib"
 	"github.com/syralon/sqlite/vtab"
 )
 
@@ -1037,3 +1041,1185 @@ func TestVtabUpdaterInsertUpdateDelete(t *testing.T) {
 
 	assertRows([]int64{1, 3})
 }
+
+// ColumnUser demo: a read-only three-column table whose cursor records the
+// ColUsed mask and the idxStr it receives.
+type colUsedModuleX struct{}
+type colUsedTableX struct{}
+type colUsedCursorX struct {
+	colUsed uint64
+	idxStr  string
+	done    bool
+}
+
+var lastColUsedCursor *colUsedCursorX
+
+func (m *colUsedModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(a, b, c)`); err != nil {
+		return nil, err
+	}
+	return &colUsedTableX{}, nil
+}
+func (m *colUsedModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+func (t *colUsedTableX) BestIndex(info *vtab.IndexInfo) error {
+	info.IdxStr = "myplan"
+	return nil
+}
+func (t *colUsedTableX) Open() (vtab.Cursor, error) {
+	lastColUsedCursor = &colUsedCursorX{}
+	return lastColUsedCursor, nil
+}
+func (t *colUsedTableX) Disconnect() error { return nil }
+func (t *colUsedTableX) Destroy() error    { return nil }
+
+func (c *colUsedCursorX) SetColumnsUsed(colUsed uint64) { c.colUsed = colUsed }
+func (c *colUsedCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.idxStr = idxStr
+	c.done = false
+	return nil
+}
+func (c *colUsedCursorX) Next() error { c.done = true; return nil }
+func (c *colUsedCursorX) Eof() bool   { return c.done }
+func (c *colUsedCursorX) Column(col int) (vtab.Value, error) {
+	return int64(col), nil
+}
+func (c *colUsedCursorX) Rowid() (int64, error) { return 1, nil }
+func (c *colUsedCursorX) Close() error          { return nil }
+
+func TestVtabColumnUserReceivesColUsed(t *testing.T) {
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	if err := vtab.RegisterModule(db, "coldemo", &colUsedModuleX{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE ct USING coldemo`); err != nil {
+		t.Fatalf("create vt: %v", err)
+	}
+
+	var c int64
+	if err := db.QueryRow(`SELECT c FROM ct`).Scan(&c); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+
+	cur := lastColUsedCursor
+	if cur.colUsed&(1<<2) == 0 {
+		t.Fatalf("colUsed %#x: bit for referenced column c not set", cur.colUsed)
+	}
+	if cur.colUsed&(1<<0) != 0 {
+		t.Fatalf("colUsed %#x: bit for unreferenced column a is set", cur.colUsed)
+	}
+	// The bridge must strip its ColUsed suffix before Filter sees idxStr.
+	if cur.idxStr != "myplan" {
+		t.Fatalf("idxStr: got %q, want %q", cur.idxStr, "myplan")
+	}
+}
+
+// PartialUpdater demo: a two-column table that records the changed mask of
+// the most recent UPDATE.
+type partialModuleX struct{}
+type partialTableX struct {
+	updaterTableX // reuse rows/Insert/Delete; val maps to column 0
+	extra         map[int64]string
+	lastChanged   []bool
+	lastCols      []vtab.Value
+}
+
+var lastPartialTable *partialTableX
+
+func (m *partialModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(val, extra)`); err != nil {
+		return nil, err
+	}
+	lastPartialTable = &partialTableX{
+		updaterTableX: updaterTableX{nextID: 1},
+		extra:         make(map[int64]string),
+	}
+	return lastPartialTable, nil
+}
+func (m *partialModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+func (t *partialTableX) Open() (vtab.Cursor, error) {
+	return &partialCursorX{updaterCursorX{t: &t.updaterTableX}, t}, nil
+}
+func (t *partialTableX) Insert(cols []vtab.Value, rowid *int64) error {
+	if err := t.updaterTableX.Insert(cols[:1], rowid); err != nil {
+		return err
+	}
+	if s, ok := cols[1].(string); ok {
+		t.extra[*rowid] = s
+	}
+	return nil
+}
+func (t *partialTableX) UpdatePartial(oldRowid int64, cols []vtab.Value, changed []bool, newRowid *int64) error {
+	t.lastChanged = append([]bool(nil), changed...)
+	t.lastCols = append([]vtab.Value(nil), cols...)
+	if changed[0] {
+		if err := t.updaterTableX.Update(oldRowid, cols[:1], newRowid); err != nil {
+			return err
+		}
+	}
+	if changed[1] {
+		s, _ := cols[1].(string)
+		t.extra[oldRowid] = s
+	}
+	return nil
+}
+
+type partialCursorX struct {
+	updaterCursorX
+	pt *partialTableX
+}
+
+// partialColumnCalls counts the Column invocations that actually reach the
+// cursor, per column index.
+var partialColumnCalls = map[int]int{}
+
+func (c *partialCursorX) Column(col int) (vtab.Value, error) {
+	partialColumnCalls[col]++
+	if col == 1 {
+		return c.pt.extra[c.t.rows[c.pos].id], nil
+	}
+	return c.updaterCursorX.Column(col)
+}
+
+func TestVtabPartialUpdaterChangedMask(t *testing.T) {
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	if err := vtab.RegisterModule(db, "partdemo", &partialModuleX{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE pt USING partdemo`); err != nil {
+		t.Fatalf("create vt: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO pt(val, extra) VALUES('a', 'x')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	// Only the second column is assigned; the mask must say so.
+	if _, err := db.Exec(`UPDATE pt SET extra = 'y' WHERE rowid = 1`); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	pt := lastPartialTable
+	if len(pt.lastChanged) != 2 || pt.lastChanged[0] || !pt.lastChanged[1] {
+		t.Fatalf("changed mask: got %v, want [false true]", pt.lastChanged)
+	}
+
+	// Both columns assigned.
+	if _, err := db.Exec(`UPDATE pt SET val = 'b', extra = 'z' WHERE rowid = 1`); err != nil {
+		t.Fatalf("update both: %v", err)
+	}
+	if len(pt.lastChanged) != 2 || !pt.lastChanged[0] || !pt.lastChanged[1] {
+		t.Fatalf("changed mask: got %v, want [true true]", pt.lastChanged)
+	}
+
+	var val, extra string
+	if err := db.QueryRow(`SELECT val, extra FROM pt WHERE rowid = 1`).Scan(&val, &extra); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if val != "b" || extra != "z" {
+		t.Fatalf("got (%q, %q), want (%q, %q)", val, extra, "b", "z")
+	}
+}
+
+// BatchUpdater demo: writes arrive in bulk at transaction sync time.
+type batchModuleX struct{}
+type batchTableX struct {
+	rows         map[int64]string
+	nextID       int64
+	insertCalls  int
+	deleteCalls  int
+	lastInserted int
+}
+type batchCursorX struct {
+	ids []int64
+	t   *batchTableX
+	pos int
+}
+
+// lastBatchTable captures the most recently created table instance so the
+// test can inspect how the bridge batched its writes.
+var lastBatchTable *batchTableX
+
+func (m *batchModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(val)`); err != nil {
+		return nil, err
+	}
+	lastBatchTable = &batchTableX{rows: make(map[int64]string), nextID: 1}
+	return lastBatchTable, nil
+}
+func (m *batchModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+func (t *batchTableX) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (t *batchTableX) Open() (vtab.Cursor, error) {
+	c := &batchCursorX{t: t}
+	for id := range t.rows {
+		c.ids = append(c.ids, id)
+	}
+	sort.Slice(c.ids, func(i, j int) bool { return c.ids[i] < c.ids[j] })
+	return c, nil
+}
+func (t *batchTableX) Disconnect() error { return nil }
+func (t *batchTableX) Destroy() error    { return nil }
+
+func (t *batchTableX) InsertBatch(rows []vtab.Row) error {
+	t.insertCalls++
+	t.lastInserted = len(rows)
+	for _, r := range rows {
+		id := r.Rowid
+		if id < 0 { // provisional rowid from the bridge
+			id = t.nextID
+		}
+		if id >= t.nextID {
+			t.nextID = id + 1
+		}
+		val, _ := r.Cols[0].(string)
+		t.rows[id] = val
+	}
+	return nil
+}
+func (t *batchTableX) DeleteBatch(rowids []int64) error {
+	t.deleteCalls++
+	for _, id := range rowids {
+		delete(t.rows, id)
+	}
+	return nil
+}
+
+func (c *batchCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.pos = 0
+	return nil
+}
+func (c *batchCursorX) Next() error {
+	if c.pos < len(c.ids) {
+		c.pos++
+	}
+	return nil
+}
+func (c *batchCursorX) Eof() bool { return c.pos >= len(c.ids) }
+func (c *batchCursorX) Column(col int) (vtab.Value, error) {
+	return c.t.rows[c.ids[c.pos]], nil
+}
+func (c *batchCursorX) Rowid() (int64, error) { return c.ids[c.pos], nil }
+func (c *batchCursorX) Close() error          { return nil }
+
+func TestVtabBatchUpdater(t *testing.T) {
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	// Keep a single underlying connection so the vtab instance is shared.
+	db.SetMaxOpenConns(1)
+
+	if err := vtab.RegisterModule(db, "batchdemo", &batchModuleX{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE bt USING batchdemo`); err != nil {
+		t.Fatalf("create vt: %v", err)
+	}
+
+	count := func() (n int) {
+		if err := db.QueryRow(`SELECT count(*) FROM bt`).Scan(&n); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		return n
+	}
+
+	// Three inserts in one transaction must arrive as a single InsertBatch.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := tx.Exec(`INSERT INTO bt(val) VALUES(?)`, v); err != nil {
+			t.Fatalf("insert %q: %v", v, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := count(); got != 3 {
+		t.Fatalf("got %d rows after commit, want 3", got)
+	}
+	if bt := lastBatchTable; bt.insertCalls != 1 || bt.lastInserted != 3 {
+		t.Fatalf("got %d InsertBatch calls with %d rows last, want 1 call with 3 rows",
+			bt.insertCalls, bt.lastInserted)
+	}
+
+	// A rolled back transaction must leave the table untouched.
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO bt(val) VALUES(?)`, "dropped"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+
+	if got := count(); got != 3 {
+		t.Fatalf("got %d rows after rollback, want 3", got)
+	}
+
+	// Deletes batch the same way.
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM bt WHERE val IN ('a', 'b')`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if got := count(); got != 1 {
+		t.Fatalf("got %d rows after batched delete, want 1", got)
+	}
+}
+
+// estModuleX exposes the planner's default cost estimates to the test and
+// offers a cheap EQ-constrained plan next to the default full scan.
+type estModuleX struct{}
+
+type estTableX struct{}
+
+type estCursorX struct{ done bool }
+
+var (
+	// estDefaults captures EstimatedCost/EstimatedRows as seen by the first
+	// BestIndex call, before the module changes them.
+	estDefaultCost   float64
+	estDefaultRows   int64
+	estDefaultsSeen  bool
+	estFilterIdxNums []int
+)
+
+func (m *estModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(c1)`); err != nil {
+		return nil, err
+	}
+	return &estTableX{}, nil
+}
+func (m *estModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *estTableX) BestIndex(info *vtab.IndexInfo) error {
+	if !estDefaultsSeen {
+		estDefaultCost = info.EstimatedCost
+		estDefaultRows = info.EstimatedRows
+		estDefaultsSeen = true
+	}
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if c.Usable && c.Op == vtab.OpEQ && c.Column == 0 {
+			// The constrained plan is far cheaper than the default full
+			// scan, so the planner must prefer it.
+			c.ArgIndex = 0
+			info.IdxNum = 1
+			info.EstimatedCost = 10
+			info.EstimatedRows = 1
+			break
+		}
+	}
+	return nil
+}
+func (t *estTableX) Open() (vtab.Cursor, error) { return &estCursorX{}, nil }
+func (t *estTableX) Disconnect() error          { return nil }
+func (t *estTableX) Destroy() error             { return nil }
+
+func (c *estCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	estFilterIdxNums = append(estFilterIdxNums, idxNum)
+	c.done = false
+	return nil
+}
+func (c *estCursorX) Next() error                        { c.done = true; return nil }
+func (c *estCursorX) Eof() bool                          { return c.done }
+func (c *estCursorX) Column(col int) (vtab.Value, error) { return int64(1), nil }
+func (c *estCursorX) Rowid() (int64, error)              { return 1, nil }
+func (c *estCursorX) Close() error                       { return nil }
+
+// TestVtabEstimates verifies that BestIndex reads the planner's default
+// estimatedCost/estimatedRows and that lowering them steers plan selection
+// towards the constrained plan.
+func TestVtabEstimates(t *testing.T) {
+	estDefaultsSeen = false
+	estFilterIdxNums = nil
+
+	if err := vtab.RegisterModule(nil, "esttest", &estModuleX{}); err != nil {
+		t.Fatalf("RegisterModule esttest: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE et USING esttest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	var v int64
+	if err := db.QueryRow(`SELECT c1 FROM et WHERE c1 = 1`).Scan(&v); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+
+	if !estDefaultsSeen {
+		t.Fatalf("expected BestIndex to be called")
+	}
+	// SQLite seeds estimatedRows with 25 and estimatedCost with a huge
+	// full-scan default; the exact cost is an implementation detail so only
+	// sanity-check its magnitude.
+	if estDefaultRows != 25 {
+		t.Fatalf("default EstimatedRows = %d, want 25", estDefaultRows)
+	}
+	if estDefaultCost <= 0 {
+		t.Fatalf("default EstimatedCost = %v, want > 0", estDefaultCost)
+	}
+
+	// The cheap constrained plan (IdxNum 1) must win over the full scan.
+	if len(estFilterIdxNums) == 0 || estFilterIdxNums[len(estFilterIdxNums)-1] != 1 {
+		t.Fatalf("Filter saw idxNums %v, want the constrained plan (1) chosen", estFilterIdxNums)
+	}
+}
+
+// inModuleX exercises IN-constraint batch processing. With batch set the
+// module asks for the whole IN list in one Filter call.
+type inModuleX struct{ batch bool }
+
+type inTableX struct{ m *inModuleX }
+
+type inCursorX struct{ done bool }
+
+var (
+	inFilterCalls int
+	inFilterVals  [][]vtab.Value
+	inSawCapable  bool
+)
+
+func (m *inModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(c1)`); err != nil {
+		return nil, err
+	}
+	return &inTableX{m: m}, nil
+}
+func (m *inModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *inTableX) BestIndex(info *vtab.IndexInfo) error {
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Op != vtab.OpEQ {
+			continue
+		}
+		if c.InCapable {
+			inSawCapable = true
+		}
+		c.ArgIndex = 0
+		c.Omit = true
+		if t.m.batch {
+			c.ProcessIn = true
+		}
+		break
+	}
+	return nil
+}
+func (t *inTableX) Open() (vtab.Cursor, error) { return &inCursorX{}, nil }
+func (t *inTableX) Disconnect() error          { return nil }
+func (t *inTableX) Destroy() error             { return nil }
+
+func (c *inCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	inFilterCalls++
+	inFilterVals = append(inFilterVals, append([]vtab.Value(nil), vals...))
+	c.done = false
+	return nil
+}
+func (c *inCursorX) Next() error                        { c.done = true; return nil }
+func (c *inCursorX) Eof() bool                          { return c.done }
+func (c *inCursorX) Column(col int) (vtab.Value, error) { return int64(7), nil }
+func (c *inCursorX) Rowid() (int64, error)              { return 1, nil }
+func (c *inCursorX) Close() error                       { return nil }
+
+// TestVtabProcessIn verifies that a constraint flagged ProcessIn delivers the
+// whole IN (...) list to a single Filter call as vtab.InValues, while an
+// unflagged module keeps the one-call-per-element behavior.
+func TestVtabProcessIn(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "in_batch", &inModuleX{batch: true}); err != nil {
+		t.Fatalf("RegisterModule in_batch: %v", err)
+	}
+	if err := vtab.RegisterModule(nil, "in_single", &inModuleX{batch: false}); err != nil {
+		t.Fatalf("RegisterModule in_single: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	for _, ddl := range []string{
+		`CREATE VIRTUAL TABLE tb USING in_batch`,
+		`CREATE VIRTUAL TABLE ts USING in_single`,
+	} {
+		if _, err := db.Exec(ddl); err != nil {
+			t.Fatalf("%s: %v", ddl, err)
+		}
+	}
+
+	run := func(table string) int {
+		t.Helper()
+		inFilterCalls = 0
+		inFilterVals = nil
+		rows, err := db.Query(`SELECT c1 FROM ` + table + ` WHERE c1 IN (10, 20, 30)`)
+		if err != nil {
+			t.Fatalf("select from %s: %v", table, err)
+		}
+		defer rows.Close()
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("rows.Err: %v", err)
+		}
+		return n
+	}
+
+	run("tb")
+	if !inSawCapable {
+		t.Fatalf("expected BestIndex to see an IN-capable constraint")
+	}
+	if inFilterCalls != 1 {
+		t.Fatalf("batch module: Filter called %d times, want 1", inFilterCalls)
+	}
+	list, ok := inFilterVals[0][0].(vtab.InValues)
+	if !ok {
+		t.Fatalf("batch module: argv[0] is %T, want vtab.InValues", inFilterVals[0][0])
+	}
+	if len(list) != 3 || list[0] != int64(10) || list[1] != int64(20) || list[2] != int64(30) {
+		t.Fatalf("batch module: got IN list %v, want [10 20 30]", list)
+	}
+
+	run("ts")
+	if inFilterCalls != 3 {
+		t.Fatalf("single module: Filter called %d times, want 3", inFilterCalls)
+	}
+	for i, vals := range inFilterVals {
+		if _, ok := vals[0].(vtab.InValues); ok {
+			t.Fatalf("single module: call %d unexpectedly received a batched list", i)
+		}
+	}
+}
+
+// rhsModuleX records the constraints BestIndex sees, including plan-time RHS
+// values.
+type rhsModuleX struct{}
+
+type rhsTableX struct{}
+
+type rhsCursorX struct{ done bool }
+
+var rhsSeen []vtab.Constraint
+
+func (m *rhsModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(c1)`); err != nil {
+		return nil, err
+	}
+	return &rhsTableX{}, nil
+}
+func (m *rhsModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *rhsTableX) BestIndex(info *vtab.IndexInfo) error {
+	rhsSeen = append(rhsSeen, info.Constraints...)
+	return nil
+}
+func (t *rhsTableX) Open() (vtab.Cursor, error) { return &rhsCursorX{}, nil }
+func (t *rhsTableX) Disconnect() error          { return nil }
+func (t *rhsTableX) Destroy() error             { return nil }
+
+func (c *rhsCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.done = false
+	return nil
+}
+func (c *rhsCursorX) Next() error                        { c.done = true; return nil }
+func (c *rhsCursorX) Eof() bool                          { return c.done }
+func (c *rhsCursorX) Column(col int) (vtab.Value, error) { return "prefix-1", nil }
+func (c *rhsCursorX) Rowid() (int64, error)              { return 1, nil }
+func (c *rhsCursorX) Close() error                       { return nil }
+
+// TestVtabRHSValue verifies that literal right-hand sides are visible to
+// BestIndex while bound parameters stay unknown.
+func TestVtabRHSValue(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "rhstest", &rhsModuleX{}); err != nil {
+		t.Fatalf("RegisterModule rhstest: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE rt USING rhstest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	rhsSeen = nil
+	if _, err := db.Exec(`SELECT * FROM rt WHERE c1 LIKE 'prefix-%'`); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	var likeSeen bool
+	for _, c := range rhsSeen {
+		if c.Op == vtab.OpLIKE {
+			likeSeen = true
+			if !c.RHSKnown || c.RHSValue != "prefix-%" {
+				t.Fatalf("LIKE constraint: known=%v value=%v, want the literal pattern", c.RHSKnown, c.RHSValue)
+			}
+		}
+	}
+	if !likeSeen {
+		t.Fatalf("expected a LIKE constraint; got %+v", rhsSeen)
+	}
+
+	rhsSeen = nil
+	if _, err := db.Exec(`SELECT * FROM rt WHERE c1 = ?`, 5); err != nil {
+		t.Fatalf("select with parameter: %v", err)
+	}
+	for _, c := range rhsSeen {
+		if c.Op == vtab.OpEQ && c.RHSKnown {
+			t.Fatalf("bound parameter unexpectedly known at plan time: %+v", c)
+		}
+	}
+}
+
+// TestVtabConstraintCollation verifies that BestIndex sees the collating
+// sequence of each constraint, reusing the rhstest module's capture.
+func TestVtabConstraintCollation(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "colltest", &rhsModuleX{}); err != nil {
+		t.Fatalf("RegisterModule colltest: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE ct USING colltest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	collations := func(query string) map[vtab.ConstraintOp]string {
+		t.Helper()
+		rhsSeen = nil
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		m := make(map[vtab.ConstraintOp]string)
+		for _, c := range rhsSeen {
+			m[c.Op] = c.Collation
+		}
+		return m
+	}
+
+	if got := collations(`SELECT * FROM ct WHERE c1 = 'a'`); got[vtab.OpEQ] != "BINARY" {
+		t.Fatalf("got collation %q for plain EQ, want BINARY", got[vtab.OpEQ])
+	}
+	if got := collations(`SELECT * FROM ct WHERE c1 = 'a' COLLATE NOCASE`); got[vtab.OpEQ] != "NOCASE" {
+		t.Fatalf("got collation %q for COLLATE NOCASE EQ, want NOCASE", got[vtab.OpEQ])
+	}
+}
+
+// distinctModuleX records the Distinct hint BestIndex receives.
+type distinctModuleX struct{}
+
+type distinctTableX struct{}
+
+var lastDistinct int
+
+func (m *distinctModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare(`CREATE TABLE x(c1)`); err != nil {
+		return nil, err
+	}
+	return &distinctTableX{}, nil
+}
+func (m *distinctModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *distinctTableX) BestIndex(info *vtab.IndexInfo) error {
+	lastDistinct = info.Distinct
+	return nil
+}
+func (t *distinctTableX) Open() (vtab.Cursor, error) { return &rhsCursorX{}, nil }
+func (t *distinctTableX) Disconnect() error          { return nil }
+func (t *distinctTableX) Destroy() error             { return nil }
+
+// TestVtabDistinctHint verifies the sqlite3_vtab_distinct hint reaches
+// BestIndex for DISTINCT and GROUP BY queries.
+func TestVtabDistinctHint(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "disttest", &distinctModuleX{}); err != nil {
+		t.Fatalf("RegisterModule disttest: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE dt USING disttest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	hint := func(query string) int {
+		t.Helper()
+		lastDistinct = -1
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return lastDistinct
+	}
+
+	if got := hint(`SELECT c1 FROM dt`); got != vtab.DistinctNone {
+		t.Fatalf("plain query: Distinct = %d, want DistinctNone", got)
+	}
+	if got := hint(`SELECT count(*) FROM dt GROUP BY c1`); got != vtab.DistinctGroupBy {
+		t.Fatalf("GROUP BY query: Distinct = %d, want DistinctGroupBy", got)
+	}
+	if got := hint(`SELECT DISTINCT c1 FROM dt`); got != vtab.DistinctDistinct {
+		t.Fatalf("DISTINCT query: Distinct = %d, want DistinctDistinct", got)
+	}
+}
+
+// cfgModuleX applies the given vtab config options during Create/Connect.
+type cfgModuleX struct{ opts []vtab.ConfigOption }
+
+func (m *cfgModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.SetConfig(m.opts...); err != nil {
+		return nil, err
+	}
+	if err := ctx.Declare(`CREATE TABLE x(c1)`); err != nil {
+		return nil, err
+	}
+	return &distinctTableX{}, nil
+}
+func (m *cfgModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+// TestVtabSetConfig verifies the named sqlite3_vtab_config options: a
+// DIRECTONLY table is unusable from a view while an unrestricted one works,
+// and declaring constraint support succeeds.
+func TestVtabSetConfig(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "cfg_direct", &cfgModuleX{opts: []vtab.ConfigOption{vtab.ConfigDirectOnly}}); err != nil {
+		t.Fatalf("RegisterModule cfg_direct: %v", err)
+	}
+	if err := vtab.RegisterModule(nil, "cfg_plain", &cfgModuleX{opts: []vtab.ConfigOption{vtab.ConfigConstraintSupport}}); err != nil {
+		t.Fatalf("RegisterModule cfg_plain: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	for _, ddl := range []string{
+		`CREATE VIRTUAL TABLE vd USING cfg_direct`,
+		`CREATE VIRTUAL TABLE vp USING cfg_plain`,
+		`CREATE VIEW view_d AS SELECT * FROM vd`,
+		`CREATE VIEW view_p AS SELECT * FROM vp`,
+	} {
+		if _, err := db.Exec(ddl); err != nil {
+			t.Fatalf("%s: %v", ddl, err)
+		}
+	}
+
+	// Direct use is fine either way.
+	if _, err := db.Exec(`SELECT * FROM vd`); err != nil {
+		t.Fatalf("direct select from DIRECTONLY table: %v", err)
+	}
+
+	// DIRECTONLY keeps the table out of views; the unrestricted table works.
+	if _, err := db.Exec(`SELECT * FROM view_d`); err == nil {
+		t.Fatalf("expected selecting a DIRECTONLY table through a view to fail")
+	}
+	if _, err := db.Exec(`SELECT * FROM view_p`); err != nil {
+		t.Fatalf("select through view: %v", err)
+	}
+}
+
+// cmModuleX is a writable module keyed by a unique name column that honors
+// the per-statement conflict resolution mode.
+type cmModuleX struct{}
+
+type cmTableX struct {
+	rows map[string]int64
+	mode vtab.ConflictMode
+}
+
+type cmCursorX struct {
+	t     *cmTableX
+	names []string
+	pos   int
+}
+
+var (
+	lastCMTable *cmTableX
+	cmModes     []vtab.ConflictMode
+)
+
+func (m *cmModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.SetConfig(vtab.ConfigConstraintSupport); err != nil {
+		return nil, err
+	}
+	if err := ctx.Declare(`CREATE TABLE x(name, v)`); err != nil {
+		return nil, err
+	}
+	lastCMTable = &cmTableX{rows: make(map[string]int64)}
+	return lastCMTable, nil
+}
+func (m *cmModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *cmTableX) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (t *cmTableX) Open() (vtab.Cursor, error) {
+	c := &cmCursorX{t: t}
+	for name := range t.rows {
+		c.names = append(c.names, name)
+	}
+	sort.Strings(c.names)
+	return c, nil
+}
+func (t *cmTableX) Disconnect() error { return nil }
+func (t *cmTableX) Destroy() error    { return nil }
+
+// SetConflictMode implements vtab.ConflictHandler.
+func (t *cmTableX) SetConflictMode(mode vtab.ConflictMode) {
+	t.mode = mode
+	cmModes = append(cmModes, mode)
+}
+
+func (t *cmTableX) Insert(cols []vtab.Value, rowid *int64) error {
+	name, _ := cols[0].(string)
+	v, _ := cols[1].(int64)
+	if _, exists := t.rows[name]; exists && t.mode != vtab.ConflictReplace {
+		return fmt.Errorf("cm: duplicate name %q", name)
+	}
+	t.rows[name] = v
+	*rowid = int64(len(t.rows))
+	return nil
+}
+func (t *cmTableX) Update(oldRowid int64, cols []vtab.Value, newRowid *int64) error {
+	return t.Insert(cols, newRowid)
+}
+func (t *cmTableX) Delete(oldRowid int64) error { return nil }
+
+func (c *cmCursorX) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.pos = 0
+	return nil
+}
+func (c *cmCursorX) Next() error { c.pos++; return nil }
+func (c *cmCursorX) Eof() bool   { return c.pos >= len(c.names) }
+func (c *cmCursorX) Column(col int) (vtab.Value, error) {
+	if col == 0 {
+		return c.names[c.pos], nil
+	}
+	return c.t.rows[c.names[c.pos]], nil
+}
+func (c *cmCursorX) Rowid() (int64, error) { return int64(c.pos) + 1, nil }
+func (c *cmCursorX) Close() error          { return nil }
+
+// TestVtabConflictMode verifies that xUpdate sees the statement's ON
+// CONFLICT resolution mode and can implement OR REPLACE itself.
+func TestVtabConflictMode(t *testing.T) {
+	cmModes = nil
+	if err := vtab.RegisterModule(nil, "cmtest", &cmModuleX{}); err != nil {
+		t.Fatalf("RegisterModule cmtest: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE cm USING cmtest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO cm VALUES ('a', 1)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if len(cmModes) != 1 || cmModes[0] != vtab.ConflictAbort {
+		t.Fatalf("plain INSERT saw modes %v, want [ConflictAbort]", cmModes)
+	}
+
+	// A plain duplicate fails in the module...
+	if _, err := db.Exec(`INSERT INTO cm VALUES ('a', 2)`); err == nil {
+		t.Fatalf("expected duplicate insert to fail")
+	}
+
+	// ...but OR REPLACE is reported as ConflictReplace and replaces.
+	cmModes = nil
+	if _, err := db.Exec(`INSERT OR REPLACE INTO cm VALUES ('a', 2)`); err != nil {
+		t.Fatalf("insert or replace: %v", err)
+	}
+	if len(cmModes) != 1 || cmModes[0] != vtab.ConflictReplace {
+		t.Fatalf("INSERT OR REPLACE saw modes %v, want [ConflictReplace]", cmModes)
+	}
+
+	var v int64
+	if err := db.QueryRow(`SELECT v FROM cm WHERE name = 'a'`).Scan(&v); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if v != 2 {
+		t.Fatalf("got v = %d after replace, want 2", v)
+	}
+}
+
+// TestVtabNoChangeColumnSkip verifies the sqlite3_vtab_nochange fast path:
+// during an UPDATE on a PartialUpdater table the unassigned columns are
+// neither materialized by the cursor nor delivered as values.
+func TestVtabNoChangeColumnSkip(t *testing.T) {
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	if err := vtab.RegisterModule(db, "nochangedemo", &partialModuleX{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE nt USING nochangedemo`); err != nil {
+		t.Fatalf("create vt: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO nt(val, extra) VALUES('a', 'x')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	partialColumnCalls = map[int]int{}
+	if _, err := db.Exec(`UPDATE nt SET extra = 'y' WHERE rowid = 1`); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// The carried-through val column must not have been read back.
+	if n := partialColumnCalls[0]; n != 0 {
+		t.Fatalf("unchanged column materialized %d times, want 0", n)
+	}
+
+	pt := lastPartialTable
+	if len(pt.lastCols) != 2 || pt.lastCols[0] != nil {
+		t.Fatalf("unchanged column value = %v, want nil", pt.lastCols[0])
+	}
+	if s, _ := pt.lastCols[1].(string); s != "y" {
+		t.Fatalf("changed column value = %v, want \"y\"", pt.lastCols[1])
+	}
+}
+
+// typedErrModuleX returns a typed vtab.Error with SQLITE_CONSTRAINT from
+// Insert when the single value already exists.
+type typedErrModuleX struct{}
+
+type typedErrTableX struct {
+	vals map[int64]bool
+}
+
+var lastTypedErrTable *typedErrTableX
+
+func (m *typedErrModuleX) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.SetConfig(vtab.ConfigConstraintSupport); err != nil {
+		return nil, err
+	}
+	if err := ctx.Declare(`CREATE TABLE x(v)`); err != nil {
+		return nil, err
+	}
+	lastTypedErrTable = &typedErrTableX{vals: make(map[int64]bool)}
+	return lastTypedErrTable, nil
+}
+func (m *typedErrModuleX) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (t *typedErrTableX) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (t *typedErrTableX) Open() (vtab.Cursor, error)           { return &rhsCursorX{}, nil }
+func (t *typedErrTableX) Disconnect() error                    { return nil }
+func (t *typedErrTableX) Destroy() error                       { return nil }
+
+func (t *typedErrTableX) Insert(cols []vtab.Value, rowid *int64) error {
+	v, _ := cols[0].(int64)
+	if t.vals[v] {
+		return &vtab.Error{Code: vtab.ErrConstraint, Msg: fmt.Sprintf("typederr: %d already present", v)}
+	}
+	t.vals[v] = true
+	*rowid = v
+	return nil
+}
+func (t *typedErrTableX) Update(oldRowid int64, cols []vtab.Value, newRowid *int64) error {
+	return nil
+}
+func (t *typedErrTableX) Delete(oldRowid int64) error { return nil }
+
+// TestVtabTypedError verifies that a *vtab.Error surfaces its result code:
+// the driver reports SQLITE_CONSTRAINT with the module's message, and OR
+// IGNORE turns the same violation into a silent no-op.
+func TestVtabTypedError(t *testing.T) {
+	if err := vtab.RegisterModule(nil, "typederr", &typedErrModuleX{}); err != nil {
+		t.Fatalf("RegisterModule typederr: %v", err)
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE te USING typederr`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO te VALUES (1)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO te VALUES (1)`)
+	if err == nil {
+		t.Fatalf("expected the duplicate insert to fail")
+	}
+	var serr *Error
+	if !errors.As(err, &serr) {
+		t.Fatalf("got %T (%v), want *sqlite.Error", err, err)
+	}
+	if serr.Code()&0xff != sqlite3.SQLITE_CONSTRAINT {
+		t.Fatalf("got result code %d, want SQLITE_CONSTRAINT", serr.Code())
+	}
+	if !strings.Contains(serr.Error(), "already present") {
+		t.Fatalf("error %q does not carry the module's message", serr.Error())
+	}
+
+	// With constraint support declared, OR IGNORE swallows the violation.
+	if _, err := db.Exec(`INSERT OR IGNORE INTO te VALUES (1)`); err != nil {
+		t.Fatalf("insert or ignore: %v", err)
+	}
+	if len(lastTypedErrTable.vals) != 1 {
+		t.Fatalf("got %d rows after OR IGNORE, want 1", len(lastTypedErrTable.vals))
+	}
+}
+
+func TestVtabUnregisterModule(t *testing.T) {
+	tempDir := t.TempDir()
+
+	if err := vtab.RegisterModule(nil, "droptest", &dummyModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, tempDir+"/unregister1.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE a USING droptest`); err != nil {
+		t.Fatalf("create virtual table: %v", err)
+	}
+
+	if err := vtab.UnregisterModule(nil, "droptest"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unregistering a name that is no longer registered is an error.
+	if err := vtab.UnregisterModule(nil, "droptest"); err == nil {
+		t.Fatal("expected unregistering twice to fail")
+	}
+
+	// New connections no longer install the module.
+	db2, err := sql.Open(driverName, tempDir+"/unregister2.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db2.Close()
+
+	if _, err := db2.Exec(`CREATE VIRTUAL TABLE b USING droptest`); err == nil {
+		t.Fatal("expected CREATE VIRTUAL TABLE after unregistration to fail")
+	} else if !strings.Contains(err.Error(), "no such module") {
+		t.Fatalf("got %v, want a no such module error", err)
+	}
+
+	// Unregistration frees the name for re-registration.
+	if err := vtab.RegisterModule(nil, "droptest", &dummyModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db3, err := sql.Open(driverName, tempDir+"/unregister3.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db3.Close()
+
+	ctx := context.Background()
+	cn, err := db3.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(ctx, `CREATE VIRTUAL TABLE c USING droptest`); err != nil {
+		t.Fatalf("create virtual table after re-registration: %v", err)
+	}
+
+	if _, err := cn.ExecContext(ctx, `DROP TABLE c`); err != nil {
+		t.Fatal(err)
+	}
+
+	// DropModule removes the module from this live connection only.
+	if err := cn.Raw(func(driverConn any) error {
+		return driverConn.(interface{ DropModule(name string) error }).DropModule("droptest")
+	}); err != nil {
+		t.Fatalf("DropModule: %v", err)
+	}
+
+	if _, err := cn.ExecContext(ctx, `CREATE VIRTUAL TABLE c USING droptest`); err == nil {
+		t.Fatal("expected CREATE VIRTUAL TABLE after DropModule to fail")
+	}
+
+	// The global registration is untouched: new connections still see it.
+	db4, err := sql.Open(driverName, tempDir+"/unregister4.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db4.Close()
+
+	if _, err := db4.Exec(`CREATE VIRTUAL TABLE d USING droptest`); err != nil {
+		t.Fatalf("create virtual table on a fresh connection: %v", err)
+	}
+
+	if err := vtab.UnregisterModule(nil, "droptest"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BindNamed expands the :named parameters in query using values taken from
+// arg and returns the rewritten query together with the positional argument
+// list to pass to Exec/Query. arg may be a map[string]any or a struct
+// (pointers to either are followed); struct fields are matched by their "db"
+// tag, their "sql" tag, or, untagged, by their lower cased field name.
+// Anonymous embedded struct fields are flattened.
+//
+// Values that are slices or arrays (except []byte) expand into one
+// placeholder per element, so :ids with []int64{1, 2, 3} becomes ?,?,? —
+// which makes IN (:ids) work without counting placeholders by hand. An empty
+// slice expands to NULL, so IN (:ids) matches no rows.
+//
+// Named parameters inside string literals, quoted identifiers and comments
+// are left alone. Referencing a name that arg does not provide is an error,
+// as is passing anything other than a map or struct.
+func BindNamed(query string, arg any) (string, []any, error) {
+	vals, err := namedValues(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		b    strings.Builder
+		args []any
+	)
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch ch {
+		case '\'', '"', '`':
+			// String literal or quoted identifier: copy until the matching
+			// quote, honoring doubled quotes.
+			j := i + 1
+			for j < len(query) {
+				if query[j] == ch {
+					if j+1 < len(query) && query[j+1] == ch {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				j++
+			}
+			b.WriteString(query[i:j])
+			i = j
+		case '[':
+			// Bracketed identifier.
+			j := strings.IndexByte(query[i:], ']')
+			if j < 0 {
+				j = len(query)
+			} else {
+				j = i + j + 1
+			}
+			b.WriteString(query[i:j])
+			i = j
+		case '-':
+			if i+1 < len(query) && query[i+1] == '-' {
+				// Line comment.
+				j := strings.IndexByte(query[i:], '\n')
+				if j < 0 {
+					j = len(query)
+				} else {
+					j = i + j
+				}
+				b.WriteString(query[i:j])
+				i = j
+				continue
+			}
+			b.WriteByte(ch)
+			i++
+		case '/':
+			if i+1 < len(query) && query[i+1] == '*' {
+				// Block comment.
+				j := strings.Index(query[i+2:], "*/")
+				if j < 0 {
+					j = len(query)
+				} else {
+					j = i + 2 + j + 2
+				}
+				b.WriteString(query[i:j])
+				i = j
+				continue
+			}
+			b.WriteByte(ch)
+			i++
+		case ':':
+			j := i + 1
+			for j < len(query) && isNamedParamChar(query[j]) {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte(ch)
+				i++
+				continue
+			}
+			name := query[i+1 : j]
+			v, ok := vals[name]
+			if !ok {
+				v, ok = vals[strings.ToLower(name)]
+			}
+			if !ok {
+				return "", nil, fmt.Errorf("sqlite: BindNamed: missing value for parameter :%s", name)
+			}
+			args = expandNamedValue(&b, args, v)
+			i = j
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	return b.String(), args, nil
+}
+
+func isNamedParamChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+// expandNamedValue writes the placeholder(s) for v to b and returns args with
+// the corresponding values appended.
+func expandNamedValue(b *strings.Builder, args []any, v any) []any {
+	rv := reflect.ValueOf(v)
+	if v != nil && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		n := rv.Len()
+		if n == 0 {
+			b.WriteString("NULL")
+			return args
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('?')
+			args = append(args, rv.Index(i).Interface())
+		}
+		return args
+	}
+	b.WriteByte('?')
+	return append(args, v)
+}
+
+// namedValues flattens arg into a name to value map.
+func namedValues(arg any) (map[string]any, error) {
+	rv := reflect.ValueOf(arg)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("sqlite: BindNamed: nil argument")
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("sqlite: BindNamed: map key type must be string, got %s", rv.Type().Key())
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, nil
+	case reflect.Struct:
+		m := make(map[string]any)
+		structValues(rv, m)
+		return m, nil
+	default:
+		return nil, fmt.Errorf("sqlite: BindNamed: unsupported argument type %T", arg)
+	}
+}
+
+// structValues adds the fields of the struct value rv to m, recursing into
+// anonymous embedded structs. Explicitly tagged names win over field names.
+func structValues(rv reflect.Value, m map[string]any) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+		// An anonymous field of unexported struct type still promotes its
+		// exported fields, so recurse before the export check.
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			structValues(fv, m)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Tag.Get("db")
+		if name == "" {
+			name = f.Tag.Get("sql")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		m[name] = fv.Interface()
+	}
+}
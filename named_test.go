@@ -0,0 +1,114 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBindNamedMap(t *testing.T) {
+	q, args, err := BindNamed(
+		"select * from t where a = :a and b in (:ids) and c = ':notaparam'",
+		map[string]any{"a": 1, "ids": []int64{10, 20, 30}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "select * from t where a = ? and b in (?,?,?) and c = ':notaparam'"
+	if q != want {
+		t.Fatalf("got query %q, want %q", q, want)
+	}
+
+	if !reflect.DeepEqual(args, []any{1, int64(10), int64(20), int64(30)}) {
+		t.Fatalf("got args %v", args)
+	}
+}
+
+func TestBindNamedStruct(t *testing.T) {
+	type common struct {
+		ID int64 `db:"id"`
+	}
+	type param struct {
+		common
+		Name    string `db:"name"`
+		Ignored string `db:"-"`
+		Limit   int
+	}
+
+	q, args, err := BindNamed(
+		"select * from t where id = :id and name = :name limit :limit",
+		&param{common: common{ID: 7}, Name: "x", Limit: 5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "select * from t where id = ? and name = ? limit ?"; q != want {
+		t.Fatalf("got query %q, want %q", q, want)
+	}
+
+	if !reflect.DeepEqual(args, []any{int64(7), "x", 5}) {
+		t.Fatalf("got args %v", args)
+	}
+}
+
+func TestBindNamedErrors(t *testing.T) {
+	if _, _, err := BindNamed("select :missing", map[string]any{}); err == nil {
+		t.Fatal("expected error for missing parameter")
+	}
+
+	if _, _, err := BindNamed("select 1", 42); err == nil {
+		t.Fatal("expected error for unsupported argument type")
+	}
+}
+
+func TestBindNamedQuery(t *testing.T) {
+	db, err := sql.Open(driverName, filepath.Join(t.TempDir(), "db.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec(`
+		create table t(id integer primary key, name text);
+		insert into t values (1, 'a'), (2, 'b'), (3, 'c');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	q, args, err := BindNamed("select count(*) from t where id in (:ids) and name <> :skip",
+		map[string]any{"ids": []int{1, 2, 3}, "skip": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow(q, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d, want 2", n)
+	}
+
+	// Empty slices expand to NULL and match nothing.
+	q, args, err = BindNamed("select count(*) from t where id in (:ids)", map[string]any{"ids": []int{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow(q, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("got %d, want 0", n)
+	}
+}
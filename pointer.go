@@ -0,0 +1,185 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// PointerValue carries a Go value through SQLite's pointer-passing interface
+// (https://www.sqlite.org/bindptr.html). Bound as a statement parameter it
+// reaches SQL as NULL, but code that knows the type name can retrieve the
+// original value: UDFs via FunctionContext.ArgPointer and vtab cursors, whose
+// Filter arguments deliver it back as a PointerValue. This lets Go code hand
+// rich objects, e.g. a prepared index structure, to carray-like modules
+// without serializing them.
+type PointerValue struct {
+	// Type is the pointer type name both sides must agree on, conventionally
+	// a reverse domain name.
+	Type string
+	// Value is the Go value being passed.
+	Value any
+}
+
+// Pointer wraps v for binding through sqlite3_bind_pointer under the given
+// type name.
+func Pointer(typ string, v any) PointerValue {
+	return PointerValue{Type: typ, Value: v}
+}
+
+// xPointers tracks Go values passed through the pointer interface. SQLite
+// stores only the handle id; the destructor trampoline drops the entry when
+// SQLite releases the pointer. Type name C strings are interned and never
+// freed: SQLite compares them by content at sqlite3_value_pointer time and
+// the set of distinct type names is small.
+var xPointers = struct {
+	mu    sync.RWMutex
+	m     map[uintptr]PointerValue
+	ids   idGen
+	types map[string]uintptr
+}{
+	m:     make(map[uintptr]PointerValue),
+	types: make(map[string]uintptr),
+}
+
+func pointerDestroyTrampoline(tls *libc.TLS, p uintptr) {
+	xPointers.mu.Lock()
+	delete(xPointers.m, p)
+	xPointers.ids.reclaim(p)
+	xPointers.mu.Unlock()
+}
+
+// internPointerType returns the interned C string for a pointer type name,
+// allocating it on first use.
+func internPointerType(typ string) (uintptr, error) {
+	xPointers.mu.RLock()
+	z, ok := xPointers.types[typ]
+	xPointers.mu.RUnlock()
+	if ok {
+		return z, nil
+	}
+
+	z, err := libc.CString(typ)
+	if err != nil {
+		return 0, err
+	}
+
+	xPointers.mu.Lock()
+	if existing, ok := xPointers.types[typ]; ok {
+		xPointers.mu.Unlock()
+		libc.Xfree(nil, z)
+		return existing, nil
+	}
+	xPointers.types[typ] = z
+	xPointers.mu.Unlock()
+	return z, nil
+}
+
+// newPointerHandle registers pv and returns the handle id SQLite will carry.
+func newPointerHandle(pv PointerValue) uintptr {
+	xPointers.mu.Lock()
+	id := xPointers.ids.next()
+	xPointers.m[id] = pv
+	xPointers.mu.Unlock()
+	return id
+}
+
+// lookupPointerHandle resolves a handle id back to the Go value.
+func lookupPointerHandle(id uintptr) (PointerValue, bool) {
+	xPointers.mu.RLock()
+	pv, ok := xPointers.m[id]
+	xPointers.mu.RUnlock()
+	return pv, ok
+}
+
+// bindPointer binds pv to the idx-th parameter of pstmt, wrapping
+// sqlite3_bind_pointer. SQLite owns the handle afterwards and the destructor
+// releases it when the binding is cleared or the statement finalized.
+func (c *conn) bindPointer(pstmt uintptr, idx int, pv PointerValue) error {
+	zType, err := internPointerType(pv.Type)
+	if err != nil {
+		return err
+	}
+
+	id := newPointerHandle(pv)
+	if rc := sqlite3.Xsqlite3_bind_pointer(
+		c.tls,
+		pstmt,
+		int32(idx),
+		id,
+		zType,
+		cFuncPointer(pointerDestroyTrampoline),
+	); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// pointerValueSubtype is the subtype SQLite assigns internally to values
+// carrying a pointer; it lets the vtab bridge recognize them in Filter
+// arguments without knowing the type name upfront.
+const pointerValueSubtype = 'p'
+
+// pointerFromValue recovers the PointerValue carried by the sqlite3_value at
+// pVal, trying every interned type name, or (PointerValue{}, false) when the
+// value carries no pointer this package handed out.
+func pointerFromValue(tls *libc.TLS, pVal uintptr) (PointerValue, bool) {
+	if sqlite3.Xsqlite3_value_subtype(tls, pVal) != pointerValueSubtype {
+		return PointerValue{}, false
+	}
+
+	xPointers.mu.RLock()
+	types := make([]uintptr, 0, len(xPointers.types))
+	for _, z := range xPointers.types {
+		types = append(types, z)
+	}
+	xPointers.mu.RUnlock()
+
+	for _, zType := range types {
+		if id := sqlite3.Xsqlite3_value_pointer(tls, pVal, zType); id != 0 {
+			return lookupPointerHandle(id)
+		}
+	}
+	return PointerValue{}, false
+}
+
+// ArgPointer returns the Go value passed as the i-th argument (zero-based) of
+// the current call through the pointer interface under the given type name,
+// wrapping sqlite3_value_pointer, or nil when the argument carries no pointer
+// of that type.
+func (f *FunctionContext) ArgPointer(i int, typ string) any {
+	if f.tls == nil || i < 0 || int32(i) >= f.argc {
+		return nil
+	}
+
+	zType, err := internPointerType(typ)
+	if err != nil {
+		return nil
+	}
+
+	pVal := *(*uintptr)(unsafe.Pointer(f.argv + uintptr(i)*ptrSize))
+	id := sqlite3.Xsqlite3_value_pointer(f.tls, pVal, zType)
+	if id == 0 {
+		return nil
+	}
+
+	pv, ok := lookupPointerHandle(id)
+	if !ok {
+		return nil
+	}
+	return pv.Value
+}
+
+// SetResultPointer makes the result of the current call a pointer value
+// carrying v under the given type name, wrapping sqlite3_result_pointer. The
+// driver.Value returned by the function is ignored; pointer results read as
+// NULL in SQL and are only retrievable through the pointer interface.
+func (f *FunctionContext) SetResultPointer(typ string, v any) {
+	f.resultPointer = &PointerValue{Type: typ, Value: v}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// testPlan is the rich object passed through the pointer interface in
+// TestPointerPassing.
+type testPlan struct {
+	ids []int64
+}
+
+func TestPointerPassing(t *testing.T) {
+	if err := RegisterFunction("ptr_count_test", &FunctionImpl{
+		NArgs:       1,
+		UsesSubtype: true,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			if p, ok := ctx.ArgPointer(0, "go.test/plan").(*testPlan); ok {
+				return int64(len(p.ids)), nil
+			}
+			return nil, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterFunction("ptr_make_test", &FunctionImpl{
+		NArgs:         0,
+		ResultSubtype: true,
+		Scalar: func(ctx *FunctionContext, args []driver.Value) (driver.Value, error) {
+			ctx.SetResultPointer("go.test/plan", &testPlan{ids: []int64{1, 2}})
+			return nil, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	argIndexFilterVals = nil
+	if err := vtab.RegisterModule(nil, "ptrargtest", &argIndexModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	// A bound pointer reaches the UDF under its type name.
+	var n sql.NullInt64
+	if err := db.QueryRow("select ptr_count_test(?)",
+		Pointer("go.test/plan", &testPlan{ids: []int64{1, 2, 3}})).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Valid || n.Int64 != 3 {
+		t.Fatalf("got %+v, want 3", n)
+	}
+
+	// A mismatched type name yields no pointer.
+	if err := db.QueryRow("select ptr_count_test(?)",
+		Pointer("go.test/other", &testPlan{})).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Valid {
+		t.Fatalf("got %+v, want NULL for wrong type name", n)
+	}
+
+	// Pointer values read as NULL in plain SQL.
+	var isNull int
+	if err := db.QueryRow("select ? is null",
+		Pointer("go.test/plan", &testPlan{})).Scan(&isNull); err != nil {
+		t.Fatal(err)
+	}
+
+	if isNull != 1 {
+		t.Fatal("pointer value must read as NULL in SQL")
+	}
+
+	// A pointer result flows from one function into another.
+	if err := db.QueryRow("select ptr_count_test(ptr_make_test())").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Valid || n.Int64 != 2 {
+		t.Fatalf("got %+v, want 2", n)
+	}
+
+	// A bound pointer reaches a vtab cursor's Filter arguments intact.
+	if _, err := db.Exec("create virtual table pt using ptrargtest(c1,c2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pointer reads as NULL, so SQLite filters out the re-checked rows;
+	// what matters is that Filter observed the original Go value.
+	rows, err := db.Query("select rowid from pt where c1 = ?",
+		Pointer("go.test/plan", &testPlan{ids: []int64{7, 8, 9}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	if len(argIndexFilterVals) != 1 {
+		t.Fatalf("Filter got %d args, want 1", len(argIndexFilterVals))
+	}
+
+	pv, ok := argIndexFilterVals[0].(PointerValue)
+	if !ok {
+		t.Fatalf("Filter arg is %T, want PointerValue", argIndexFilterVals[0])
+	}
+
+	if pv.Type != "go.test/plan" {
+		t.Fatalf("pointer type = %q, want %q", pv.Type, "go.test/plan")
+	}
+
+	if p := pv.Value.(*testPlan); len(p.ids) != 3 || p.ids[0] != 7 {
+		t.Fatalf("unexpected payload %+v", p)
+	}
+}
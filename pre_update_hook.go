@@ -30,6 +30,12 @@ var (
 	}{
 		m: make(map[uintptr]RollbackHookFn),
 	}
+	xUpdateHandlers = struct {
+		mu sync.RWMutex
+		m  map[uintptr]UpdateHookFn
+	}{
+		m: make(map[uintptr]UpdateHookFn),
+	}
 )
 
 type PreUpdateHookFn func(SQLitePreUpdateData)
@@ -82,6 +88,28 @@ func (c *conn) RegisterRollbackHook(callback RollbackHookFn) {
 	sqlite3.Xsqlite3_rollback_hook(c.tls, c.db, cFuncPointer(rollbackHookTrampoline), c.db)
 }
 
+// UpdateHookFn is called after each row change with the operation
+// (SQLITE_INSERT, SQLITE_UPDATE or SQLITE_DELETE), the database and table
+// names and the rowid of the affected row. Unlike the pre-update hook it
+// carries no old/new column values, which makes it considerably cheaper.
+type UpdateHookFn func(op int32, dbName, tableName string, rowid int64)
+
+// RegisterUpdateHook wires callback into sqlite3_update_hook. Passing nil
+// removes the hook.
+func (c *conn) RegisterUpdateHook(callback UpdateHookFn) {
+	if callback == nil {
+		xUpdateHandlers.mu.Lock()
+		delete(xUpdateHandlers.m, c.db)
+		xUpdateHandlers.mu.Unlock()
+		sqlite3.Xsqlite3_update_hook(c.tls, c.db, uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)))
+		return
+	}
+	xUpdateHandlers.mu.Lock()
+	xUpdateHandlers.m[c.db] = callback
+	xUpdateHandlers.mu.Unlock()
+	sqlite3.Xsqlite3_update_hook(c.tls, c.db, cFuncPointer(updateHookTrampoline), c.db)
+}
+
 type SQLitePreUpdateData struct {
 	tls          *libc.TLS
 	pCsr         uintptr
@@ -202,6 +230,18 @@ func preUpdateHookTrampoline(tls *libc.TLS, handle uintptr, pCsr uintptr, op int
 	xPreUpdateHandler(data)
 }
 
+func updateHookTrampoline(tls *libc.TLS, handle uintptr, op int32, zDb uintptr, zTab uintptr, rowid int64) {
+	xUpdateHandlers.mu.RLock()
+	xUpdateHandler := xUpdateHandlers.m[handle]
+	xUpdateHandlers.mu.RUnlock()
+
+	if xUpdateHandler == nil {
+		return
+	}
+
+	xUpdateHandler(op, libc.GoString(zDb), libc.GoString(zTab), rowid)
+}
+
 func commitHookTrampoline(tls *libc.TLS, handle uintptr, pCsr uintptr) int32 {
 	xCommitHandlers.mu.RLock()
 	xCommitHandler := xCommitHandlers.m[handle]
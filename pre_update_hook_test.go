@@ -9,6 +9,13 @@ import (
 	sqlite3 "github.com/syralon/sqlite/lib"
 )
 
+type updateHookCall struct {
+	op        int32
+	dbName    string
+	tableName string
+	rowid     int64
+}
+
 func TestPreUpdateHook(t *testing.T) {
 	connStr := ":memory:"
 	driverName := "sqlite_pre_update_hook_test"
@@ -23,6 +30,7 @@ func TestPreUpdateHook(t *testing.T) {
 		deleteOldValues []any
 		commitCount     int
 		rollbackCount   int
+		updateHookCalls []updateHookCall
 	)
 	var testDriver sqlite.Driver
 	testDriver.RegisterConnectionHook(func(conn sqlite.ExecQuerierContext, dsn string) error {
@@ -64,6 +72,9 @@ func TestPreUpdateHook(t *testing.T) {
 			hooker.RegisterRollbackHook(func() {
 				rollbackCount++
 			})
+			hooker.RegisterUpdateHook(func(op int32, dbName, tableName string, rowid int64) {
+				updateHookCalls = append(updateHookCalls, updateHookCall{op, dbName, tableName, rowid})
+			})
 		}
 		return nil
 	})
@@ -114,5 +125,13 @@ func TestPreUpdateHook(t *testing.T) {
 	if rollbackCount != 0 {
 		t.Errorf("rollback hook: expect %d, got %d", 0, rollbackCount)
 	}
+	expectUpdateHookCalls := []updateHookCall{
+		{sqlite3.SQLITE_INSERT, "main", "in_memory_test", 42},
+		{sqlite3.SQLITE_UPDATE, "main", "in_memory_test", 43},
+		{sqlite3.SQLITE_DELETE, "main", "in_memory_test", 43},
+	}
+	if !slices.Equal(updateHookCalls, expectUpdateHookCalls) {
+		t.Errorf("update hook: expect %v, got %v", expectUpdateHookCalls, updateHookCalls)
+	}
 
 }
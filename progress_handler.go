@@ -0,0 +1,58 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+var xProgressHandlers = struct {
+	mu sync.RWMutex
+	m  map[uintptr]ProgressHandlerFn
+}{
+	m: make(map[uintptr]ProgressHandlerFn),
+}
+
+// ProgressHandlerFn is invoked periodically while statements run. Returning a
+// non-zero value interrupts the current operation, which then fails with
+// SQLITE_INTERRUPT.
+type ProgressHandlerFn func() int32
+
+// RegisterProgressHandler wires callback into sqlite3_progress_handler so it
+// is invoked approximately every nOps virtual machine opcodes, enabling
+// progress reporting and cooperative cancellation of long-running queries
+// without relying solely on context interrupt. Passing a nil callback or a
+// non-positive nOps removes the handler.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterProgressHandler(nOps int32, callback ProgressHandlerFn) {
+	if callback == nil || nOps <= 0 {
+		xProgressHandlers.mu.Lock()
+		delete(xProgressHandlers.m, c.db)
+		xProgressHandlers.mu.Unlock()
+		sqlite3.Xsqlite3_progress_handler(c.tls, c.db, 0, uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)))
+		return
+	}
+	xProgressHandlers.mu.Lock()
+	xProgressHandlers.m[c.db] = callback
+	xProgressHandlers.mu.Unlock()
+	sqlite3.Xsqlite3_progress_handler(c.tls, c.db, nOps, cFuncPointer(progressHandlerTrampoline), c.db)
+}
+
+func progressHandlerTrampoline(tls *libc.TLS, handle uintptr) int32 {
+	xProgressHandlers.mu.RLock()
+	xProgressHandler := xProgressHandlers.m[handle]
+	xProgressHandlers.mu.RUnlock()
+
+	if xProgressHandler == nil {
+		return 0
+	}
+
+	return xProgressHandler()
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestProgressHandler(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type progressRegistrar interface {
+		RegisterProgressHandler(int32, ProgressHandlerFn)
+	}
+	register := func(nOps int32, fn ProgressHandlerFn) {
+		t.Helper()
+		if err := cn.Raw(func(driverConn any) error {
+			driverConn.(progressRegistrar).RegisterProgressHandler(nOps, fn)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const longQuery = `
+		with recursive c(n) as (select 1 union all select n+1 from c where n < 100000)
+		select count(*) from c
+	`
+
+	var calls int
+	register(10, func() int32 {
+		calls++
+		return 0
+	})
+
+	var n int
+	if err := cn.QueryRowContext(context.Background(), longQuery).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 100000 {
+		t.Fatalf("got %d, want 100000", n)
+	}
+
+	if calls == 0 {
+		t.Fatal("progress handler was not invoked")
+	}
+
+	// A non-zero return interrupts the statement.
+	register(10, func() int32 { return 1 })
+
+	err = cn.QueryRowContext(context.Background(), longQuery).Scan(&n)
+	if err == nil || !strings.Contains(err.Error(), "interrupt") {
+		t.Fatalf("expected interrupt error, got %v", err)
+	}
+
+	// Removing the handler lets queries run to completion again.
+	register(0, nil)
+
+	if err := cn.QueryRowContext(context.Background(), longQuery).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+}
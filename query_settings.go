@@ -0,0 +1,148 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// querySettingsKey is the context key carrying per-query pragma settings.
+type querySettingsKey struct{}
+
+// queryPragma is one pragma assignment carried in a context.
+type queryPragma struct {
+	name  string
+	value string
+}
+
+// WithPragma returns a context that causes statements executed with it to run
+// with the given pragma set, restoring the previous value once the statement
+// (or, for queries, the returned rows) is done with. This allows per-request
+// tuning, e.g. a larger cache_size for one expensive query, without
+// dedicating connections.
+//
+// The pragma must be readable (PRAGMA name must return its current value) so
+// the driver can restore it. Multiple WithPragma calls accumulate and are
+// applied in order; they are undone in reverse order.
+//
+// The settings apply to the connection executing the statement, so concurrent
+// statements on the same connection observe them while they are in effect.
+func WithPragma(ctx context.Context, name, value string) context.Context {
+	ps, _ := ctx.Value(querySettingsKey{}).([]queryPragma)
+	ps = append(ps[:len(ps):len(ps)], queryPragma{name: name, value: value})
+	return context.WithValue(ctx, querySettingsKey{}, ps)
+}
+
+// WithQueryOnly returns a context that causes statements executed with it to
+// run with PRAGMA query_only enabled, so any attempt to modify the database
+// fails with SQLITE_READONLY. The previous query_only setting is restored
+// afterwards.
+func WithQueryOnly(ctx context.Context) context.Context {
+	return WithPragma(ctx, "query_only", "on")
+}
+
+// validPragmaName reports whether name is a plausible pragma identifier. It
+// keeps WithPragma arguments from smuggling arbitrary SQL into the PRAGMA
+// statements the driver builds.
+func validPragmaName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// quotePragmaValue renders v as a single quoted SQL string literal.
+func quotePragmaValue(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+// pragmaValue returns the current value of the named pragma as a string.
+func (c *conn) pragmaValue(name string) (string, error) {
+	r, err := c.query(context.Background(), "pragma "+name, nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer r.Close()
+
+	rr := r.(*rows)
+	if len(rr.columns) == 0 {
+		return "", fmt.Errorf("pragma %q does not return a value", name)
+	}
+
+	dest := make([]driver.Value, len(rr.columns))
+	if err := rr.Next(dest); err != nil {
+		if err == io.EOF {
+			return "", fmt.Errorf("pragma %q does not return a value", name)
+		}
+		return "", err
+	}
+
+	switch v := dest[0].(type) {
+	case []byte:
+		return string(v), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// applyQuerySettings applies any pragmas carried by ctx to the connection and
+// returns a function restoring their previous values in reverse order. It
+// returns a nil restore function when ctx carries no settings.
+func (c *conn) applyQuerySettings(ctx context.Context) (restore func() error, err error) {
+	if ctx == nil {
+		return nil, nil
+	}
+
+	ps, _ := ctx.Value(querySettingsKey{}).([]queryPragma)
+	if len(ps) == 0 {
+		return nil, nil
+	}
+
+	saved := make([]queryPragma, 0, len(ps))
+	restore = func() error {
+		var err error
+		for i := len(saved) - 1; i >= 0; i-- {
+			cmd := "pragma " + saved[i].name + " = " + quotePragmaValue(saved[i].value)
+			if _, e := c.exec(context.Background(), cmd, nil); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+
+	for _, p := range ps {
+		if !validPragmaName(p.name) {
+			restore()
+			return nil, fmt.Errorf("invalid pragma name %q", p.name)
+		}
+
+		old, err := c.pragmaValue(p.name)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+
+		cmd := "pragma " + p.name + " = " + quotePragmaValue(p.value)
+		if _, err := c.exec(context.Background(), cmd, nil); err != nil {
+			restore()
+			return nil, err
+		}
+
+		saved = append(saved, queryPragma{name: p.name, value: old})
+	}
+	return restore, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// ReadOnlyError is returned when a statement attempts to modify the database
+// or its schema on a connection opened with the _readonly DSN parameter. Use
+// errors.As to distinguish denied writes from other failures when serving
+// untrusted queries.
+type ReadOnlyError struct {
+	err error
+}
+
+// Error implements error.
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("sqlite: connection is read-only: %v", e.err)
+}
+
+// Unwrap returns the underlying driver error, an *Error carrying the sqlite
+// result code.
+func (e *ReadOnlyError) Unwrap() error { return e.err }
+
+// getReadOnly reports whether the DSN query parameters request enforced
+// read-only mode via _readonly=1 (also accepted: true, on).
+func getReadOnly(query string) (bool, error) {
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := q.Get("_readonly"); v {
+	case "":
+		return false, nil
+	case "1", "true", "on":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown _readonly value %q", v)
+	}
+}
+
+// enforceReadOnly puts the connection into enforced read-only mode: the
+// database is already opened with SQLITE_OPEN_READONLY by newConn, and this
+// additionally sets PRAGMA query_only and installs an authorizer denying
+// every write and DDL action, so the restriction holds even against
+// statements that try to lift it (e.g. PRAGMA query_only=off). Violations
+// surface as *ReadOnlyError.
+func (c *conn) enforceReadOnly() error {
+	if _, err := c.exec(context.Background(), "pragma query_only=on", nil); err != nil {
+		return err
+	}
+	if rc := sqlite3.Xsqlite3_set_authorizer(c.tls, c.db, cFuncPointer(readOnlyAuthTrampoline), c.db); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	c.readOnlyEnforced = true
+	return nil
+}
+
+// readOnlyAuthTrampoline is the sqlite3_set_authorizer callback for enforced
+// read-only connections. It allows only the actions needed to read data and
+// denies everything else, including PRAGMA.
+func readOnlyAuthTrampoline(tls *libc.TLS, pArg uintptr, action int32, zArg1, zArg2, zArg3, zArg4 uintptr) int32 {
+	switch action {
+	case sqlite3.SQLITE_SELECT,
+		sqlite3.SQLITE_READ,
+		sqlite3.SQLITE_FUNCTION,
+		sqlite3.SQLITE_RECURSIVE,
+		sqlite3.SQLITE_TRANSACTION,
+		sqlite3.SQLITE_SAVEPOINT:
+		return sqlite3.SQLITE_OK
+	default:
+		return sqlite3.SQLITE_DENY
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// xRandomnessSource holds the entropy source backing sqlite3_randomness once
+// RegisterRandomnessSource has been called. The reader defaults to
+// crypto/rand.Reader.
+var xRandomnessSource = struct {
+	mu        sync.RWMutex
+	r         io.Reader
+	installed bool
+}{
+	r: rand.Reader,
+}
+
+// RegisterRandomnessSource replaces the entropy source that seeds SQLite's
+// PRNG, which backs sqlite3_randomness(), randomblob() and temporary file
+// names. The first call patches the default VFS's xRandomness method and
+// resets the PRNG so it reseeds from the new source on next use.
+//
+// Passing nil selects the default source, crypto/rand.Reader — deployments
+// with FIPS-style entropy requirements can simply call
+// RegisterRandomnessSource(nil) to stop using the platform VFS entropy.
+// Sources must be safe for concurrent use if the database is used from
+// multiple goroutines.
+func RegisterRandomnessSource(r io.Reader) error {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	xRandomnessSource.mu.Lock()
+	defer xRandomnessSource.mu.Unlock()
+
+	xRandomnessSource.r = r
+	if !xRandomnessSource.installed {
+		tls := libc.NewTLS()
+		defer tls.Close()
+
+		pVfs := sqlite3.Xsqlite3_vfs_find(tls, 0)
+		if pVfs == 0 {
+			return fmt.Errorf("sqlite: no default VFS registered")
+		}
+		(*sqlite3.Tsqlite3_vfs)(unsafe.Pointer(pVfs)).FxRandomness = cFuncPointer(randomnessTrampoline)
+		xRandomnessSource.installed = true
+
+		// sqlite3_randomness(0, NULL) resets the PRNG; the next call reseeds
+		// it through the VFS, i.e. from the new source.
+		sqlite3.Xsqlite3_randomness(tls, 0, 0)
+	}
+	return nil
+}
+
+// RandomBytes returns n bytes from the currently registered randomness
+// source (crypto/rand by default). It is intended for extensions that need
+// entropy from the same source as the database core.
+func RandomBytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("sqlite: RandomBytes: negative length %d", n)
+	}
+
+	xRandomnessSource.mu.RLock()
+	r := xRandomnessSource.r
+	xRandomnessSource.mu.RUnlock()
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// randomnessTrampoline implements sqlite3_vfs.xRandomness on top of the
+// registered source. On read failure it reports zero bytes of entropy,
+// matching how VFS implementations signal the absence of randomness.
+func randomnessTrampoline(tls *libc.TLS, pVfs uintptr, nByte int32, zOut uintptr) int32 {
+	if nByte <= 0 || zOut == 0 {
+		return 0
+	}
+
+	xRandomnessSource.mu.RLock()
+	r := xRandomnessSource.r
+	xRandomnessSource.mu.RUnlock()
+
+	b := (*libc.RawMem)(unsafe.Pointer(zOut))[:nByte:nByte]
+	n, _ := io.ReadFull(r, b)
+	return int32(n)
+}
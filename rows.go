@@ -10,8 +10,10 @@ import (
 	"io"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
+	"unsafe"
 
 	sqlite3 "github.com/syralon/sqlite/lib"
 )
@@ -25,6 +27,10 @@ type rows struct {
 	doStep    bool
 	empty     bool
 	reuseStmt bool // If true, Close() resets instead of finalizing
+
+	// restore undoes per-query settings applied from the statement's context
+	// (see WithPragma). It runs once, after the statement is released.
+	restore func() error
 }
 
 func newRows(c *conn, pstmt uintptr, allocs []uintptr, empty bool) (r *rows, err error) {
@@ -59,6 +65,16 @@ func (r *rows) Close() (err error) {
 	}
 	r.allocs = nil
 
+	if r.restore != nil {
+		f := r.restore
+		r.restore = nil
+		defer func() {
+			if e := f(); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+
 	if r.reuseStmt {
 		// Reset the statement for reuse instead of finalizing it
 		if e := r.c.reset(r.pstmt); e != nil {
@@ -158,20 +174,58 @@ func (r *rows) Next(dest []driver.Value) (err error) {
 					return err
 				}
 
-				dest[i] = v
-			case sqlite3.SQLITE_TEXT:
-				v, err := r.c.columnText(r.pstmt, i)
-				if err != nil {
-					return err
+				// A REAL in a time column is SQLite's julian day
+				// representation; decode it when numeric time decoding was
+				// opted into.
+				if r.c.intToTime || r.c.integerTimeFormat == "julian" {
+					switch r.ColumnTypeDatabaseTypeName(i) {
+					case "DATE", "DATETIME", "TIMESTAMP":
+						dest[i] = julianDayToTime(v)
+						continue
+					}
 				}
 
+				dest[i] = v
+			case sqlite3.SQLITE_TEXT:
 				switch r.ColumnTypeDatabaseTypeName(i) {
 				case "DATE", "DATETIME", "TIMESTAMP":
+					v, err := r.c.columnText(r.pstmt, i)
+					if err != nil {
+						return err
+					}
+
 					dest[i], _ = r.c.parseTime(v)
 				default:
+					// In _rawbytes mode the scanned slice borrows SQLite's
+					// buffer and is only valid until the next call to Next.
+					if r.c.rawBytes {
+						v, err := r.c.columnRawBytes(r.pstmt, i)
+						if err != nil {
+							return err
+						}
+
+						dest[i] = v
+						continue
+					}
+
+					v, err := r.c.columnText(r.pstmt, i)
+					if err != nil {
+						return err
+					}
+
 					dest[i] = v
 				}
 			case sqlite3.SQLITE_BLOB:
+				if r.c.rawBytes {
+					v, err := r.c.columnRawBytes(r.pstmt, i)
+					if err != nil {
+						return err
+					}
+
+					dest[i] = v
+					continue
+				}
+
 				v, err := r.c.columnBlob(r.pstmt, i)
 				if err != nil {
 					return err
@@ -239,8 +293,12 @@ func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
 // be true if it is known the column may be null, or false if the column is
 // known to be not nullable. If the column nullability is unknown, ok should be
 // false.
+//
+// For columns that originate directly from a table the answer comes from
+// sqlite3_table_column_metadata; expressions and computed columns report
+// unknown.
 func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
-	return true, true
+	return r.c.columnNullable(r.pstmt, index)
 }
 
 // RowsColumnTypePrecisionScale may be implemented by Rows. It should return
@@ -251,7 +309,38 @@ func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
 //	int               (0, 0, false)
 //	decimal           (math.MaxInt64, math.MaxInt64, true)
 func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
-	return 0, 0, false
+	decl := strings.ToLower(r.c.columnDeclType(r.pstmt, index))
+	base, args, parens := strings.Cut(decl, "(")
+	switch strings.TrimSpace(base) {
+	case "decimal", "numeric":
+	default:
+		return 0, 0, false
+	}
+
+	if !parens {
+		return math.MaxInt64, math.MaxInt64, true
+	}
+
+	p, s, hasScale := strings.Cut(strings.TrimSuffix(strings.TrimSpace(args), ")"), ",")
+	if precision, ok = parseDeclNumber(p); !ok {
+		return 0, 0, false
+	}
+
+	if !hasScale {
+		return precision, 0, true
+	}
+
+	if scale, ok = parseDeclNumber(s); !ok {
+		return 0, 0, false
+	}
+	return precision, scale, true
+}
+
+// parseDeclNumber parses a precision or scale figure from a declared column
+// type like "decimal(38, 4)".
+func parseDeclNumber(s string) (int64, bool) {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return v, err == nil
 }
 
 // RowsColumnTypeScanType may be implemented by Rows. It should return the
@@ -286,6 +375,27 @@ func (r *rows) ColumnTypeScanType(index int) reflect.Type {
 	}
 }
 
+// columnNullable reports whether the column may hold NULL, looking up the
+// declared NOT NULL constraint of the originating table column with
+// sqlite3_table_column_metadata. Columns that are not a direct reference to a
+// table column have unknown nullability.
+func (c *conn) columnNullable(pstmt uintptr, iCol int) (nullable, ok bool) {
+	zDb := sqlite3.Xsqlite3_column_database_name(c.tls, pstmt, int32(iCol))
+	zTable := sqlite3.Xsqlite3_column_table_name(c.tls, pstmt, int32(iCol))
+	zCol := sqlite3.Xsqlite3_column_origin_name(c.tls, pstmt, int32(iCol))
+	if zDb == 0 || zTable == 0 || zCol == 0 {
+		return true, false
+	}
+
+	pNotNull := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	if rc := sqlite3.Xsqlite3_table_column_metadata(c.tls, c.db, zDb, zTable, zCol, 0, 0, pNotNull, 0, 0); rc != sqlite3.SQLITE_OK {
+		return true, false
+	}
+	return *(*int32)(unsafe.Pointer(pNotNull)) == 0, true
+}
+
 // C documentation
 //
 //	int sqlite3_reset(sqlite3_stmt *pStmt);
@@ -0,0 +1,214 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"sync"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// RTreeGeometryFunction implements a custom region test for the R*Tree MATCH
+// operator. params holds the arguments given to the SQL function in the
+// query, e.g. center and radius of a circle. coords holds the bounding box of
+// the node or entry under consideration as min/max pairs per dimension:
+// min1, max1, min2, max2, ... The function reports whether the box intersects
+// the region; returning false prunes the subtree.
+type RTreeGeometryFunction func(params, coords []float64) (bool, error)
+
+// xRTreeGeometries maps the context handle passed to
+// sqlite3_rtree_geometry_callback back to the registered Go implementation.
+var xRTreeGeometries = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]RTreeGeometryFunction
+	ids idGen
+}{
+	m: make(map[uintptr]RTreeGeometryFunction),
+}
+
+// RegisterRTreeGeometryFunction registers f as the region test zGeom on this
+// connection, wrapping sqlite3_rtree_geometry_callback. The function is then
+// usable in MATCH constraints of rtree queries:
+//
+//	select id from idx where id match zGeom(...)
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterRTreeGeometryFunction(zGeom string, f RTreeGeometryFunction) error {
+	zName, err := libc.CString(zGeom)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(zName)
+
+	xRTreeGeometries.mu.Lock()
+	id := xRTreeGeometries.ids.next()
+	xRTreeGeometries.m[id] = f
+	xRTreeGeometries.mu.Unlock()
+
+	if rc := sqlite3.Xsqlite3_rtree_geometry_callback(c.tls, c.db, zName, cFuncPointer(rtreeGeometryTrampoline), id); rc != sqlite3.SQLITE_OK {
+		xRTreeGeometries.mu.Lock()
+		delete(xRTreeGeometries.m, id)
+		xRTreeGeometries.ids.reclaim(id)
+		xRTreeGeometries.mu.Unlock()
+		return c.errstr(rc)
+	}
+
+	return nil
+}
+
+// rtreeGeometryTrampoline is the xGeom callback shared by all Go geometry
+// functions.
+func rtreeGeometryTrampoline(tls *libc.TLS, pGeom uintptr, nCoord int32, aCoord uintptr, pRes uintptr) int32 {
+	geom := (*sqlite3.Tsqlite3_rtree_geometry)(unsafe.Pointer(pGeom))
+	xRTreeGeometries.mu.RLock()
+	f := xRTreeGeometries.m[geom.FpContext]
+	xRTreeGeometries.mu.RUnlock()
+	if f == nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	within, err := f(rtreeDoubles(geom.FaParam, geom.FnParam), rtreeDoubles(aCoord, nCoord))
+	if err != nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	var res int32
+	if within {
+		res = 1
+	}
+	*(*int32)(unsafe.Pointer(pRes)) = res
+	return sqlite3.SQLITE_OK
+}
+
+// rtreeDoubles copies an array of n C doubles into a Go slice.
+func rtreeDoubles(p uintptr, n int32) []float64 {
+	if p == 0 || n <= 0 {
+		return nil
+	}
+
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = *(*float64)(unsafe.Pointer(p + uintptr(i)*8))
+	}
+	return s
+}
+
+// Allowed values for RTreeQueryInfo.Within and ParentWithin, mirroring the
+// NOT_WITHIN, PARTLY_WITHIN and FULLY_WITHIN constants from sqlite3rtree.h.
+const (
+	RTreeNotWithin    = sqlite3.NOT_WITHIN
+	RTreePartlyWithin = sqlite3.PARTLY_WITHIN
+	RTreeFullyWithin  = sqlite3.FULLY_WITHIN
+)
+
+// RTreeQueryInfo describes the node or entry an RTreeQueryFunction is asked
+// to score. Params and Coords have the same meaning as the arguments of an
+// RTreeGeometryFunction. Entries at the leaf level have Level 0, in which
+// case Rowid identifies the row; inner nodes have higher levels.
+//
+// The function reports its verdict through Within and Score: subtrees with
+// Within == RTreeNotWithin are pruned, everything else is visited - and rows
+// returned - in order of ascending Score.
+type RTreeQueryInfo struct {
+	Params       []float64
+	Coords       []float64
+	Level        int
+	MaxLevel     int
+	Rowid        int64
+	ParentScore  float64
+	ParentWithin int
+
+	// Outputs.
+	Within int
+	Score  float64
+}
+
+// RTreeQueryFunction implements a scored region test for the R*Tree MATCH
+// operator, enabling nearest-neighbor style priority queries.
+type RTreeQueryFunction func(info *RTreeQueryInfo) error
+
+// xRTreeQueries maps the context handle passed to
+// sqlite3_rtree_query_callback back to the registered Go implementation.
+var xRTreeQueries = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]RTreeQueryFunction
+	ids idGen
+}{
+	m: make(map[uintptr]RTreeQueryFunction),
+}
+
+// RegisterRTreeQueryFunction registers f as the scored region test zQueryFunc
+// on this connection, wrapping sqlite3_rtree_query_callback. Like a geometry
+// function it is used in MATCH constraints of rtree queries, but rows come
+// back ordered by the score the function assigns.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterRTreeQueryFunction(zQueryFunc string, f RTreeQueryFunction) error {
+	zName, err := libc.CString(zQueryFunc)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(zName)
+
+	xRTreeQueries.mu.Lock()
+	id := xRTreeQueries.ids.next()
+	xRTreeQueries.m[id] = f
+	xRTreeQueries.mu.Unlock()
+
+	if rc := sqlite3.Xsqlite3_rtree_query_callback(c.tls, c.db, zName, cFuncPointer(rtreeQueryTrampoline), id, cFuncPointer(rtreeQueryDestroyTrampoline)); rc != sqlite3.SQLITE_OK {
+		xRTreeQueries.mu.Lock()
+		delete(xRTreeQueries.m, id)
+		xRTreeQueries.ids.reclaim(id)
+		xRTreeQueries.mu.Unlock()
+		return c.errstr(rc)
+	}
+
+	return nil
+}
+
+// rtreeQueryTrampoline is the xQueryFunc callback shared by all Go query
+// functions.
+func rtreeQueryTrampoline(tls *libc.TLS, pInfo uintptr) int32 {
+	qi := (*sqlite3.Tsqlite3_rtree_query_info)(unsafe.Pointer(pInfo))
+	xRTreeQueries.mu.RLock()
+	f := xRTreeQueries.m[qi.FpContext]
+	xRTreeQueries.mu.RUnlock()
+	if f == nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	info := RTreeQueryInfo{
+		Params:       rtreeDoubles(qi.FaParam, qi.FnParam),
+		Coords:       rtreeDoubles(qi.FaCoord, qi.FnCoord),
+		Level:        int(qi.FiLevel),
+		MaxLevel:     int(qi.FmxLevel),
+		Rowid:        int64(qi.FiRowid),
+		ParentScore:  float64(qi.FrParentScore),
+		ParentWithin: int(qi.FeParentWithin),
+		Within:       RTreeNotWithin,
+	}
+	if err := f(&info); err != nil {
+		return sqlite3.SQLITE_ERROR
+	}
+
+	qi.FeWithin = int32(info.Within)
+	qi.FrScore = sqlite3.Tsqlite3_rtree_dbl(info.Score)
+	return sqlite3.SQLITE_OK
+}
+
+// rtreeQueryDestroyTrampoline is the xDestructor callback, invoked when the
+// registration is replaced or the connection closes.
+func rtreeQueryDestroyTrampoline(tls *libc.TLS, pContext uintptr) {
+	xRTreeQueries.mu.Lock()
+	defer xRTreeQueries.mu.Unlock()
+	if _, ok := xRTreeQueries.m[pContext]; ok {
+		delete(xRTreeQueries.m, pContext)
+		xRTreeQueries.ids.reclaim(pContext)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+)
+
+// circleIntersects reports whether the circle given by params (cx, cy, r)
+// intersects the box in coords (xmin, xmax, ymin, ymax).
+func circleIntersects(params, coords []float64) (bool, error) {
+	cx, cy, r := params[0], params[1], params[2]
+	x := math.Min(math.Max(cx, coords[0]), coords[1])
+	y := math.Min(math.Max(cy, coords[2]), coords[3])
+	return math.Hypot(cx-x, cy-y) <= r, nil
+}
+
+func TestRTreeGeometryCallback(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/rtree.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+	cn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type geomRegisterer interface {
+		RegisterRTreeGeometryFunction(zGeom string, f RTreeGeometryFunction) error
+	}
+	if err := cn.Raw(func(driverConn interface{}) error {
+		return driverConn.(geomRegisterer).RegisterRTreeGeometryFunction("circle_test", circleIntersects)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(ctx, "create virtual table demo using rtree(id, minx, maxx, miny, maxy)"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, box := range [][5]float64{
+		{1, 44, 46, 21, 23},    // around the query circle's center
+		{2, 50, 52, 21, 23},    // inside the radius
+		{3, 100, 101, 100, 101}, // far away
+	} {
+		if _, err := cn.ExecContext(ctx, "insert into demo values (?, ?, ?, ?, ?)",
+			box[0], box[1], box[2], box[3], box[4]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := cn.QueryContext(ctx, "select id from demo where id match circle_test(45.0, 22.0, 10.0) order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("got ids %v, want [1 2]", ids)
+	}
+}
+
+func TestRTreeQueryCallback(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/rtreeq.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	ctx := context.Background()
+	cn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	// nearest_test scores everything by the distance between the query point
+	// in params and the closest point of the box, so rows come back nearest
+	// first.
+	type queryRegisterer interface {
+		RegisterRTreeQueryFunction(zQueryFunc string, f RTreeQueryFunction) error
+	}
+	if err := cn.Raw(func(driverConn interface{}) error {
+		return driverConn.(queryRegisterer).RegisterRTreeQueryFunction("nearest_test", func(info *RTreeQueryInfo) error {
+			px, py := info.Params[0], info.Params[1]
+			x := math.Min(math.Max(px, info.Coords[0]), info.Coords[1])
+			y := math.Min(math.Max(py, info.Coords[2]), info.Coords[3])
+			info.Within = RTreePartlyWithin
+			if info.Level == 0 {
+				info.Within = RTreeFullyWithin
+			}
+			info.Score = math.Hypot(px-x, py-y)
+			return nil
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(ctx, "create virtual table demo using rtree(id, minx, maxx, miny, maxy)"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, box := range [][5]float64{
+		{1, 100, 101, 100, 101},
+		{2, 10, 11, 10, 11},
+		{3, 50, 51, 50, 51},
+	} {
+		if _, err := cn.ExecContext(ctx, "insert into demo values (?, ?, ?, ?, ?)",
+			box[0], box[1], box[2], box[3], box[4]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := cn.QueryContext(ctx, "select id from demo where id match nearest_test(0.0, 0.0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 3 || ids[2] != 1 {
+		t.Fatalf("got ids %v, want nearest-first order [2 3 1]", ids)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// Snapshot identifies a historical state of a database in WAL mode, wrapping
+// sqlite3_snapshot. Snapshots are obtained with Conn.GetSnapshot and pin a
+// state only as long as it remains in the WAL: a checkpoint that overwrites
+// it makes the snapshot unopenable.
+type Snapshot struct {
+	tls *libc.TLS
+	p   uintptr
+}
+
+// GetSnapshot records the state of the named schema as seen by the
+// connection's current read transaction, wrapping sqlite3_snapshot_get. The
+// database must be in WAL mode and a read transaction must be open, e.g. by
+// executing BEGIN followed by a SELECT. Free the snapshot when done.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) GetSnapshot(schema string) (*Snapshot, error) {
+	zDb, err := libc.CString(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.free(zDb)
+
+	bp := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	if rc := sqlite3.Xsqlite3_snapshot_get(c.tls, c.db, zDb, bp); rc != sqlite3.SQLITE_OK {
+		return nil, c.errstr(rc)
+	}
+	return &Snapshot{tls: c.tls, p: *(*uintptr)(unsafe.Pointer(bp))}, nil
+}
+
+// OpenSnapshot pins the connection's new read transaction to the given
+// snapshot, wrapping sqlite3_snapshot_open. It must be called after BEGIN
+// but before any statement has read from the schema; subsequent reads then
+// see the database exactly as it was when the snapshot was taken, regardless
+// of commits by other connections.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) OpenSnapshot(schema string, s *Snapshot) error {
+	if s == nil || s.p == 0 {
+		return fmt.Errorf("sqlite: cannot open a nil or freed snapshot")
+	}
+
+	zDb, err := libc.CString(schema)
+	if err != nil {
+		return err
+	}
+
+	defer c.free(zDb)
+
+	if rc := sqlite3.Xsqlite3_snapshot_open(c.tls, c.db, zDb, s.p); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// CompareSnapshots orders two snapshots of the same database: the result is
+// negative, zero or positive as a is older than, equal to or newer than b.
+// It wraps sqlite3_snapshot_cmp and is only meaningful while both snapshots
+// remain openable.
+func CompareSnapshots(a, b *Snapshot) int {
+	return int(sqlite3.Xsqlite3_snapshot_cmp(a.tls, a.p, b.p))
+}
+
+// Free releases the snapshot, wrapping sqlite3_snapshot_free. The snapshot
+// must not be used afterwards.
+func (s *Snapshot) Free() {
+	if s.p != 0 {
+		sqlite3.Xsqlite3_snapshot_free(s.tls, s.p)
+		s.p = 0
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+)
+
+func TestWALSnapshot(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "snapshot.db") + "?_pragma=journal_mode(wal)"
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(v);
+		insert into t values (1);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	type snapshotter interface {
+		GetSnapshot(schema string) (*Snapshot, error)
+		OpenSnapshot(schema string, s *Snapshot) error
+	}
+	exec := func(dc any, query string) {
+		t.Helper()
+		if _, err := dc.(driver.ExecerContext).ExecContext(context.Background(), query, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	countRows := func(dc any) int {
+		t.Helper()
+		rows, err := dc.(driver.QueryerContext).QueryContext(context.Background(), "select count(*) from t", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer rows.Close()
+
+		dest := make([]driver.Value, 1)
+		if err := rows.Next(dest); err != nil {
+			t.Fatal(err)
+		}
+		return int(dest[0].(int64))
+	}
+
+	// Pin the current state from an open read transaction.
+	var snap *Snapshot
+	if err := cn.Raw(func(driverConn any) error {
+		exec(driverConn, "begin")
+		defer exec(driverConn, "commit")
+
+		if n := countRows(driverConn); n != 1 {
+			t.Fatalf("got %d rows, want 1", n)
+		}
+
+		var err error
+		snap, err = driverConn.(snapshotter).GetSnapshot("main")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer snap.Free()
+
+	// Another connection commits a new row.
+	cn2, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn2.Close()
+
+	if _, err := cn2.ExecContext(context.Background(), "insert into t values (2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A transaction pinned to the snapshot still sees the old state; a
+	// plain transaction sees the new row.
+	if err := cn.Raw(func(driverConn any) error {
+		exec(driverConn, "begin")
+		defer exec(driverConn, "commit")
+
+		if err := driverConn.(snapshotter).OpenSnapshot("main", snap); err != nil {
+			return err
+		}
+
+		if n := countRows(driverConn); n != 1 {
+			t.Fatalf("got %d rows in snapshot, want 1", n)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cn.Raw(func(driverConn any) error {
+		exec(driverConn, "begin")
+		defer exec(driverConn, "commit")
+
+		if n := countRows(driverConn); n != 2 {
+			t.Fatalf("got %d rows, want 2", n)
+		}
+
+		// The newer state orders after the pinned snapshot.
+		snap2, err := driverConn.(snapshotter).GetSnapshot("main")
+		if err != nil {
+			return err
+		}
+
+		defer snap2.Free()
+
+		if cmp := CompareSnapshots(snap, snap2); cmp >= 0 {
+			t.Fatalf("CompareSnapshots = %d, want < 0", cmp)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
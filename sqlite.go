@@ -74,7 +74,10 @@ var parseTimeFormats = []string{
 
 // interruptOnDone sets up a goroutine to interrupt the provided db when the
 // context is canceled, and returns a function the caller must defer so it
-// doesn't interrupt after the caller finishes.
+// doesn't interrupt after the caller finishes. The deferred function also
+// clears any interrupt still pending on the connection, so a cancellation
+// only aborts the statement it raced with and the connection stays usable
+// for the next query.
 func interruptOnDone(
 	ctx context.Context,
 	c *conn,
@@ -88,6 +91,8 @@ func interruptOnDone(
 	// calling interrupt in the select statement below.
 	var donemu sync.Mutex
 
+	var interrupted bool
+
 	donech := make(chan struct{})
 
 	go func() {
@@ -99,6 +104,7 @@ func interruptOnDone(
 			donemu.Lock()
 			if atomic.CompareAndSwapInt32(done, 0, 1) {
 				c.interrupt(c.db)
+				interrupted = true
 			}
 			donemu.Unlock()
 		case <-donech:
@@ -111,6 +117,12 @@ func interruptOnDone(
 		// returns doesn't trigger a call to interrupt for some other statement.
 		donemu.Lock()
 		atomic.StoreInt32(done, 1)
+		if interrupted {
+			// The caller has finalized or reset the interrupted statement by now,
+			// but the interrupt flag outlives the statement it aborted and would
+			// make the connection look unusable to the pool.
+			c.clearInterrupt()
+		}
 		donemu.Unlock()
 		close(donech)
 	}
@@ -133,6 +145,28 @@ func getVFSName(query string) (r string, err error) {
 	return r, nil
 }
 
+// getSharedCache translates the _sharedcache DSN query parameter into the
+// sqlite3_open_v2 cache flag: SQLITE_OPEN_SHAREDCACHE when enabled,
+// SQLITE_OPEN_PRIVATECACHE when explicitly disabled, or 0 when absent (the
+// global default set by EnableSharedCache then applies).
+func getSharedCache(query string) (int32, error) {
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := q.Get("_sharedcache"); v {
+	case "":
+		return 0, nil
+	case "1", "true", "on":
+		return sqlite3.SQLITE_OPEN_SHAREDCACHE, nil
+	case "0", "false", "off":
+		return sqlite3.SQLITE_OPEN_PRIVATECACHE, nil
+	default:
+		return 0, fmt.Errorf("unknown _sharedcache value %q", v)
+	}
+}
+
 func applyQueryParams(c *conn, query string) error {
 	q, err := url.ParseQuery(query)
 	if err != nil {
@@ -172,12 +206,29 @@ func applyQueryParams(c *conn, query string) error {
 		}
 		c.writeTimeFormat = f
 	}
+	if v := q.Get("_time_location"); v != "" {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return fmt.Errorf("invalid _time_location %q: %v", v, err)
+		}
+		c.timeLoc = loc
+	}
+	if v := q.Get("_time_utc"); v != "" {
+		switch v {
+		case "1", "true", "on":
+			c.timeUTC = true
+		case "0", "false", "off":
+		default:
+			return fmt.Errorf("unknown _time_utc %q", v)
+		}
+	}
 	if v := q.Get("_time_integer_format"); v != "" {
 		switch v {
 		case "unix":
 		case "unix_milli":
 		case "unix_micro":
 		case "unix_nano":
+		case "julian":
 		default:
 			return fmt.Errorf("unknown _time_integer_format %q", v)
 		}
@@ -201,6 +252,15 @@ func applyQueryParams(c *conn, query string) error {
 		c.intToTime = onoff
 	}
 
+	if v := q.Get("_rawbytes"); v != "" {
+		onoff, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("unknown _rawbytes %q, must be 1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False",
+				v)
+		}
+		c.rawBytes = onoff
+	}
+
 	return nil
 }
 
@@ -231,6 +291,23 @@ type FunctionImpl struct {
 	// for more details.
 	Deterministic bool
 
+	// UserData is an arbitrary value made available to every invocation
+	// through FunctionContext.UserData, e.g. configuration the function
+	// needs without resorting to global variables.
+	UserData any
+
+	// UsesSubtype registers the function with SQLITE_SUBTYPE, declaring that
+	// it inspects the subtype of its arguments via
+	// FunctionContext.ArgSubtype. Without it SQLite may not preserve the
+	// subtypes of argument values.
+	UsesSubtype bool
+
+	// ResultSubtype registers the function with SQLITE_RESULT_SUBTYPE,
+	// declaring that it may tag its result via
+	// FunctionContext.SetResultSubtype. SQLite discards subtypes set by
+	// functions registered without it.
+	ResultSubtype bool
+
 	// Scalar is called when a scalar function is invoked in SQL. The
 	// argument Values are not valid past the return of the function.
 	Scalar func(ctx *FunctionContext, args []driver.Value) (driver.Value, error)
@@ -319,10 +396,26 @@ func registerCollation(
 		return fmt.Errorf("a collation %q is already registered", zName)
 	}
 
+	coll, err := newCollation(zName, impl, enc)
+	if err != nil {
+		return err
+	}
+
+	d.collations[zName] = coll
+	return nil
+}
+
+// newCollation allocates the C-side state backing a Go collation: the name
+// string and a trampoline registry id for impl.
+func newCollation(
+	zName string,
+	impl func(left, right string) int,
+	enc int32,
+) (*collation, error) {
 	// dont free, collations registered on the driver live as long as the program
 	name, err := libc.CString(zName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	xCollations.mu.Lock()
@@ -330,13 +423,11 @@ func registerCollation(
 	xCollations.m[id] = impl
 	xCollations.mu.Unlock()
 
-	d.collations[zName] = &collation{
+	return &collation{
 		zName: name,
 		pApp:  id,
 		enc:   enc,
-	}
-
-	return nil
+	}, nil
 }
 
 type ExecQuerierContext interface {
@@ -348,8 +439,46 @@ type HookRegisterer interface {
 	RegisterPreUpdateHook(PreUpdateHookFn)
 	RegisterCommitHook(CommitHookFn)
 	RegisterRollbackHook(RollbackHookFn)
+	RegisterUpdateHook(UpdateHookFn)
+	RegisterAuthorizer(AuthorizerFn) error
+}
+
+// Conn is the stable low-level API of a driver connection. The connection
+// obtained inside sql.Conn.Raw implements it, so code that needs more than
+// database/sql offers can assert to this one type instead of declaring ad hoc
+// interfaces per method:
+//
+//	cn.Raw(func(driverConn any) error {
+//		c := driverConn.(sqlite.Conn)
+//		...
+//		return nil
+//	})
+//
+// Methods may be added to Conn in later releases; existing ones will not be
+// removed or changed incompatibly.
+type Conn interface {
+	ExecQuerierContext
+	driver.ConnPrepareContext
+	HookRegisterer
+
+	AutoCommit() bool
+	Changes() int64
+	Deserialize(buf []byte) error
+	Filename(schema string) (string, error)
+	Interrupt() error
+	IsReadOnly(schema string) (bool, error)
+	LastInsertRowID() int64
+	Limit(id, newVal int) int
+	NewBackup(dstUri string) (*Backup, error)
+	NewRestore(srcUri string) (*Backup, error)
+	OpenBlob(schema, table, column string, rowid int64, write bool) (*Blob, error)
+	Serialize() ([]byte, error)
+	TotalChanges() int64
+	TxnState(schema string) (TxnState, error)
 }
 
+var _ Conn = (*conn)(nil)
+
 // ConnectionHookFn function type for a connection hook on the Driver. Connection
 // hooks are called after the connection has been set up.
 type ConnectionHookFn func(
@@ -362,6 +491,149 @@ type ConnectionHookFn func(
 type FunctionContext struct {
 	tls *libc.TLS
 	ctx uintptr
+
+	// argc and argv are the sqlite3_value arguments of the current call,
+	// when the context belongs to one; they back ArgSubtype.
+	argc int32
+	argv uintptr
+
+	// subtype is the result subtype recorded by SetResultSubtype, applied by
+	// the trampoline after the result value has been set, since setting a
+	// result clears any previously assigned subtype.
+	subtype    uint32
+	subtypeSet bool
+
+	// resultPointer, when set by SetResultPointer, replaces the function's
+	// returned driver.Value with a pointer result.
+	resultPointer *PointerValue
+
+	// userData is the FunctionImpl.UserData value of the function being
+	// invoked, resolved by the trampoline.
+	userData any
+}
+
+// UserData returns the value supplied in FunctionImpl.UserData when the
+// function was registered, or nil. It lets a function carry configuration
+// without global variables.
+func (f *FunctionContext) UserData() any { return f.userData }
+
+// Conn returns the connection the current call is executing on as the stable
+// Conn interface, or nil when the context carries none. It allows a UDF to
+// perform per-connection lookups, e.g. resolving a tenant id from a temp
+// table, instead of relying on globals. The connection is inside a running
+// statement; reads are fine, but do not close it.
+func (f *FunctionContext) Conn() Conn {
+	if f.tls == nil {
+		return nil
+	}
+
+	db := sqlite3.Xsqlite3_context_db_handle(f.tls, f.ctx)
+	xConns.mu.RLock()
+	c := xConns.m[db]
+	xConns.mu.RUnlock()
+	if c == nil {
+		return nil
+	}
+	return c
+}
+
+// xConns maps an open sqlite3* handle back to its owning *conn so callbacks
+// that only receive the C handle, like UDFs, can reach the Go connection.
+var xConns = struct {
+	mu sync.RWMutex
+	m  map[uintptr]*conn
+}{
+	m: make(map[uintptr]*conn),
+}
+
+// aggregateUserData resolves the FunctionImpl.UserData of the aggregate
+// function a callback context belongs to.
+func aggregateUserData(tls *libc.TLS, ctx uintptr) any {
+	id := sqlite3.Xsqlite3_user_data(tls, ctx)
+	xAggregateFactories.mu.RLock()
+	v := xAggregateFactories.ud[id]
+	xAggregateFactories.mu.RUnlock()
+	return v
+}
+
+// ArgSubtype returns the subtype of the i-th argument (zero-based) of the
+// current call, wrapping sqlite3_value_subtype, or 0 when i is out of range
+// or the context carries no arguments. JSON1 tags JSON values with subtype
+// 'J'; the function must be registered with FunctionImpl.UsesSubtype for the
+// tags to be preserved.
+func (f *FunctionContext) ArgSubtype(i int) uint32 {
+	if f.tls == nil || i < 0 || int32(i) >= f.argc {
+		return 0
+	}
+
+	pVal := *(*uintptr)(unsafe.Pointer(f.argv + uintptr(i)*ptrSize))
+	return sqlite3.Xsqlite3_value_subtype(f.tls, pVal)
+}
+
+// SetResultSubtype tags the result of the current call with the given
+// subtype, wrapping sqlite3_result_subtype; only the low 8 bits are used.
+// The function must be registered with FunctionImpl.ResultSubtype, or SQLite
+// discards the tag.
+func (f *FunctionContext) SetResultSubtype(s uint32) {
+	f.subtype = s
+	f.subtypeSet = true
+}
+
+// xAuxdata tracks Go values handed to sqlite3_set_auxdata. The id is what
+// SQLite stores; the delete callback drops the registry entry when SQLite
+// discards the data.
+var xAuxdata = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]any
+	ids idGen
+}{
+	m: make(map[uintptr]any),
+}
+
+func auxdataDeleteTrampoline(tls *libc.TLS, p uintptr) {
+	xAuxdata.mu.Lock()
+	delete(xAuxdata.m, p)
+	xAuxdata.ids.reclaim(p)
+	xAuxdata.mu.Unlock()
+}
+
+// AuxData returns the auxiliary data previously associated with the i-th
+// argument (zero-based) of the current call-site by SetAuxData, wrapping
+// sqlite3_get_auxdata, or nil when none is set. Auxiliary data lets a
+// function like regexp() cache its compiled pattern per call-site instead of
+// recompiling on every row; SQLite may discard it at any time, so callers
+// must be prepared for nil and recompute.
+func (f *FunctionContext) AuxData(i int) any {
+	if f.tls == nil {
+		return nil
+	}
+
+	id := sqlite3.Xsqlite3_get_auxdata(f.tls, f.ctx, int32(i))
+	if id == 0 {
+		return nil
+	}
+
+	xAuxdata.mu.RLock()
+	v := xAuxdata.m[id]
+	xAuxdata.mu.RUnlock()
+	return v
+}
+
+// SetAuxData associates v as auxiliary data with the i-th argument
+// (zero-based) of the current call-site, wrapping sqlite3_set_auxdata. The
+// value is retained until SQLite discards it, typically when the statement
+// finalizes or the argument changes.
+func (f *FunctionContext) SetAuxData(i int, v any) {
+	if f.tls == nil {
+		return
+	}
+
+	xAuxdata.mu.Lock()
+	id := xAuxdata.ids.next()
+	xAuxdata.m[id] = v
+	xAuxdata.mu.Unlock()
+
+	sqlite3.Xsqlite3_set_auxdata(f.tls, f.ctx, int32(i), id, cFuncPointer(auxdataDeleteTrampoline))
 }
 
 const sqliteValPtrSize = unsafe.Sizeof(&sqlite3.Sqlite3_value{})
@@ -459,6 +731,42 @@ func RegisterDeterministicScalarFunction(
 	return registerFunction(zFuncName, &FunctionImpl{NArgs: nArg, Scalar: xFunc, Deterministic: true})
 }
 
+// RegisterAggregateFunction registers an aggregate function named zFuncName
+// with nArg arguments. Passing -1 for nArg indicates the function is
+// variadic. makeAggregate is called at the beginning of each evaluation to
+// create the per-invocation aggregate state; see AggregateFunction for the
+// Step/Final lifecycle.
+//
+// The new function will be available to all new connections opened after
+// executing RegisterAggregateFunction.
+func RegisterAggregateFunction(
+	zFuncName string,
+	nArg int32,
+	makeAggregate func(ctx FunctionContext) (AggregateFunction, error),
+) (err error) {
+	if dmesgs {
+		defer func() {
+			dmesg("zFuncName %q, nArg %v, makeAggregate %p: err %v", zFuncName, nArg, makeAggregate, err)
+		}()
+	}
+	return registerFunction(zFuncName, &FunctionImpl{NArgs: nArg, MakeAggregate: makeAggregate})
+}
+
+// MustRegisterAggregateFunction is like RegisterAggregateFunction but panics
+// on error.
+func MustRegisterAggregateFunction(
+	zFuncName string,
+	nArg int32,
+	makeAggregate func(ctx FunctionContext) (AggregateFunction, error),
+) {
+	if dmesgs {
+		dmesg("zFuncName %q, nArg %v, makeAggregate %p", zFuncName, nArg, makeAggregate)
+	}
+	if err := RegisterAggregateFunction(zFuncName, nArg, makeAggregate); err != nil {
+		panic(err)
+	}
+}
+
 func registerFunction(
 	zFuncName string,
 	impl *FunctionImpl,
@@ -480,6 +788,14 @@ func registerFunction(
 		textrep |= sqlite3.SQLITE_DETERMINISTIC
 	}
 
+	if impl.UsesSubtype {
+		textrep |= sqlite3.SQLITE_SUBTYPE
+	}
+
+	if impl.ResultSubtype {
+		textrep |= sqlite3.SQLITE_RESULT_SUBTYPE
+	}
+
 	udf := &userDefinedFunction{
 		zFuncName: name,
 		nArg:      impl.NArgs,
@@ -490,6 +806,9 @@ func registerFunction(
 		xFuncs.mu.Lock()
 		id := xFuncs.ids.next()
 		xFuncs.m[id] = impl.Scalar
+		if impl.UserData != nil {
+			xFuncs.ud[id] = impl.UserData
+		}
 		xFuncs.mu.Unlock()
 
 		udf.scalar = true
@@ -498,6 +817,9 @@ func registerFunction(
 		xAggregateFactories.mu.Lock()
 		id := xAggregateFactories.ids.next()
 		xAggregateFactories.m[id] = impl.MakeAggregate
+		if impl.UserData != nil {
+			xAggregateFactories.ud[id] = impl.UserData
+		}
 		xAggregateFactories.mu.Unlock()
 
 		udf.pApp = id
@@ -508,12 +830,70 @@ func registerFunction(
 	return nil
 }
 
+// UnregisterFunction removes a function previously registered with
+// RegisterFunction or one of its variants from the driver, so connections
+// opened afterwards no longer see it, and releases the Go-side state backing
+// it. Intended for plugin-style applications that replace functions when a
+// plugin is unloaded, instead of letting the registry grow forever.
+//
+// Connections that are already open keep the function installed; the caller
+// must close them or remove it with Conn.UnregisterFunction before calling
+// this, as invoking the function after its state is released is undefined.
+func UnregisterFunction(zFuncName string) error {
+	udf, ok := d.udfs[zFuncName]
+	if !ok {
+		return fmt.Errorf("no function named %q is registered", zFuncName)
+	}
+
+	delete(d.udfs, zFuncName)
+	if udf.scalar {
+		xFuncs.mu.Lock()
+		delete(xFuncs.m, udf.pApp)
+		delete(xFuncs.ud, udf.pApp)
+		xFuncs.ids.reclaim(udf.pApp)
+		xFuncs.mu.Unlock()
+	} else {
+		xAggregateFactories.mu.Lock()
+		delete(xAggregateFactories.m, udf.pApp)
+		delete(xAggregateFactories.ud, udf.pApp)
+		xAggregateFactories.ids.reclaim(udf.pApp)
+		xAggregateFactories.mu.Unlock()
+	}
+	return nil
+}
+
+// UnregisterCollation removes a collation previously registered with
+// RegisterCollationUtf8 from the driver, so connections opened afterwards no
+// longer see it, and releases the Go-side state backing it. The same caveat
+// as for UnregisterFunction applies to connections that are already open.
+func UnregisterCollation(zName string) error {
+	coll, ok := d.collations[zName]
+	if !ok {
+		return fmt.Errorf("no collation named %q is registered", zName)
+	}
+
+	delete(d.collations, zName)
+	xCollations.mu.Lock()
+	delete(xCollations.m, coll.pApp)
+	xCollations.ids.reclaim(coll.pApp)
+	xCollations.mu.Unlock()
+	libc.Xfree(nil, coll.zName)
+	return nil
+}
+
 // RegisterConnectionHook registers a function to be called after each connection
 // is opened. This is called after all the connection has been set up.
 func RegisterConnectionHook(fn ConnectionHookFn) {
 	d.RegisterConnectionHook(fn)
 }
 
+// SetWalAutoCheckpoint arranges for every connection subsequently opened by
+// the default driver to have its WAL autocheckpoint threshold set to nFrames.
+// Zero or a negative value disables automatic checkpointing.
+func SetWalAutoCheckpoint(nFrames int32) {
+	d.SetWalAutoCheckpoint(nFrames)
+}
+
 func origin(skip int) string {
 	pc, fn, fl, _ := runtime.Caller(skip)
 	f := runtime.FuncForPC(pc)
@@ -527,18 +907,64 @@ func origin(skip int) string {
 	return fmt.Sprintf("%s:%d:%s", fn, fl, fns)
 }
 
+// FunctionError is an error a user-defined function can return to fail the
+// statement with a specific SQLite result code instead of the generic
+// SQLITE_ERROR, e.g. SQLITE_CONSTRAINT or SQLITE_AUTH. The special codes
+// SQLITE_TOOBIG and SQLITE_NOMEM map to sqlite3_result_error_toobig and
+// sqlite3_result_error_nomem respectively; everything else goes through
+// sqlite3_result_error_code.
+type FunctionError struct {
+	// Msg is the error message; empty leaves SQLite's default message for
+	// Code in place.
+	Msg string
+	// Code is the SQLite result code the statement fails with.
+	Code int
+}
+
+// Error implements error.
+func (e *FunctionError) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	if s, ok := ErrorCodeString[e.Code]; ok {
+		return s
+	}
+	return fmt.Sprintf("sqlite result code %d", e.Code)
+}
+
 func errorResultFunction(tls *libc.TLS, ctx uintptr) func(error) {
 	return func(res error) {
-		errmsg, cerr := libc.CString(res.Error())
-		if cerr != nil {
-			panic(cerr)
+		var fe *FunctionError
+		if errors.As(res, &fe) {
+			switch fe.Code {
+			case sqlite3.SQLITE_TOOBIG:
+				sqlite3.Xsqlite3_result_error_toobig(tls, ctx)
+				return
+			case sqlite3.SQLITE_NOMEM:
+				sqlite3.Xsqlite3_result_error_nomem(tls, ctx)
+				return
+			}
+			if fe.Msg != "" {
+				setErrorMsg(tls, ctx, fe.Msg)
+			}
+			sqlite3.Xsqlite3_result_error_code(tls, ctx, int32(fe.Code))
+			return
 		}
-		defer libc.Xfree(tls, errmsg)
-		sqlite3.Xsqlite3_result_error(tls, ctx, errmsg, -1)
+
+		setErrorMsg(tls, ctx, res.Error())
 		sqlite3.Xsqlite3_result_error_code(tls, ctx, sqlite3.SQLITE_ERROR)
 	}
 }
 
+func setErrorMsg(tls *libc.TLS, ctx uintptr, msg string) {
+	errmsg, cerr := libc.CString(msg)
+	if cerr != nil {
+		panic(cerr)
+	}
+	defer libc.Xfree(tls, errmsg)
+	sqlite3.Xsqlite3_result_error(tls, ctx, errmsg, -1)
+}
+
 func functionArgs(tls *libc.TLS, argc int32, argv uintptr) []driver.Value {
 	args := make([]driver.Value, argc)
 	for i := int32(0); i < argc; i++ {
@@ -581,6 +1007,10 @@ func functionReturnValue(tls *libc.TLS, ctx uintptr, res driver.Value) error {
 		sqlite3.Xsqlite3_result_int(tls, ctx, libc.Bool32(resTyped))
 	case time.Time:
 		sqlite3.Xsqlite3_result_int64(tls, ctx, resTyped.Unix())
+	case ZeroBlob:
+		if rc := sqlite3.Xsqlite3_result_zeroblob64(tls, ctx, sqlite3.Tu64(resTyped)); rc != sqlite3.SQLITE_OK {
+			return fmt.Errorf("sqlite: result_zeroblob64: %s (%d)", libc.GoString(sqlite3.Xsqlite3_errstr(tls, rc)), rc)
+		}
 	case string:
 		size := int32(len(resTyped))
 		cstr, err := libc.CString(resTyped)
@@ -627,17 +1057,21 @@ var (
 	xFuncs = struct {
 		mu  sync.RWMutex
 		m   map[uintptr]func(*FunctionContext, []driver.Value) (driver.Value, error)
+		ud  map[uintptr]any
 		ids idGen
 	}{
-		m: make(map[uintptr]func(*FunctionContext, []driver.Value) (driver.Value, error)),
+		m:  make(map[uintptr]func(*FunctionContext, []driver.Value) (driver.Value, error)),
+		ud: make(map[uintptr]any),
 	}
 
 	xAggregateFactories = struct {
 		mu  sync.RWMutex
 		m   map[uintptr]func(FunctionContext) (AggregateFunction, error)
+		ud  map[uintptr]any
 		ids idGen
 	}{
-		m: make(map[uintptr]func(FunctionContext) (AggregateFunction, error)),
+		m:  make(map[uintptr]func(FunctionContext) (AggregateFunction, error)),
+		ud: make(map[uintptr]any),
 	}
 
 	xAggregateContext = struct {
@@ -681,7 +1115,7 @@ func (gen *idGen) reclaim(id uintptr) {
 }
 
 func makeAggregate(tls *libc.TLS, ctx uintptr) (AggregateFunction, uintptr) {
-	goCtx := FunctionContext{tls: tls, ctx: ctx}
+	goCtx := FunctionContext{tls: tls, ctx: ctx, userData: aggregateUserData(tls, ctx)}
 	aggCtx := (*uintptr)(unsafe.Pointer(sqlite3.Xsqlite3_aggregate_context(tls, ctx, int32(ptrSize))))
 	setErrorResult := errorResultFunction(tls, ctx)
 	if aggCtx == nil {
@@ -738,19 +1172,36 @@ func funcTrampoline(tls *libc.TLS, ctx uintptr, argc int32, argv uintptr) {
 	id := sqlite3.Xsqlite3_user_data(tls, ctx)
 	xFuncs.mu.RLock()
 	xFunc := xFuncs.m[id]
+	userData := xFuncs.ud[id]
 	xFuncs.mu.RUnlock()
 
 	setErrorResult := errorResultFunction(tls, ctx)
-	res, err := xFunc(&FunctionContext{}, functionArgs(tls, argc, argv))
+	fctx := &FunctionContext{tls: tls, ctx: ctx, argc: argc, argv: argv, userData: userData}
+	res, err := xFunc(fctx, functionArgs(tls, argc, argv))
 
 	if err != nil {
 		setErrorResult(err)
 		return
 	}
 
+	if pv := fctx.resultPointer; pv != nil {
+		zType, err := internPointerType(pv.Type)
+		if err != nil {
+			setErrorResult(err)
+			return
+		}
+		sqlite3.Xsqlite3_result_pointer(tls, ctx, newPointerHandle(*pv), zType, cFuncPointer(pointerDestroyTrampoline))
+		return
+	}
+
 	err = functionReturnValue(tls, ctx, res)
 	if err != nil {
 		setErrorResult(err)
+		return
+	}
+
+	if fctx.subtypeSet {
+		sqlite3.Xsqlite3_result_subtype(tls, ctx, fctx.subtype)
 	}
 }
 
@@ -776,7 +1227,7 @@ func stepTrampoline(tls *libc.TLS, ctx uintptr, argc int32, argv uintptr) {
 	}
 
 	setErrorResult := errorResultFunction(tls, ctx)
-	err := impl.Step(&FunctionContext{}, functionArgs(tls, argc, argv))
+	err := impl.Step(&FunctionContext{tls: tls, ctx: ctx, argc: argc, argv: argv, userData: aggregateUserData(tls, ctx)}, functionArgs(tls, argc, argv))
 	if err != nil {
 		setErrorResult(err)
 	}
@@ -789,7 +1240,7 @@ func inverseTrampoline(tls *libc.TLS, ctx uintptr, argc int32, argv uintptr) {
 	}
 
 	setErrorResult := errorResultFunction(tls, ctx)
-	err := impl.WindowInverse(&FunctionContext{}, functionArgs(tls, argc, argv))
+	err := impl.WindowInverse(&FunctionContext{tls: tls, ctx: ctx, argc: argc, argv: argv, userData: aggregateUserData(tls, ctx)}, functionArgs(tls, argc, argv))
 	if err != nil {
 		setErrorResult(err)
 	}
@@ -802,13 +1253,16 @@ func valueTrampoline(tls *libc.TLS, ctx uintptr) {
 	}
 
 	setErrorResult := errorResultFunction(tls, ctx)
-	res, err := impl.WindowValue(&FunctionContext{})
+	fctx := &FunctionContext{tls: tls, ctx: ctx, userData: aggregateUserData(tls, ctx)}
+	res, err := impl.WindowValue(fctx)
 	if err != nil {
 		setErrorResult(err)
 	} else {
 		err = functionReturnValue(tls, ctx, res)
 		if err != nil {
 			setErrorResult(err)
+		} else if fctx.subtypeSet {
+			sqlite3.Xsqlite3_result_subtype(tls, ctx, fctx.subtype)
 		}
 	}
 }
@@ -820,16 +1274,19 @@ func finalTrampoline(tls *libc.TLS, ctx uintptr) {
 	}
 
 	setErrorResult := errorResultFunction(tls, ctx)
-	res, err := impl.WindowValue(&FunctionContext{})
+	fctx := &FunctionContext{tls: tls, ctx: ctx, userData: aggregateUserData(tls, ctx)}
+	res, err := impl.WindowValue(fctx)
 	if err != nil {
 		setErrorResult(err)
 	} else {
 		err = functionReturnValue(tls, ctx, res)
 		if err != nil {
 			setErrorResult(err)
+		} else if fctx.subtypeSet {
+			sqlite3.Xsqlite3_result_subtype(tls, ctx, fctx.subtype)
 		}
 	}
-	impl.Final(&FunctionContext{})
+	impl.Final(&FunctionContext{tls: tls, ctx: ctx, userData: fctx.userData})
 
 	xAggregateContext.mu.Lock()
 	defer xAggregateContext.mu.Unlock()
@@ -861,6 +1318,24 @@ func collationTrampoline(tls *libc.TLS, pApp uintptr, nLeft int32, zLeft uintptr
 	}
 }
 
+// Limit categories accepted by Limit, mapping to the SQLITE_LIMIT_*
+// constants. See https://www.sqlite.org/c3ref/c_limit_attached.html for what
+// each one bounds.
+const (
+	LimitLength            = int(sqlite3.SQLITE_LIMIT_LENGTH)
+	LimitSQLLength         = int(sqlite3.SQLITE_LIMIT_SQL_LENGTH)
+	LimitColumn            = int(sqlite3.SQLITE_LIMIT_COLUMN)
+	LimitExprDepth         = int(sqlite3.SQLITE_LIMIT_EXPR_DEPTH)
+	LimitCompoundSelect    = int(sqlite3.SQLITE_LIMIT_COMPOUND_SELECT)
+	LimitVdbeOp            = int(sqlite3.SQLITE_LIMIT_VDBE_OP)
+	LimitFunctionArg       = int(sqlite3.SQLITE_LIMIT_FUNCTION_ARG)
+	LimitAttached          = int(sqlite3.SQLITE_LIMIT_ATTACHED)
+	LimitLikePatternLength = int(sqlite3.SQLITE_LIMIT_LIKE_PATTERN_LENGTH)
+	LimitVariableNumber    = int(sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
+	LimitTriggerDepth      = int(sqlite3.SQLITE_LIMIT_TRIGGER_DEPTH)
+	LimitWorkerThreads     = int(sqlite3.SQLITE_LIMIT_WORKER_THREADS)
+)
+
 // Limit calls sqlite3_limit, see the docs at
 // https://www.sqlite.org/c3ref/limit.html for details.
 //
@@ -881,3 +1356,129 @@ func Limit(c *sql.Conn, id int, newVal int) (r int, err error) {
 	return r, err
 
 }
+
+// Complete reports whether sql appears to be a complete SQL statement, i.e.
+// it ends with a semicolon that is not part of a string literal, comment or
+// CREATE TRIGGER body. It wraps sqlite3_complete and lets REPLs decide
+// whether to prompt for more input and migration splitters find statement
+// boundaries before executing anything.
+func Complete(sql string) bool {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	p, err := libc.CString(sql)
+	if err != nil {
+		return false
+	}
+
+	defer libc.Xfree(tls, p)
+
+	return sqlite3.Xsqlite3_complete(tls, p) == 1
+}
+
+// SoftHeapLimit sets the process-wide soft heap limit to n bytes, wrapping
+// sqlite3_soft_heap_limit64, and returns the previous limit. When the limit
+// is exceeded SQLite frees cached memory where it can but keeps working;
+// zero disables the limit and a negative n leaves it unchanged, making
+// SoftHeapLimit(-1) a pure query.
+func SoftHeapLimit(n int64) int64 {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	return int64(sqlite3.Xsqlite3_soft_heap_limit64(tls, sqlite3.Tsqlite3_int64(n)))
+}
+
+// HardHeapLimit sets the process-wide hard heap limit to n bytes, wrapping
+// sqlite3_hard_heap_limit64, and returns the previous limit. Unlike the soft
+// limit, allocations that would exceed it fail with SQLITE_NOMEM; zero
+// disables the limit and a negative n leaves it unchanged. The hard limit
+// cannot be set below the current soft limit.
+func HardHeapLimit(n int64) int64 {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	return int64(sqlite3.Xsqlite3_hard_heap_limit64(tls, sqlite3.Tsqlite3_int64(n)))
+}
+
+// EnableMemoryStatistics enables or disables collection of the memory
+// statistics reported by MemoryUsed and MemoryHighwater, wrapping
+// sqlite3_config(SQLITE_CONFIG_MEMSTATUS). This package compiles SQLite with
+// SQLITE_DEFAULT_MEMSTATUS=0, so both functions report zero until statistics
+// are enabled. Like every sqlite3_config option it must be called before the
+// library initializes, i.e. before the first connection is opened; afterwards
+// it fails with SQLITE_MISUSE.
+func EnableMemoryStatistics(enable bool) error {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	var v int32
+	if enable {
+		v = 1
+	}
+	va := libc.NewVaList(v)
+
+	defer libc.Xfree(tls, va)
+
+	if rc := sqlite3.Xsqlite3_config(tls, sqlite3.SQLITE_CONFIG_MEMSTATUS, va); rc != sqlite3.SQLITE_OK {
+		return fmt.Errorf("%s (%d)", libc.GoString(sqlite3.Xsqlite3_errstr(tls, rc)), rc)
+	}
+
+	return nil
+}
+
+// MemoryUsed returns the number of bytes of memory currently outstanding
+// (malloced but not freed) by the SQLite library, wrapping
+// sqlite3_memory_used. Together with MemoryHighwater it lets applications
+// export SQLite memory consumption to their monitoring stack. Requires
+// EnableMemoryStatistics; without it the counters stay at zero.
+func MemoryUsed() int64 {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	return int64(sqlite3.Xsqlite3_memory_used(tls))
+}
+
+// MemoryHighwater returns the maximum value MemoryUsed has reached since the
+// high-water mark was last reset, wrapping sqlite3_memory_highwater. When
+// reset is true the mark is reset to the current MemoryUsed value after being
+// read.
+func MemoryHighwater(reset bool) int64 {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	var v int32
+	if reset {
+		v = 1
+	}
+	return int64(sqlite3.Xsqlite3_memory_highwater(tls, v))
+}
+
+// EnableSharedCache sets the process-wide shared-cache default, wrapping
+// sqlite3_enable_shared_cache. It affects connections opened afterwards;
+// existing connections keep the mode they were opened with, and the
+// _sharedcache DSN parameter or a cache= URI parameter overrides the default
+// for an individual connection. Shared cache trades per-connection page
+// caches for a single shared one, which saves memory when many connections
+// open the same database but makes table-level SQLITE_LOCKED contention
+// possible; see https://www.sqlite.org/sharedcache.html.
+func EnableSharedCache(enable bool) error {
+	tls := libc.NewTLS()
+
+	defer tls.Close()
+
+	var v int32
+	if enable {
+		v = 1
+	}
+	if rc := sqlite3.Xsqlite3_enable_shared_cache(tls, v); rc != sqlite3.SQLITE_OK {
+		return fmt.Errorf("%s (%d)", libc.GoString(sqlite3.Xsqlite3_errstr(tls, rc)), rc)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ package sqlite // import "github.com/syralon/sqlite"
 import (
 	"context"
 	"database/sql/driver"
+	"reflect"
 	"sync/atomic"
 	"unsafe"
 
@@ -234,6 +235,74 @@ func (s *stmt) exec(ctx context.Context, args []driver.NamedValue) (r driver.Res
 	return r, err
 }
 
+// CheckNamedValue implements driver.NamedValueChecker. It widens the set of
+// Go types that bind without the caller converting them to the base driver
+// types first: named []byte types such as json.RawMessage, byte arrays such
+// as [16]byte UUIDs, pointers (nil binds NULL), and driver.Valuer
+// implementations whose Value method itself returns one of the above.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	v, err := convertBindValue(nv.Value)
+	if err != nil {
+		return err
+	}
+
+	nv.Value = v
+	return nil
+}
+
+func convertBindValue(v any) (driver.Value, error) {
+	// Pointer-passing values go through as-is; conn.bind routes them to
+	// sqlite3_bind_pointer.
+	if pv, ok := v.(PointerValue); ok {
+		return pv, nil
+	}
+
+	// Zero-filled blob placeholders likewise; conn.bind routes them to
+	// sqlite3_bind_zeroblob64.
+	if zb, ok := v.(ZeroBlob); ok {
+		return zb, nil
+	}
+
+	if vr, ok := v.(driver.Valuer); ok {
+		// A typed nil pointer still satisfies driver.Valuer; calling Value on
+		// a value-receiver implementation through it would panic. Bind NULL
+		// instead, matching database/sql.
+		if rv := reflect.ValueOf(vr); rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+
+		val, err := vr.Value()
+		if err != nil {
+			return nil, err
+		}
+		return convertBindValue(val)
+	}
+
+	if driver.IsValue(v) {
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return convertBindValue(rv.Elem().Interface())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
 // NumInput returns the number of placeholder parameters.
 //
 // If NumInput returns >= 0, the sql package will sanity check argument counts
@@ -448,6 +517,17 @@ func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (dr dr
 			dmesg("stmt %p, ctx %p, args %v: (driver.Result %p, err %v)", s, ctx, args, dr, err)
 		}()
 	}
+	restore, err := s.c.applyQuerySettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restore != nil {
+		defer func() {
+			if e := restore(); e != nil && err == nil {
+				dr, err = nil, e
+			}
+		}()
+	}
 	return s.exec(ctx, args)
 }
 
@@ -458,6 +538,23 @@ func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (dr d
 			dmesg("stmt %p, ctx %p, args %v: (driver.Rows %p, err %v)", s, ctx, args, dr, err)
 		}()
 	}
+	restore, err := s.c.applyQuerySettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restore != nil {
+		// The returned rows keep the settings in effect until closed; on
+		// failure restore immediately.
+		defer func() {
+			if err != nil || dr == nil {
+				if e := restore(); e != nil && err == nil {
+					dr, err = nil, e
+				}
+				return
+			}
+			dr.(*rows).restore = restore
+		}()
+	}
 	return s.query(ctx, args)
 }
 
@@ -0,0 +1,72 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+)
+
+// StmtStatus is a snapshot of a prepared statement's sqlite3_stmt_status
+// counters, collected by Stmt.Status. Non-zero FullscanSteps or Sorts flag
+// queries that walk whole tables or build temporary B-trees and usually want
+// an index.
+type StmtStatus struct {
+	// FullscanSteps counts steps of full table scans.
+	FullscanSteps int64
+
+	// Sorts counts sort operations, i.e. ORDER BY or GROUP BY clauses not
+	// satisfied by an index.
+	Sorts int64
+
+	// Autoindexes counts transient indexes built just for this statement.
+	Autoindexes int64
+
+	// VMSteps counts virtual machine operations executed.
+	VMSteps int64
+
+	// Reprepares counts automatic re-preparations, e.g. after schema
+	// changes.
+	Reprepares int64
+
+	// Runs counts how often the statement has been executed.
+	Runs int64
+
+	// MemUsed is the heap memory used by the statement, in bytes. It is
+	// not affected by the reset flag.
+	MemUsed int64
+}
+
+// Status collects the statement's sqlite3_stmt_status counters. With reset
+// set, the counters (except MemUsed) restart from zero after being read. It
+// is only available for single-statement queries; preparing a script of
+// several statements reports an error.
+//
+// Access it by preparing the statement on a driver connection obtained
+// through sql.Conn.Raw and type asserting the result.
+func (s *stmt) Status(reset bool) (*StmtStatus, error) {
+	if s.pstmt == 0 {
+		return nil, fmt.Errorf("sqlite: statement status is not available for multi-statement scripts")
+	}
+
+	var resetFlag int32
+	if reset {
+		resetFlag = 1
+	}
+
+	get := func(op int32) int64 {
+		return int64(sqlite3.Xsqlite3_stmt_status(s.c.tls, s.pstmt, op, resetFlag))
+	}
+	return &StmtStatus{
+		FullscanSteps: get(sqlite3.SQLITE_STMTSTATUS_FULLSCAN_STEP),
+		Sorts:         get(sqlite3.SQLITE_STMTSTATUS_SORT),
+		Autoindexes:   get(sqlite3.SQLITE_STMTSTATUS_AUTOINDEX),
+		VMSteps:       get(sqlite3.SQLITE_STMTSTATUS_VM_STEP),
+		Reprepares:    get(sqlite3.SQLITE_STMTSTATUS_REPREPARE),
+		Runs:          get(sqlite3.SQLITE_STMTSTATUS_RUN),
+		MemUsed:       get(sqlite3.SQLITE_STMTSTATUS_MEMUSED),
+	}, nil
+}
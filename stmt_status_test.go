@@ -0,0 +1,105 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestStmtStatus(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(v);
+		insert into t values (3), (1), (2);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	type statuser interface {
+		Status(reset bool) (*StmtStatus, error)
+	}
+	if err := cn.Raw(func(driverConn any) error {
+		st, err := driverConn.(driver.Conn).Prepare("select v from t order by v")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer st.Close()
+
+		rows, err := st.Query(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dest := make([]driver.Value, 1)
+		for rows.Next(dest) == nil {
+		}
+		rows.Close()
+
+		s, err := st.(statuser).Status(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.FullscanSteps == 0 {
+			t.Error("FullscanSteps = 0, want > 0 for unindexed scan")
+		}
+		if s.Sorts == 0 {
+			t.Error("Sorts = 0, want > 0 for unindexed order by")
+		}
+		if s.VMSteps == 0 {
+			t.Error("VMSteps = 0, want > 0")
+		}
+		if s.Runs != 1 {
+			t.Errorf("Runs = %d, want 1", s.Runs)
+		}
+		if s.MemUsed <= 0 {
+			t.Errorf("MemUsed = %d, want > 0", s.MemUsed)
+		}
+
+		// The read above reset the resettable counters.
+		if s, err = st.(statuser).Status(false); err != nil {
+			t.Fatal(err)
+		}
+
+		if s.VMSteps != 0 {
+			t.Errorf("VMSteps = %d after reset, want 0", s.VMSteps)
+		}
+		if s.MemUsed <= 0 {
+			t.Errorf("MemUsed = %d after reset, want > 0", s.MemUsed)
+		}
+
+		// Scripts have no single statement handle to report on.
+		script, err := driverConn.(driver.Conn).Prepare("select 1; select 2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer script.Close()
+
+		if _, err := script.(statuser).Status(false); err == nil {
+			t.Error("expected error for multi-statement script")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
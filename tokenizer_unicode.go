@@ -0,0 +1,200 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	MustRegisterFTS5Tokenizer("unicode_words", newUnicodeWordsTokenizer)
+}
+
+// unicodeWordsTokenizer is a pure Go FTS5 tokenizer aimed at non-Latin text.
+// It segments input into runs of Unicode letters and digits, lowercases them
+// and optionally folds diacritics. CJK codepoints are tokenized separately,
+// either as individual characters or as overlapping bigrams, which gives
+// usable full-text search for languages without word separators.
+//
+// It is registered as "unicode_words" on every new connection and accepts the
+// following options in the tokenize=... clause, as name value pairs:
+//
+//	cjk_bigrams       0 or 1 (default 0): emit overlapping bigrams for runs
+//	                  of CJK characters instead of single characters.
+//	remove_diacritics 0 or 1 (default 1): strip combining marks after
+//	                  canonical decomposition, so e.g. "café" matches "cafe".
+//	stopwords         comma separated list of tokens to drop from documents
+//	                  and queries.
+//
+// Example:
+//
+//	CREATE VIRTUAL TABLE doc USING fts5(body,
+//	    tokenize = 'unicode_words cjk_bigrams 1 stopwords ''the,a,of''');
+type unicodeWordsTokenizer struct {
+	cjkBigrams       bool
+	removeDiacritics bool
+	stopWords        map[string]struct{}
+}
+
+func newUnicodeWordsTokenizer(args []string) (FTS5Tokenizer, error) {
+	t := &unicodeWordsTokenizer{removeDiacritics: true}
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("unicode_words: options must be name value pairs: %q", args)
+	}
+	for i := 0; i < len(args); i += 2 {
+		name, value := args[i], args[i+1]
+		switch name {
+		case "cjk_bigrams", "remove_diacritics":
+			var on bool
+			switch value {
+			case "0":
+				on = false
+			case "1":
+				on = true
+			default:
+				return nil, fmt.Errorf("unicode_words: option %q must be 0 or 1, got %q", name, value)
+			}
+			if name == "cjk_bigrams" {
+				t.cjkBigrams = on
+			} else {
+				t.removeDiacritics = on
+			}
+		case "stopwords":
+			t.stopWords = make(map[string]struct{})
+			for _, w := range strings.Split(value, ",") {
+				if w = strings.TrimSpace(w); w != "" {
+					t.stopWords[strings.ToLower(w)] = struct{}{}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unicode_words: unknown option %q", name)
+		}
+	}
+	return t, nil
+}
+
+// cjkRanges covers the scripts that are tokenized per character (or as
+// bigrams) rather than as whitespace separated words.
+var cjkRanges = []*unicode.RangeTable{
+	unicode.Han,
+	unicode.Hiragana,
+	unicode.Katakana,
+	unicode.Hangul,
+}
+
+func isCJK(r rune) bool {
+	for _, rt := range cjkRanges {
+		if unicode.Is(rt, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tokenize implements FTS5Tokenizer.
+func (t *unicodeWordsTokenizer) Tokenize(flags int, text string, emit FTS5TokenFn) error {
+	i := 0
+	for i < len(text) {
+		r, sz := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isCJK(r):
+			end := i
+			for end < len(text) {
+				r, sz := utf8.DecodeRuneInString(text[end:])
+				if !isCJK(r) {
+					break
+				}
+				end += sz
+			}
+			if err := t.emitCJK(text, i, end, emit); err != nil {
+				return err
+			}
+			i = end
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			end := i
+			for end < len(text) {
+				r, sz := utf8.DecodeRuneInString(text[end:])
+				if isCJK(r) || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+					break
+				}
+				end += sz
+			}
+			if err := t.emitWord(text[i:end], i, end, emit); err != nil {
+				return err
+			}
+			i = end
+		default:
+			i += sz
+		}
+	}
+	return nil
+}
+
+// emitWord folds and emits a single non-CJK word token unless it is a stop
+// word.
+func (t *unicodeWordsTokenizer) emitWord(word string, start, end int, emit FTS5TokenFn) error {
+	tok := t.fold(word)
+	if tok == "" {
+		return nil
+	}
+	if _, drop := t.stopWords[tok]; drop {
+		return nil
+	}
+	return emit(tok, start, end)
+}
+
+// emitCJK emits tokens for the run of CJK characters text[start:end], either
+// one token per character or overlapping bigrams.
+func (t *unicodeWordsTokenizer) emitCJK(text string, start, end int, emit FTS5TokenFn) error {
+	// Collect the byte offset of each character in the run, plus the end
+	// offset, so bigram boundaries can be reported precisely.
+	var offs []int
+	for i := start; i < end; {
+		_, sz := utf8.DecodeRuneInString(text[i:])
+		offs = append(offs, i)
+		i += sz
+	}
+	offs = append(offs, end)
+
+	n := len(offs) - 1 // number of characters
+	if t.cjkBigrams && n >= 2 {
+		for i := 0; i < n-1; i++ {
+			if err := emit(text[offs[i]:offs[i+2]], offs[i], offs[i+2]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		if err := emit(text[offs[i]:offs[i+1]], offs[i], offs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fold lowercases s and optionally strips combining marks after canonical
+// decomposition.
+func (t *unicodeWordsTokenizer) fold(s string) string {
+	s = strings.ToLower(s)
+	if !t.removeDiacritics {
+		return s
+	}
+	s = norm.NFD.String(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
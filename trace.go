@@ -0,0 +1,157 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+var xTraceHandlers = struct {
+	mu sync.RWMutex
+	m  map[uintptr]TraceFn
+}{
+	m: make(map[uintptr]TraceFn),
+}
+
+// Trace event codes, used both as bits of the mask passed to RegisterTrace
+// and as the Code of a delivered TraceEvent.
+const (
+	TraceStmt    = uint32(sqlite3.SQLITE_TRACE_STMT)
+	TraceProfile = uint32(sqlite3.SQLITE_TRACE_PROFILE)
+	TraceRow     = uint32(sqlite3.SQLITE_TRACE_ROW)
+	TraceClose   = uint32(sqlite3.SQLITE_TRACE_CLOSE)
+)
+
+// TraceEvent describes one sqlite3_trace_v2 notification.
+type TraceEvent struct {
+	// Code is one of TraceStmt, TraceProfile, TraceRow or TraceClose.
+	Code uint32
+
+	// SQL is the statement's expanded SQL text, with bound parameters
+	// substituted in. It is set for TraceStmt and TraceProfile events;
+	// for statements run by triggers it holds the trigger comment SQLite
+	// reports instead.
+	SQL string
+
+	// Duration is how long the statement ran. It is only set for
+	// TraceProfile events, which fire when a statement finishes.
+	Duration time.Duration
+}
+
+// TraceFn receives trace events for a connection.
+type TraceFn func(event TraceEvent)
+
+// RegisterTrace wires callback into sqlite3_trace_v2, reporting the SQL a
+// connection actually executes and how long it takes. mask selects the event
+// classes to deliver and is any combination of TraceStmt, TraceProfile,
+// TraceRow and TraceClose. Passing a nil callback or an empty mask removes
+// the trace.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) RegisterTrace(mask uint32, callback TraceFn) error {
+	if callback == nil || mask == 0 {
+		xTraceHandlers.mu.Lock()
+		delete(xTraceHandlers.m, c.db)
+		xTraceHandlers.mu.Unlock()
+		rc := sqlite3.Xsqlite3_trace_v2(c.tls, c.db, 0, uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(nil)))
+		if rc != sqlite3.SQLITE_OK {
+			return c.errstr(rc)
+		}
+		return nil
+	}
+	xTraceHandlers.mu.Lock()
+	xTraceHandlers.m[c.db] = callback
+	xTraceHandlers.mu.Unlock()
+
+	rc := sqlite3.Xsqlite3_trace_v2(c.tls, c.db, mask, cFuncPointer(traceTrampoline), c.db)
+	if rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// ProfileFn receives the expanded SQL text and run time of a completed
+// statement.
+type ProfileFn func(sql string, d time.Duration)
+
+// profilerDesc describes the driver-level profile callback registered with
+// RegisterProfiler.
+type profilerDesc struct {
+	threshold time.Duration
+	fn        ProfileFn
+}
+
+// RegisterProfiler installs fn as a profile callback on every connection the
+// driver opens afterwards. fn is invoked after a statement completes with its
+// expanded SQL text and duration, but only when the statement ran for at
+// least threshold — pass e.g. 50*time.Millisecond to log only slow queries,
+// or 0 to report every statement.
+//
+// The profiler uses the connection's sqlite3_trace_v2 slot; a later
+// RegisterTrace call on a connection replaces it there.
+func RegisterProfiler(threshold time.Duration, fn ProfileFn) {
+	d.RegisterProfiler(threshold, fn)
+}
+
+// RegisterProfiler installs fn as a profile callback on every connection the
+// driver opens afterwards. See the package-level RegisterProfiler.
+func (d *Driver) RegisterProfiler(threshold time.Duration, fn ProfileFn) {
+	if fn == nil {
+		d.profiler = nil
+		return
+	}
+	d.profiler = &profilerDesc{threshold: threshold, fn: fn}
+}
+
+// registerProfiler applies the driver-level profiler to a new connection.
+func (c *conn) registerProfiler(p *profilerDesc) error {
+	return c.RegisterTrace(TraceProfile, func(event TraceEvent) {
+		if event.Duration >= p.threshold {
+			p.fn(event.SQL, event.Duration)
+		}
+	})
+}
+
+func traceTrampoline(tls *libc.TLS, event uint32, handle uintptr, p uintptr, x uintptr) int32 {
+	xTraceHandlers.mu.RLock()
+	xTraceHandler := xTraceHandlers.m[handle]
+	xTraceHandlers.mu.RUnlock()
+
+	if xTraceHandler == nil {
+		return 0
+	}
+
+	ev := TraceEvent{Code: event}
+	switch event {
+	case TraceStmt:
+		// x is the unexpanded SQL text or a trigger comment. Prefer the
+		// expanded form so bound parameters are visible; triggers report
+		// a "--" comment and have no expansion.
+		if s := libc.GoString(x); len(s) >= 2 && s[:2] == "--" {
+			ev.SQL = s
+		} else if pz := sqlite3.Xsqlite3_expanded_sql(tls, p); pz != 0 {
+			ev.SQL = libc.GoString(pz)
+			sqlite3.Xsqlite3_free(tls, pz)
+		} else {
+			ev.SQL = s
+		}
+	case TraceProfile:
+		// x points to the statement's run time in nanoseconds.
+		ev.Duration = time.Duration(*(*int64)(unsafe.Pointer(x)))
+		if pz := sqlite3.Xsqlite3_expanded_sql(tls, p); pz != 0 {
+			ev.SQL = libc.GoString(pz)
+			sqlite3.Xsqlite3_free(tls, pz)
+		} else if pz := sqlite3.Xsqlite3_sql(tls, p); pz != 0 {
+			ev.SQL = libc.GoString(pz)
+		}
+	}
+	xTraceHandler(ev)
+	return 0
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterTrace(t *testing.T) {
+	db, err := sql.Open(driverName, "file::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	var events []TraceEvent
+	type traceRegistrar interface {
+		RegisterTrace(uint32, TraceFn) error
+	}
+	if err := cn.Raw(func(driverConn any) error {
+		return driverConn.(traceRegistrar).RegisterTrace(
+			TraceStmt|TraceProfile|TraceRow,
+			func(event TraceEvent) {
+				events = append(events, event)
+			})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(context.Background(), "create table t(v)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cn.ExecContext(context.Background(), "insert into t values (?)", 42); err != nil {
+		t.Fatal(err)
+	}
+
+	var v int
+	if err := cn.QueryRowContext(context.Background(), "select v from t").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	var stmts, profiles, rows int
+	var sawExpanded, sawDuration bool
+	for _, ev := range events {
+		switch ev.Code {
+		case TraceStmt:
+			stmts++
+			if strings.Contains(ev.SQL, "insert into t values (42)") {
+				sawExpanded = true
+			}
+		case TraceProfile:
+			profiles++
+			if ev.Duration >= 0 && ev.SQL != "" {
+				sawDuration = true
+			}
+		case TraceRow:
+			rows++
+		}
+	}
+
+	if stmts < 3 {
+		t.Errorf("got %d TraceStmt events, want at least 3", stmts)
+	}
+	if profiles < 3 {
+		t.Errorf("got %d TraceProfile events, want at least 3", profiles)
+	}
+	if rows < 1 {
+		t.Errorf("got %d TraceRow events, want at least 1", rows)
+	}
+	if !sawExpanded {
+		t.Error("no TraceStmt event carried the expanded insert statement")
+	}
+	if !sawDuration {
+		t.Error("no TraceProfile event carried SQL text and a duration")
+	}
+
+	// Removing the trace stops event delivery.
+	if err := cn.Raw(func(driverConn any) error {
+		return driverConn.(traceRegistrar).RegisterTrace(0, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	n := len(events)
+	if _, err := cn.ExecContext(context.Background(), "delete from t"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != n {
+		t.Errorf("trace still active after removal: %d new events", len(events)-n)
+	}
+}
+
+func TestRegisterProfiler(t *testing.T) {
+	type profiled struct {
+		sql string
+		d   time.Duration
+	}
+
+	var slow, all []profiled
+	var slowDriver, allDriver Driver
+	slowDriver.RegisterProfiler(time.Hour, func(sql string, d time.Duration) {
+		slow = append(slow, profiled{sql, d})
+	})
+	allDriver.RegisterProfiler(0, func(sql string, d time.Duration) {
+		all = append(all, profiled{sql, d})
+	})
+	sql.Register("sqlite3_profiler_slow_test", &slowDriver)
+	sql.Register("sqlite3_profiler_all_test", &allDriver)
+
+	run := func(driverName string) {
+		t.Helper()
+		db, err := sql.Open(driverName, "file::memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer db.Close()
+
+		if _, err := db.Exec("create table t(v)"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.Exec("insert into t values (?)", 42); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("sqlite3_profiler_slow_test")
+	run("sqlite3_profiler_all_test")
+
+	if len(slow) != 0 {
+		t.Errorf("threshold not honored: %v", slow)
+	}
+
+	if len(all) < 2 {
+		t.Fatalf("got %d profiled statements, want at least 2", len(all))
+	}
+
+	var sawExpanded bool
+	for _, p := range all {
+		if strings.Contains(p.sql, "insert into t values (42)") {
+			sawExpanded = true
+		}
+	}
+	if !sawExpanded {
+		t.Errorf("no profiled statement carried the expanded insert, got %v", all)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// TempStore designates where temporary tables, indices and transient files
+// are stored; see https://www.sqlite.org/pragma.html#pragma_temp_store.
+type TempStore int32
+
+const (
+	TempStoreDefault TempStore = iota // the compile-time default
+	TempStoreFile                     // temporary files on disk
+	TempStoreMemory                   // in memory
+)
+
+// setPragmaInt64 assigns v to the named pragma and returns the value the
+// pragma reports afterwards, which may differ when SQLite caps or rounds the
+// request.
+func (c *conn) setPragmaInt64(name string, v int64) (int64, error) {
+	if _, err := c.exec(context.Background(), fmt.Sprintf("pragma %s=%d", name, v), nil); err != nil {
+		return 0, err
+	}
+
+	s, err := c.pragmaValue(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// SetMmapSize sets the maximum number of bytes of the database file this
+// connection accesses through memory-mapped I/O, wrapping PRAGMA mmap_size.
+// It returns the effective limit, which may be lower than requested when
+// capped by the SQLITE_MAX_MMAP_SIZE compile-time option; zero disables
+// memory-mapped I/O.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) SetMmapSize(n int64) (int64, error) {
+	return c.setPragmaInt64("mmap_size", n)
+}
+
+// SetCacheSize sets the suggested size of this connection's page cache,
+// wrapping PRAGMA cache_size. A positive n is a number of pages, a negative n
+// requests abs(n) KiB regardless of page size. It fails when the pragma does
+// not take effect.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) SetCacheSize(n int) error {
+	got, err := c.setPragmaInt64("cache_size", int64(n))
+	if err != nil {
+		return err
+	}
+
+	if got != int64(n) {
+		return fmt.Errorf("cache_size: requested %d, effective %d", n, got)
+	}
+
+	return nil
+}
+
+// SetCacheSpill enables or disables spilling dirty cache pages to the
+// database file mid-transaction, wrapping PRAGMA cache_spill. Disabling it
+// keeps a write transaction from taking an exclusive lock before commit at
+// the cost of higher memory use.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) SetCacheSpill(enable bool) error {
+	v := "off"
+	if enable {
+		v = "on"
+	}
+	if _, err := c.exec(context.Background(), "pragma cache_spill="+v, nil); err != nil {
+		return err
+	}
+
+	// Reading the pragma back yields the spill threshold in pages, zero when
+	// spilling is disabled.
+	s, err := c.pragmaValue("cache_spill")
+	if err != nil {
+		return err
+	}
+
+	got, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if (got != 0) != enable {
+		return fmt.Errorf("cache_spill: requested %v, effective threshold %d", enable, got)
+	}
+
+	return nil
+}
+
+// SetTempStore sets where this connection keeps temporary tables, indices
+// and transient files, wrapping PRAGMA temp_store. Existing temporary data is
+// deleted when the setting changes.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) SetTempStore(s TempStore) error {
+	switch s {
+	case TempStoreDefault, TempStoreFile, TempStoreMemory:
+		// ok
+	default:
+		return fmt.Errorf("invalid temp_store value %d", s)
+	}
+
+	got, err := c.setPragmaInt64("temp_store", int64(s))
+	if err != nil {
+		return err
+	}
+
+	if got != int64(s) {
+		return fmt.Errorf("temp_store: requested %d, effective %d", s, got)
+	}
+
+	return nil
+}
+
+// SetJournalSizeLimit limits the size of the rollback journal or WAL file
+// left behind after a transaction or checkpoint, wrapping PRAGMA
+// journal_size_limit. It returns the effective limit; n < 0 removes the
+// limit and zero truncates the journal to the minimum whenever possible.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) SetJournalSizeLimit(n int64) (int64, error) {
+	return c.setPragmaInt64("journal_size_limit", n)
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnTuning(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "tuning.db")
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	type tuner interface {
+		SetMmapSize(n int64) (int64, error)
+		SetCacheSize(n int) error
+		SetCacheSpill(enable bool) error
+		SetTempStore(s TempStore) error
+		SetJournalSizeLimit(n int64) (int64, error)
+	}
+
+	if err := cn.Raw(func(driverConn any) error {
+		tc := driverConn.(tuner)
+
+		eff, err := tc.SetMmapSize(1 << 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if eff < 0 || eff > 1<<20 {
+			t.Fatalf("mmap_size = %d, want in [0, %d]", eff, 1<<20)
+		}
+
+		if err := tc.SetCacheSize(-4000); err != nil { // 4000 KiB
+			t.Fatal(err)
+		}
+
+		if err := tc.SetCacheSpill(false); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tc.SetCacheSpill(true); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tc.SetTempStore(TempStoreMemory); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tc.SetTempStore(TempStore(7)); err == nil {
+			t.Fatal("expected error for invalid temp_store value")
+		}
+
+		limit, err := tc.SetJournalSizeLimit(1 << 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if limit != 1<<16 {
+			t.Fatalf("journal_size_limit = %d, want %d", limit, 1<<16)
+		}
+
+		if limit, err = tc.SetJournalSizeLimit(-1); err != nil {
+			t.Fatal(err)
+		}
+
+		if limit != -1 {
+			t.Fatalf("journal_size_limit = %d, want -1", limit)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection still works after tuning.
+	var n int
+	if err := cn.QueryRowContext(context.Background(), "select 1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,7 +6,9 @@ package sqlite // import "github.com/syralon/sqlite"
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
+	"fmt"
 
 	sqlite3 "github.com/syralon/sqlite/lib"
 	"modernc.org/libc"
@@ -16,15 +18,33 @@ type tx struct {
 	c *conn
 }
 
+// newTx starts a transaction, honoring sql.TxOptions. The isolation level
+// picks the BEGIN variant: LevelDefault uses the _txlock DSN setting (plain
+// BEGIN when unset), LevelSerializable forces BEGIN IMMEDIATE so the write
+// lock is taken upfront instead of on the first write, and LevelLinearizable
+// forces BEGIN EXCLUSIVE. Read-only transactions always use a plain deferred
+// BEGIN. SQLite supports no weaker isolation; other levels are rejected.
 func newTx(ctx context.Context, c *conn, opts driver.TxOptions) (*tx, error) {
 	r := &tx{c: c}
 
-	sql := "begin"
-	if !opts.ReadOnly && c.beginMode != "" {
-		sql = "begin " + c.beginMode
+	mode := c.beginMode
+	switch level := sql.IsolationLevel(opts.Isolation); level {
+	case sql.LevelDefault:
+		// nop
+	case sql.LevelSerializable:
+		mode = "immediate"
+	case sql.LevelLinearizable:
+		mode = "exclusive"
+	default:
+		return nil, fmt.Errorf("unsupported isolation level: %v", level)
 	}
 
-	if err := r.exec(ctx, sql); err != nil {
+	query := "begin"
+	if !opts.ReadOnly && mode != "" {
+		query = "begin " + mode
+	}
+
+	if err := r.exec(ctx, query); err != nil {
 		return nil, err
 	}
 
@@ -14,7 +14,7 @@ import (
 	"unsafe"
 
 	"modernc.org/libc"
-	sqlite3 "modernc.org/sqlite/lib"
+	sqlite3 "github.com/syralon/sqlite/lib"
 )
 
 var (
@@ -5,8 +5,11 @@
 package sqlite // import "github.com/syralon/sqlite"
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"unsafe"
 
@@ -17,6 +20,7 @@ import (
 
 func init() {
 	vtab.SetRegisterFunc(registerModule)
+	vtab.SetUnregisterFunc(unregisterModule)
 }
 
 var (
@@ -73,6 +77,97 @@ type goModule struct {
 type goTable struct {
 	mod  *goModule
 	impl vtab.Table
+	// db is the owning connection, needed for per-statement queries such as
+	// sqlite3_vtab_on_conflict during xUpdate.
+	db uintptr
+
+	// Batch write buffering, used when impl implements vtab.BatchUpdater.
+	// Buffered rows are flushed at xSync/xCommit and discarded at xRollback.
+	batchMu    sync.Mutex
+	pendingIns []vtab.Row
+	pendingDel []int64
+	provRowid  int64 // last provisional (negative) rowid handed out
+
+	// overloads caches the vtabOverloads ids handed out by xFindFunction,
+	// keyed by function name and arity, so repeated statement preparations
+	// reuse one registration. Released at xDisconnect/xDestroy.
+	overloadMu sync.Mutex
+	overloads  map[string]uintptr
+}
+
+// releaseOverloads returns the table's xFindFunction registrations to the
+// registry.
+func (gt *goTable) releaseOverloads() {
+	gt.overloadMu.Lock()
+	defer gt.overloadMu.Unlock()
+
+	vtabOverloads.mu.Lock()
+	for _, id := range gt.overloads {
+		delete(vtabOverloads.m, id)
+		vtabOverloads.ids.reclaim(id)
+	}
+	vtabOverloads.mu.Unlock()
+	gt.overloads = nil
+}
+
+// vtabOverloads maps the user-data ids handed to SQLite in xFindFunction to
+// the Go implementations of overloaded table functions.
+var vtabOverloads = struct {
+	mu  sync.RWMutex
+	m   map[uintptr]vtab.TableFunction
+	ids idGen
+}{m: make(map[uintptr]vtab.TableFunction)}
+
+// bufferDelete queues a delete for a BatchUpdater table.
+func (gt *goTable) bufferDelete(rowid int64) {
+	gt.batchMu.Lock()
+	gt.pendingDel = append(gt.pendingDel, rowid)
+	gt.batchMu.Unlock()
+}
+
+// bufferInsert queues an insert for a BatchUpdater table. If rowid is zero a
+// provisional negative rowid is synthesized and returned.
+func (gt *goTable) bufferInsert(rowid int64, cols []vtab.Value) int64 {
+	gt.batchMu.Lock()
+	if rowid == 0 {
+		gt.provRowid--
+		rowid = gt.provRowid
+	}
+	gt.pendingIns = append(gt.pendingIns, vtab.Row{Rowid: rowid, Cols: cols})
+	gt.batchMu.Unlock()
+	return rowid
+}
+
+// flushBatch delivers buffered deletes and inserts to the BatchUpdater, in
+// that order. It is a no-op for tables that do not batch or have nothing
+// pending.
+func (gt *goTable) flushBatch() error {
+	bu, ok := gt.impl.(vtab.BatchUpdater)
+	if !ok {
+		return nil
+	}
+	gt.batchMu.Lock()
+	dels, ins := gt.pendingDel, gt.pendingIns
+	gt.pendingDel, gt.pendingIns = nil, nil
+	gt.batchMu.Unlock()
+	if len(dels) > 0 {
+		if err := bu.DeleteBatch(dels); err != nil {
+			return err
+		}
+	}
+	if len(ins) > 0 {
+		if err := bu.InsertBatch(ins); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardBatch drops buffered writes without delivering them.
+func (gt *goTable) discardBatch() {
+	gt.batchMu.Lock()
+	gt.pendingDel, gt.pendingIns = nil, nil
+	gt.batchMu.Unlock()
 }
 
 // goCursor wraps a vtab.Cursor implementation and remembers its table.
@@ -81,6 +176,12 @@ type goCursor struct {
 	impl  vtab.Cursor
 }
 
+// vtabIdxStrColUsedSep separates the module's own IdxStr from the hex
+// encoded ColUsed mask the bridge appends in xBestIndex and strips again in
+// xFilter. The unit separator is vanishingly unlikely in a real idxStr; the
+// hex suffix must also parse for the split to happen.
+const vtabIdxStrColUsedSep = "\x1f"
+
 // Use aliases of the underlying lib types so field layouts remain correct.
 type cIndexConstraint = sqlite3.Tsqlite3_index_constraint
 type cIndexOrderBy = sqlite3.Tsqlite3_index_orderby
@@ -95,6 +196,18 @@ func registerModule(name string, m vtab.Module) error {
 	return nil
 }
 
+// unregisterModule is installed as the hook for vtab.UnregisterModule. It
+// removes the module from the driver-global registry so new connections no
+// longer install it; per-name trampoline state is kept so virtual tables on
+// already-open connections keep working.
+func unregisterModule(name string) error {
+	if _, exists := d.modules[name]; !exists {
+		return fmt.Errorf("sqlite: module %q not registered", name)
+	}
+	delete(d.modules, name)
+	return nil
+}
+
 // registerModules installs all globally registered vtab modules on this
 // connection by calling sqlite3_create_module_v2 for each one.
 func (c *conn) registerModules() error {
@@ -123,11 +236,22 @@ func (c *conn) registerSingleModule(name string, m vtab.Module) error {
 	if existing, exists := nativeModules.m[name]; exists {
 		mod = existing
 	} else {
-		// Build a sqlite3_module descriptor with trampolines.
+		// Build a sqlite3_module descriptor with trampolines. Version 2 is
+		// required for SQLite to invoke the xSavepoint/xRelease/xRollbackTo
+		// callbacks, version 3 for xShadowName.
 		mod = &sqlite3.Sqlite3_module{}
-		mod.FiVersion = 1
-		mod.FxCreate = cFuncPointer(vtabCreateTrampoline)
+		mod.FiVersion = 3
 		mod.FxConnect = cFuncPointer(vtabConnectTrampoline)
+		// Eponymous modules need xCreate == xConnect, eponymous-only ones a
+		// nil xCreate; SQLite keys the behavior on those pointer values.
+		switch m.(type) {
+		case vtab.EponymousOnlyModule:
+			// leave FxCreate zero
+		case vtab.EponymousModule:
+			mod.FxCreate = mod.FxConnect
+		default:
+			mod.FxCreate = cFuncPointer(vtabCreateTrampoline)
+		}
 		mod.FxBestIndex = cFuncPointer(vtabBestIndexTrampoline)
 		mod.FxDisconnect = cFuncPointer(vtabDisconnectTrampoline)
 		mod.FxDestroy = cFuncPointer(vtabDestroyTrampoline)
@@ -148,6 +272,9 @@ func (c *conn) registerSingleModule(name string, m vtab.Module) error {
 		mod.FxSavepoint = cFuncPointer(vtabSavepointTrampoline)
 		mod.FxRelease = cFuncPointer(vtabReleaseTrampoline)
 		mod.FxRollbackTo = cFuncPointer(vtabRollbackToTrampoline)
+		if _, ok := m.(vtab.ShadowNamer); ok {
+			mod.FxShadowName = cFuncPointer(vtabShadowNameTrampoline)
+		}
 
 		nativeModules.m[name] = mod
 	}
@@ -166,6 +293,24 @@ func (c *conn) registerSingleModule(name string, m vtab.Module) error {
 	return nil
 }
 
+// DropModule removes a virtual table module from this connection by calling
+// sqlite3_create_module with a nil module pointer. It does not touch the
+// driver-global registry; combine it with vtab.UnregisterModule to also stop
+// the module from being installed on new connections.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) DropModule(name string) error {
+	zName, err := libc.CString(name)
+	if err != nil {
+		return err
+	}
+	defer libc.Xfree(c.tls, zName)
+	if rc := sqlite3.Xsqlite3_create_module_v2(c.tls, c.db, zName, 0, 0, 0); rc != sqlite3.SQLITE_OK {
+		return fmt.Errorf("drop module %q: %w", name, c.errstr(rc))
+	}
+	return nil
+}
+
 func vtabConfig(tls *libc.TLS, db uintptr, op int32, args ...int32) error {
 	var va uintptr
 	if len(args) > 1 {
@@ -229,7 +374,7 @@ func vtabCreateTrampoline(tls *libc.TLS, db uintptr, pAux uintptr, argc int32, a
 	}
 	*(*uintptr)(unsafe.Pointer(ppVtab)) = p
 
-	gt := &goTable{mod: gm, impl: tbl}
+	gt := &goTable{mod: gm, impl: tbl, db: db}
 	vtabTables.mu.Lock()
 	vtabTables.m[p] = gt
 	vtabTables.mu.Unlock()
@@ -277,7 +422,7 @@ func vtabConnectTrampoline(tls *libc.TLS, db uintptr, pAux uintptr, argc int32,
 	}
 	*(*uintptr)(unsafe.Pointer(ppVtab)) = p
 
-	gt := &goTable{mod: gm, impl: tbl}
+	gt := &goTable{mod: gm, impl: tbl, db: db}
 	vtabTables.mu.Lock()
 	vtabTables.m[p] = gt
 	vtabTables.mu.Unlock()
@@ -343,13 +488,27 @@ func vtabBestIndexTrampoline(tls *libc.TLS, pVtab uintptr, pInfo uintptr) int32
 			case sqlite3.SQLITE_INDEX_CONSTRAINT_OFFSET:
 				op = vtab.OpOFFSET
 			}
-			cs = append(cs, vtab.Constraint{
+			gc := vtab.Constraint{
 				Column:   int(c.FiColumn),
 				Op:       op,
 				Usable:   c.Fusable != 0,
 				ArgIndex: -1, // 0-based; -1 means ignore
 				Omit:     false,
-			})
+				// A third argument of -1 only queries IN capability.
+				InCapable: sqlite3.Xsqlite3_vtab_in(tls, pInfo, int32(i), -1) != 0,
+			}
+			if z := sqlite3.Xsqlite3_vtab_collation(tls, pInfo, int32(i)); z != 0 {
+				gc.Collation = libc.GoString(z)
+			}
+			// Surface literal right-hand sides so BestIndex can plan on the
+			// actual value; sqlite3_vtab_rhs_value returns SQLITE_NOTFOUND
+			// for RHS expressions not known until run time.
+			var pVal uintptr
+			if sqlite3.Xsqlite3_vtab_rhs_value(tls, pInfo, int32(i), uintptr(unsafe.Pointer(&pVal))) == sqlite3.SQLITE_OK && pVal != 0 {
+				gc.RHSKnown = true
+				gc.RHSValue = functionArgs(tls, 1, uintptr(unsafe.Pointer(&pVal)))[0]
+			}
+			cs = append(cs, gc)
 		}
 		info.Constraints = cs
 	}
@@ -378,14 +537,22 @@ func vtabBestIndexTrampoline(tls *libc.TLS, pVtab uintptr, pInfo uintptr) int32
 		info.IdxFlags = int(idx.FidxFlags)
 	}
 
+	info.Distinct = int(sqlite3.Xsqlite3_vtab_distinct(tls, pInfo))
+
+	// Seed the planner's default cost estimates so BestIndex can read them
+	// and scale its own estimates relative to a full scan.
+	info.EstimatedCost = idx.FestimatedCost
+	info.EstimatedRows = int64(idx.FestimatedRows)
+
 	if err := gt.impl.BestIndex(info); err != nil {
 		// Report error via zErrMsg on pVtab.
 		setVtabZErrMsg(tls, pVtab, err.Error())
-		return sqlite3.SQLITE_ERROR
+		return vtabErrorCode(err)
 	}
 
 	// Propagate any ArgIndex assignments back into aConstraintUsage so that
 	// SQLite will populate xFilter's argv[] accordingly.
+	var inMask uint64
 	if idx.FnConstraint > 0 && idx.FaConstraintUsage != 0 && len(info.Constraints) > 0 {
 		n := int(idx.FnConstraint)
 		base := idx.FaConstraintUsage
@@ -400,6 +567,12 @@ func vtabBestIndexTrampoline(tls *libc.TLS, pVtab uintptr, pInfo uintptr) int32
 			if info.Constraints[i].Omit {
 				cu.Fomit = 1
 			}
+			// Honor ProcessIn: ask SQLite to hand the whole IN list to
+			// xFilter in one call and note which argv slot carries it.
+			if info.Constraints[i].ProcessIn && argIndex >= 0 && argIndex < 64 &&
+				sqlite3.Xsqlite3_vtab_in(tls, pInfo, int32(i), 1) != 0 {
+				inMask |= 1 << uint(argIndex)
+			}
 		}
 	}
 	// Guard against int32 overflow: SQLite expects idxNum as int32.
@@ -408,14 +581,18 @@ func vtabBestIndexTrampoline(tls *libc.TLS, pVtab uintptr, pInfo uintptr) int32
 		return sqlite3.SQLITE_ERROR
 	}
 	idx.FidxNum = int32(info.IdxNum)
-	if info.IdxStr != "" {
-		// Allocate using SQLite allocator because needToFreeIdxStr=1 instructs
-		// SQLite to free the string with sqlite3_free.
-		z := sqlite3AllocCString(tls, info.IdxStr)
-		if z != 0 {
-			idx.FidxStr = z
-			idx.FneedToFreeIdxStr = 1
-		}
+	// Smuggle the plan's ColUsed mask and the IN-list argv mask through
+	// idxStr so xFilter can hand the former to cursors implementing
+	// vtab.ColumnUser and expand the flagged argv slots into vtab.InValues.
+	// The suffix is stripped again in vtabFilterTrampoline before the module
+	// sees the string.
+	idxStr := info.IdxStr + vtabIdxStrColUsedSep + strconv.FormatUint(info.ColUsed, 16) +
+		"," + strconv.FormatUint(inMask, 16)
+	// Allocate using SQLite allocator because needToFreeIdxStr=1 instructs
+	// SQLite to free the string with sqlite3_free.
+	if z := sqlite3AllocCString(tls, idxStr); z != 0 {
+		idx.FidxStr = z
+		idx.FneedToFreeIdxStr = 1
 	}
 	if info.OrderByConsumed {
 		idx.ForderByConsumed = 1
@@ -423,12 +600,10 @@ func vtabBestIndexTrampoline(tls *libc.TLS, pVtab uintptr, pInfo uintptr) int32
 	if info.IdxFlags != 0 {
 		idx.FidxFlags = int32(info.IdxFlags)
 	}
-	if info.EstimatedCost != 0 {
-		idx.FestimatedCost = info.EstimatedCost
-	}
-	if info.EstimatedRows != 0 {
-		idx.FestimatedRows = sqlite3.Sqlite3_int64(info.EstimatedRows)
-	}
+	// The estimates were seeded from the planner defaults before BestIndex
+	// ran, so writing them back unconditionally preserves untouched values.
+	idx.FestimatedCost = info.EstimatedCost
+	idx.FestimatedRows = sqlite3.Sqlite3_int64(info.EstimatedRows)
 	return sqlite3.SQLITE_OK
 }
 
@@ -440,6 +615,7 @@ func vtabDisconnectTrampoline(tls *libc.TLS, pVtab uintptr) int32 {
 	vtabTables.mu.RUnlock()
 	if gt != nil {
 		_ = gt.impl.Disconnect()
+		gt.releaseOverloads()
 		vtabTables.mu.Lock()
 		delete(vtabTables.m, pVtab)
 		vtabTables.mu.Unlock()
@@ -455,6 +631,7 @@ func vtabDestroyTrampoline(tls *libc.TLS, pVtab uintptr) int32 {
 	vtabTables.mu.RUnlock()
 	if gt != nil {
 		_ = gt.impl.Destroy()
+		gt.releaseOverloads()
 		vtabTables.mu.Lock()
 		delete(vtabTables.m, pVtab)
 		vtabTables.mu.Unlock()
@@ -529,7 +706,49 @@ func vtabFilterTrampoline(tls *libc.TLS, pCursor uintptr, idxNum int32, idxStr u
 	if idxStr != 0 {
 		idxStrGo = libc.GoString(idxStr)
 	}
+	// Strip the ColUsed/IN-mask suffix appended in vtabBestIndexTrampoline,
+	// pass the column mask to the cursor if it wants it and remember which
+	// argv slots carry whole IN lists.
+	var inMask uint64
+	if i := strings.LastIndex(idxStrGo, vtabIdxStrColUsedSep); i >= 0 {
+		suffix, inHex, _ := strings.Cut(idxStrGo[i+len(vtabIdxStrColUsedSep):], ",")
+		if mask, err := strconv.ParseUint(suffix, 16, 64); err == nil {
+			idxStrGo = idxStrGo[:i]
+			if cu, ok := gc.impl.(vtab.ColumnUser); ok {
+				cu.SetColumnsUsed(mask)
+			}
+			inMask, _ = strconv.ParseUint(inHex, 16, 64)
+		}
+	}
 	vals := functionArgs(tls, argc, argv)
+	// Expand argv slots flagged as batched IN lists into vtab.InValues by
+	// walking the list with sqlite3_vtab_in_first/in_next.
+	for i := 0; i < int(argc) && inMask != 0; i++ {
+		if inMask>>uint(i)&1 == 0 {
+			continue
+		}
+		pList := *(*uintptr)(unsafe.Pointer(argv + uintptr(i)*ptrSize))
+		list, err := vtabInList(tls, pList)
+		if err != nil {
+			cur := (*sqlite3.Sqlite3_vtab_cursor)(unsafe.Pointer(pCursor))
+			if cur.FpVtab != 0 {
+				setVtabZErrMsg(tls, cur.FpVtab, err.Error())
+			}
+			return sqlite3.SQLITE_ERROR
+		}
+		vals[i] = list
+	}
+	// Arguments bound through the pointer-passing interface convert to nil
+	// above; hand the module the original Go value instead.
+	for i := 0; i < int(argc); i++ {
+		if vals[i] != nil {
+			continue
+		}
+		pVal := *(*uintptr)(unsafe.Pointer(argv + uintptr(i)*ptrSize))
+		if pv, ok := pointerFromValue(tls, pVal); ok {
+			vals[i] = pv
+		}
+	}
 	if err := gc.impl.Filter(int(idxNum), idxStrGo, vals); err != nil {
 		// Set zErrMsg on the associated vtab for better diagnostics.
 		if pCursor != 0 {
@@ -538,11 +757,28 @@ func vtabFilterTrampoline(tls *libc.TLS, pCursor uintptr, idxNum int32, idxStr u
 				setVtabZErrMsg(tls, cur.FpVtab, err.Error())
 			}
 		}
-		return sqlite3.SQLITE_ERROR
+		return vtabErrorCode(err)
 	}
 	return sqlite3.SQLITE_OK
 }
 
+// vtabInList walks an IN-list value handed to xFilter for a ProcessIn
+// constraint and converts every element to a Go value.
+func vtabInList(tls *libc.TLS, pList uintptr) (vtab.InValues, error) {
+	list := vtab.InValues{}
+	var pVal uintptr
+	pOut := uintptr(unsafe.Pointer(&pVal))
+	for rc := sqlite3.Xsqlite3_vtab_in_first(tls, pList, pOut); ; rc = sqlite3.Xsqlite3_vtab_in_next(tls, pList, pOut) {
+		if rc == sqlite3.SQLITE_DONE {
+			return list, nil
+		}
+		if rc != sqlite3.SQLITE_OK || pVal == 0 {
+			return nil, fmt.Errorf("vtab: reading IN list: rc=%d", rc)
+		}
+		list = append(list, functionArgs(tls, 1, uintptr(unsafe.Pointer(&pVal)))[0])
+	}
+}
+
 // vtabNextTrampoline is xNext.
 func vtabNextTrampoline(tls *libc.TLS, pCursor uintptr) int32 {
 	_ = tls
@@ -579,18 +815,27 @@ func vtabColumnTrampoline(tls *libc.TLS, pCursor uintptr, ctx uintptr, iCol int3
 		sqlite3.Xsqlite3_result_null(tls, ctx)
 		return sqlite3.SQLITE_OK
 	}
+	if _, partial := gc.table.impl.(vtab.PartialUpdater); partial {
+		// SQLite sets the nochange flag when it only needs the value to carry
+		// it through an UPDATE. Returning without a result marks the column as
+		// unchanged, which is what feeds the PartialUpdater changed mask.
+		if sqlite3.Xsqlite3_vtab_nochange(tls, ctx) != 0 {
+			return sqlite3.SQLITE_OK
+		}
+	}
 	val, err := gc.impl.Column(int(iCol))
 	if err != nil {
 		// Report via result error on the context.
+		code := vtabErrorCode(err)
 		z, cerr := libc.CString(err.Error())
 		if cerr == nil {
 			defer libc.Xfree(tls, z)
 			sqlite3.Xsqlite3_result_error(tls, ctx, z, -1)
-			sqlite3.Xsqlite3_result_error_code(tls, ctx, sqlite3.SQLITE_ERROR)
+			sqlite3.Xsqlite3_result_error_code(tls, ctx, code)
 		} else {
-			sqlite3.Xsqlite3_result_error_code(tls, ctx, sqlite3.SQLITE_ERROR)
+			sqlite3.Xsqlite3_result_error_code(tls, ctx, code)
 		}
-		return sqlite3.SQLITE_ERROR
+		return code
 	}
 	if err := functionReturnValue(tls, ctx, val); err != nil {
 		// Include a descriptive error message for easier debugging
@@ -671,22 +916,91 @@ func setVtabZErrMsg(tls *libc.TLS, pVtab uintptr, msg string) {
 	vt.FzErrMsg = z
 }
 
+// vtabErrorCode maps an error returned by a vtab method to the result code
+// reported to SQLite: the code carried by a wrapped *vtab.Error, or the
+// generic SQLITE_ERROR.
+func vtabErrorCode(err error) int32 {
+	var ve *vtab.Error
+	if errors.As(err, &ve) && ve.Code != 0 {
+		return int32(ve.Code)
+	}
+	return sqlite3.SQLITE_ERROR
+}
+
 // Optional vtab callbacks
 
-// vtabFindFunctionTrampoline is xFindFunction. We currently do not expose a
-// Go surface for per-table SQL functions; report not found (return 0).
+// vtabFindFunctionTrampoline is xFindFunction. Tables implementing
+// vtab.FunctionOverloader can overload SQL functions — including the
+// function form of MATCH — applied to their columns; the Go implementation
+// is registered in vtabOverloads and its id passed to SQLite as the function
+// user data.
 func vtabFindFunctionTrampoline(tls *libc.TLS, pVtab uintptr, nArg int32, zName uintptr, pxFunc uintptr, ppArg uintptr) int32 {
-	_ = tls
-	_ = pVtab
-	_ = nArg
-	_ = zName
+	vtabTables.mu.RLock()
+	gt := vtabTables.m[pVtab]
+	vtabTables.mu.RUnlock()
+	if gt == nil {
+		return 0
+	}
+
+	fo, ok := gt.impl.(interface {
+		FindFunction(name string, nArg int) (vtab.TableFunction, int)
+	})
+	if !ok {
+		return 0
+	}
+
+	name := libc.GoString(zName)
+	f, opcode := fo.FindFunction(name, int(nArg))
+	if f == nil || opcode <= 0 {
+		return 0
+	}
+
+	key := fmt.Sprintf("%s/%d", name, nArg)
+	gt.overloadMu.Lock()
+	id, ok := gt.overloads[key]
+	vtabOverloads.mu.Lock()
+	if !ok {
+		id = vtabOverloads.ids.next()
+		if gt.overloads == nil {
+			gt.overloads = make(map[string]uintptr)
+		}
+		gt.overloads[key] = id
+	}
+	vtabOverloads.m[id] = f
+	vtabOverloads.mu.Unlock()
+	gt.overloadMu.Unlock()
+
 	if pxFunc != 0 {
-		*(*uintptr)(unsafe.Pointer(pxFunc)) = 0
+		*(*uintptr)(unsafe.Pointer(pxFunc)) = cFuncPointer(vtabOverloadedFuncTrampoline)
 	}
 	if ppArg != 0 {
-		*(*uintptr)(unsafe.Pointer(ppArg)) = 0
+		*(*uintptr)(unsafe.Pointer(ppArg)) = id
+	}
+	return int32(opcode)
+}
+
+// vtabOverloadedFuncTrampoline invokes a function installed by
+// vtabFindFunctionTrampoline; the function user data is the vtabOverloads
+// id.
+func vtabOverloadedFuncTrampoline(tls *libc.TLS, ctx uintptr, argc int32, argv uintptr) {
+	setErrorResult := errorResultFunction(tls, ctx)
+	id := sqlite3.Xsqlite3_user_data(tls, ctx)
+	vtabOverloads.mu.RLock()
+	f := vtabOverloads.m[id]
+	vtabOverloads.mu.RUnlock()
+	if f == nil {
+		setErrorResult(fmt.Errorf("overloaded table function not found"))
+		return
+	}
+
+	res, err := f(functionArgs(tls, argc, argv))
+	if err != nil {
+		setErrorResult(err)
+		return
+	}
+	if err := functionReturnValue(tls, ctx, res); err != nil {
+		setErrorResult(err)
 	}
-	return 0 // not found
 }
 
 // vtabRenameTrampoline is xRename. Calls Table.Rename if implemented.
@@ -707,6 +1021,22 @@ func vtabRenameTrampoline(tls *libc.TLS, pVtab uintptr, zNew uintptr) int32 {
 	return sqlite3.SQLITE_OK
 }
 
+// vtabShadowNameTrampoline is xShadowName. SQLite passes only the suffix of
+// the candidate table name, with no module or table context, so the suffix
+// is offered to every registered module that implements vtab.ShadowNamer and
+// accepted if any of them claims it.
+func vtabShadowNameTrampoline(tls *libc.TLS, zName uintptr) int32 {
+	suffix := libc.GoString(zName)
+	vtabModules.mu.RLock()
+	defer vtabModules.mu.RUnlock()
+	for _, gm := range vtabModules.m {
+		if sn, ok := gm.impl.(vtab.ShadowNamer); ok && sn.ShadowName(suffix) {
+			return 1
+		}
+	}
+	return 0
+}
+
 // vtabUpdateTrampoline is xUpdate. Not supported by default; report read-only.
 func vtabUpdateTrampoline(tls *libc.TLS, pVtab uintptr, argc int32, argv uintptr, pRowid uintptr) int32 {
 	vtabTables.mu.RLock()
@@ -720,10 +1050,20 @@ func vtabUpdateTrampoline(tls *libc.TLS, pVtab uintptr, argc int32, argv uintptr
 		Update(oldRowid int64, cols []vtab.Value, newRowid *int64) error
 		Delete(oldRowid int64) error
 	})
-	if !ok {
+	_, isBatch := gt.impl.(vtab.BatchUpdater)
+	if !ok && !isBatch {
 		return sqlite3.SQLITE_READONLY
 	}
 
+	// Tell interested tables how a constraint violation should be resolved.
+	// sqlite3_vtab_on_conflict only answers during xUpdate, so the mode is
+	// pushed rather than pulled.
+	if ch, ok := gt.impl.(interface {
+		SetConflictMode(mode vtab.ConflictMode)
+	}); ok {
+		ch.SetConflictMode(vtab.ConflictMode(sqlite3.Xsqlite3_vtab_on_conflict(tls, gt.db)))
+	}
+
 	// DELETE: argc == 1; argv[0]=oldRowid
 	if argc == 1 {
 		valPtr := *(*uintptr)(unsafe.Pointer(argv))
@@ -731,26 +1071,31 @@ func vtabUpdateTrampoline(tls *libc.TLS, pVtab uintptr, argc int32, argv uintptr
 		if sqlite3.Xsqlite3_value_type(tls, valPtr) != sqlite3.SQLITE_NULL {
 			oldRowid = int64(sqlite3.Xsqlite3_value_int64(tls, valPtr))
 		}
+		if isBatch {
+			gt.bufferDelete(oldRowid)
+			return sqlite3.SQLITE_OK
+		}
 		if err := upd.Delete(oldRowid); err != nil {
 			setVtabZErrMsg(tls, pVtab, err.Error())
-			return sqlite3.SQLITE_ERROR
+			return vtabErrorCode(err)
 		}
 		return sqlite3.SQLITE_OK
 	}
 
 	// INSERT or UPDATE: argc == N+2. argv[0]=oldRowid (NULL for insert),
-	// argv[1..N]=column values, argv[N+1]=newRowid (or desired rowid for insert, may be NULL).
+	// argv[1]=newRowid (or desired rowid for insert, may be NULL),
+	// argv[2..N+1]=column values.
 	if argc < 3 {
 		return sqlite3.SQLITE_MISUSE
 	}
 	nCols := argc - 2
 	// Extract column values
-	colsPtr := argv + uintptr(1)*sqliteValPtrSize
+	colsPtr := argv + uintptr(2)*sqliteValPtrSize
 	cols := functionArgs(tls, nCols, colsPtr)
 
 	// Determine old/new rowid
 	oldPtr := *(*uintptr)(unsafe.Pointer(argv + uintptr(0)*sqliteValPtrSize))
-	newPtr := *(*uintptr)(unsafe.Pointer(argv + uintptr(argc-1)*sqliteValPtrSize))
+	newPtr := *(*uintptr)(unsafe.Pointer(argv + uintptr(1)*sqliteValPtrSize))
 
 	oldIsNull := sqlite3.Xsqlite3_value_type(tls, oldPtr) == sqlite3.SQLITE_NULL
 	newIsNull := sqlite3.Xsqlite3_value_type(tls, newPtr) == sqlite3.SQLITE_NULL
@@ -761,9 +1106,16 @@ func vtabUpdateTrampoline(tls *libc.TLS, pVtab uintptr, argc int32, argv uintptr
 		if !newIsNull {
 			rid = int64(sqlite3.Xsqlite3_value_int64(tls, newPtr))
 		}
+		if isBatch {
+			rid = gt.bufferInsert(rid, cols)
+			if pRowid != 0 {
+				*(*int64)(unsafe.Pointer(pRowid)) = rid
+			}
+			return sqlite3.SQLITE_OK
+		}
 		if err := upd.Insert(cols, &rid); err != nil {
 			setVtabZErrMsg(tls, pVtab, err.Error())
-			return sqlite3.SQLITE_ERROR
+			return vtabErrorCode(err)
 		}
 		if pRowid != 0 {
 			*(*int64)(unsafe.Pointer(pRowid)) = rid
@@ -777,9 +1129,53 @@ func vtabUpdateTrampoline(tls *libc.TLS, pVtab uintptr, argc int32, argv uintptr
 	if !newIsNull {
 		newRid = int64(sqlite3.Xsqlite3_value_int64(tls, newPtr))
 	}
+	if isBatch && !ok {
+		// Decompose into a buffered delete plus insert; BatchUpdater-only
+		// tables have no row level Update to call.
+		gt.bufferDelete(oldRowid)
+		rid := newRid
+		if rid == 0 {
+			rid = oldRowid
+		}
+		gt.bufferInsert(rid, cols)
+		if pRowid != 0 {
+			*(*int64)(unsafe.Pointer(pRowid)) = rid
+		}
+		return sqlite3.SQLITE_OK
+	}
+	if isBatch {
+		// Keep ordering intact: deliver buffered writes before the direct
+		// Updater.Update call.
+		if err := gt.flushBatch(); err != nil {
+			setVtabZErrMsg(tls, pVtab, err.Error())
+			return sqlite3.SQLITE_ERROR
+		}
+	}
+	if pu, ok := gt.impl.(vtab.PartialUpdater); ok {
+		// Build the changed-column mask from sqlite3_value_nochange: a column
+		// is unchanged when SQLite passes the old value through untouched.
+		// Unchanged slots are normalized to nil — xColumn skipped
+		// materializing them, so whatever they carry is not the column value.
+		changed := make([]bool, nCols)
+		for i := int32(0); i < nCols; i++ {
+			valPtr := *(*uintptr)(unsafe.Pointer(colsPtr + uintptr(i)*sqliteValPtrSize))
+			changed[i] = sqlite3.Xsqlite3_value_nochange(tls, valPtr) == 0
+			if !changed[i] {
+				cols[i] = nil
+			}
+		}
+		if err := pu.UpdatePartial(oldRowid, cols, changed, &newRid); err != nil {
+			setVtabZErrMsg(tls, pVtab, err.Error())
+			return vtabErrorCode(err)
+		}
+		if pRowid != 0 && newRid != 0 {
+			*(*int64)(unsafe.Pointer(pRowid)) = newRid
+		}
+		return sqlite3.SQLITE_OK
+	}
 	if err := upd.Update(oldRowid, cols, &newRid); err != nil {
 		setVtabZErrMsg(tls, pVtab, err.Error())
-		return sqlite3.SQLITE_ERROR
+		return vtabErrorCode(err)
 	}
 	if pRowid != 0 && newRid != 0 {
 		*(*int64)(unsafe.Pointer(pRowid)) = newRid
@@ -811,6 +1207,10 @@ func vtabSyncTrampoline(tls *libc.TLS, pVtab uintptr) int32 {
 	if gt == nil {
 		return sqlite3.SQLITE_ERROR
 	}
+	if err := gt.flushBatch(); err != nil {
+		setVtabZErrMsg(tls, pVtab, err.Error())
+		return sqlite3.SQLITE_ERROR
+	}
 	if tr, ok := gt.impl.(interface{ Sync() error }); ok {
 		if err := tr.Sync(); err != nil {
 			setVtabZErrMsg(tls, pVtab, err.Error())
@@ -827,6 +1227,12 @@ func vtabCommitTrampoline(tls *libc.TLS, pVtab uintptr) int32 {
 	if gt == nil {
 		return sqlite3.SQLITE_ERROR
 	}
+	// Normally flushed at xSync already; this covers any writes buffered
+	// in between.
+	if err := gt.flushBatch(); err != nil {
+		setVtabZErrMsg(tls, pVtab, err.Error())
+		return sqlite3.SQLITE_ERROR
+	}
 	if tr, ok := gt.impl.(interface{ Commit() error }); ok {
 		if err := tr.Commit(); err != nil {
 			setVtabZErrMsg(tls, pVtab, err.Error())
@@ -843,6 +1249,7 @@ func vtabRollbackTrampoline(tls *libc.TLS, pVtab uintptr) int32 {
 	if gt == nil {
 		return sqlite3.SQLITE_ERROR
 	}
+	gt.discardBatch()
 	if tr, ok := gt.impl.(interface{ Rollback() error }); ok {
 		if err := tr.Rollback(); err != nil {
 			setVtabZErrMsg(tls, pVtab, err.Error())
@@ -0,0 +1,170 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package carray provides a carray-style virtual table that scans a Go slice
+// bound into the query through the pointer-passing interface, making huge
+// IN (...) lists efficient without building SQL strings:
+//
+//	CREATE VIRTUAL TABLE ca USING carray;
+//	SELECT x FROM t WHERE x IN (SELECT value FROM ca(?));
+//
+// with the parameter bound to carray.Values([]int64{...}). Slices of int64,
+// float64 and string are supported. Like the C carray extension, the slice
+// is not copied; it must stay unmodified while the query runs.
+package carray // import "github.com/syralon/sqlite/vtab/carray"
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/syralon/sqlite"
+	"github.com/syralon/sqlite/vtab"
+)
+
+// PointerType is the pointer type name carray tables accept, mirroring the
+// "carray" binding of the C extension.
+const PointerType = "carray"
+
+// Values wraps slice for binding as a statement parameter. slice must be a
+// []int64, []float64 or []string.
+func Values(slice any) (sqlite.PointerValue, error) {
+	switch slice.(type) {
+	case []int64, []float64, []string:
+		return sqlite.Pointer(PointerType, slice), nil
+	}
+	return sqlite.PointerValue{}, fmt.Errorf("carray: unsupported slice type %T", slice)
+}
+
+// MustValues is like Values but panics on error.
+func MustValues(slice any) sqlite.PointerValue {
+	pv, err := Values(slice)
+	if err != nil {
+		panic(err)
+	}
+	return pv
+}
+
+// Column indexes of the declared schema.
+const (
+	colValue = iota
+	colPointer
+)
+
+// Module implements vtab.Module for carray tables.
+type Module struct{}
+
+// Register registers a carray module named name. Registration applies to new
+// connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, Module{})
+}
+
+// Create implements vtab.Module.
+func (Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(value, pointer HIDDEN)"); err != nil {
+		return nil, err
+	}
+	return table{}, nil
+}
+
+// Connect implements vtab.Module.
+func (m Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct{}
+
+// BestIndex implements vtab.Table. The only useful plan consumes an equality
+// constraint on the hidden pointer column; without one the table is empty.
+func (table) BestIndex(info *vtab.IndexInfo) error {
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Op != vtab.OpEQ || c.Column != colPointer {
+			continue
+		}
+
+		c.ArgIndex = 0
+		c.Omit = true
+		info.IdxNum = 1
+		info.EstimatedCost = 1
+		break
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (table) Open() (vtab.Cursor, error) { return &cursor{}, nil }
+
+// Disconnect implements vtab.Table.
+func (table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (table) Destroy() error { return nil }
+
+type cursor struct {
+	slice any
+	n     int
+	i     int
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.slice, c.n, c.i = nil, 0, 0
+	if idxNum != 1 {
+		// No pointer bound; the table is empty.
+		return nil
+	}
+
+	pv, ok := vals[0].(sqlite.PointerValue)
+	if !ok || pv.Type != PointerType {
+		return nil
+	}
+
+	switch s := pv.Value.(type) {
+	case []int64:
+		c.n = len(s)
+	case []float64:
+		c.n = len(s)
+	case []string:
+		c.n = len(s)
+	default:
+		return fmt.Errorf("carray: unsupported slice type %T", pv.Value)
+	}
+
+	c.slice = pv.Value
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= c.n }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	if col != colValue {
+		// The hidden pointer column has no meaningful value.
+		return nil, nil
+	}
+
+	switch s := c.slice.(type) {
+	case []int64:
+		return s[c.i], nil
+	case []float64:
+		return s[c.i], nil
+	case []string:
+		return s[c.i], nil
+	}
+	return nil, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return int64(c.i) + 1, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
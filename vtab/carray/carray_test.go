@@ -0,0 +1,94 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package carray // import "github.com/syralon/sqlite/vtab/carray"
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestCArray(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+"/carray.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "carray"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table ca using carray"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Scan a bound []int64 as a table.
+	rows, err := db.Query("select value from ca(?)", MustValues([]int64{3, 1, 4, 1, 5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	var ints []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		ints = append(ints, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int64{3, 1, 4, 1, 5}; !reflect.DeepEqual(ints, want) {
+		t.Fatalf("got %v, want %v", ints, want)
+	}
+
+	// The typical use: a huge IN (...) list without string building.
+	if _, err := db.Exec("create table t(x); insert into t values (1), (2), (3), (4), (5)"); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from t where x in (select value from ca(?))",
+		MustValues([]int64{2, 4, 99})).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("IN list matched %d rows, want 2", n)
+	}
+
+	// String slices.
+	var s string
+	if err := db.QueryRow("select group_concat(value) from ca(?)",
+		MustValues([]string{"a", "b"})).Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s != "a,b" {
+		t.Fatalf("got %q, want %q", s, "a,b")
+	}
+
+	// Without a bound pointer the table is empty.
+	if err := db.QueryRow("select count(*) from ca").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("unbound scan returned %d rows, want 0", n)
+	}
+
+	// Unsupported slice types are rejected at bind time.
+	if _, err := Values([]int{1}); err == nil {
+		t.Fatal("expected an error for []int")
+	}
+}
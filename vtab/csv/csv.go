@@ -0,0 +1,369 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package csv provides a read-only virtual table over a CSV file. Unlike the
+// naive loader in examples/vtab_csv it streams the file per cursor instead of
+// materializing it in memory, detects column type affinity from a sample of
+// the data, handles RFC 4180 quoting including embedded delimiters and
+// newlines, and supports configurable NULL representation:
+//
+//	CREATE VIRTUAL TABLE people USING csv(
+//	    filename='people.csv',  -- required
+//	    delimiter=',',          -- default ','
+//	    header=true,            -- default true; false synthesizes c1..cN
+//	    nullvalue='',           -- cells equal to this scan as NULL; default none
+//	    affinity=true           -- default true; false declares all columns TEXT
+//	);
+//
+// Column types are detected from up to the first 100 data rows: a column
+// whose cells all parse as integers is declared INTEGER, all-numeric columns
+// become REAL, everything else TEXT.
+package csv // import "github.com/syralon/sqlite/vtab/csv"
+
+import (
+	"database/sql"
+	stdcsv "encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// affinitySampleRows is the number of data rows inspected for type affinity
+// detection.
+const affinitySampleRows = 100
+
+// Module implements vtab.Module for CSV tables.
+type Module struct{}
+
+// Register registers a CSV module named name. Registration applies to new
+// connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, Module{})
+}
+
+type options struct {
+	filename  string
+	delimiter rune
+	header    bool
+	nullvalue string
+	hasNull   bool
+	affinity  bool
+}
+
+func parseOptions(args []string) (options, error) {
+	opts := options{delimiter: ',', header: true, affinity: true}
+	for _, a := range args {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "filename":
+			opts.filename = v
+		case "delimiter":
+			if v == `\t` {
+				v = "\t"
+			}
+			r, n := utf8.DecodeRuneInString(v)
+			if n == 0 || r == utf8.RuneError {
+				return opts, fmt.Errorf("csv: invalid delimiter %q", v)
+			}
+			opts.delimiter = r
+		case "header":
+			b, err := parseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("csv: invalid header option %q", v)
+			}
+			opts.header = b
+		case "nullvalue":
+			opts.nullvalue = v
+			opts.hasNull = true
+		case "affinity":
+			b, err := parseBool(v)
+			if err != nil {
+				return opts, fmt.Errorf("csv: invalid affinity option %q", v)
+			}
+			opts.affinity = b
+		default:
+			return opts, fmt.Errorf("csv: unknown option %q", k)
+		}
+	}
+	if opts.filename == "" {
+		return opts, fmt.Errorf("csv: the filename option is required")
+	}
+	return opts, nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("not a boolean: %q", s)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Column affinities, ordered from most to least specific.
+const (
+	affInteger = iota
+	affReal
+	affText
+)
+
+// Create implements vtab.Module.
+func (Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	opts, err := parseOptions(args[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{opts: opts}
+	if err := t.detectSchema(); err != nil {
+		return nil, err
+	}
+
+	decl := make([]string, len(t.cols))
+	for i, col := range t.cols {
+		typ := "TEXT"
+		switch t.affinities[i] {
+		case affInteger:
+			typ = "INTEGER"
+		case affReal:
+			typ = "REAL"
+		}
+		decl[i] = fmt.Sprintf("%q %s", col, typ)
+	}
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(decl, ", "))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Connect implements vtab.Module.
+func (m Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct {
+	opts       options
+	cols       []string
+	affinities []int
+	size       int64 // file size at schema detection time, for cost estimation
+}
+
+func (t *table) newReader(f *os.File) *stdcsv.Reader {
+	r := stdcsv.NewReader(f)
+	r.Comma = t.opts.delimiter
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	return r
+}
+
+// detectSchema reads the header and a sample of rows to fix the column names
+// and, when enabled, their type affinities.
+func (t *table) detectSchema() error {
+	f, err := os.Open(t.opts.filename)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if fi, err := f.Stat(); err == nil {
+		t.size = fi.Size()
+	}
+
+	r := t.newReader(f)
+	first, err := r.Read()
+	if err == io.EOF {
+		return fmt.Errorf("csv: %s is empty", t.opts.filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	if t.opts.header {
+		t.cols = append([]string(nil), first...)
+	} else {
+		t.cols = make([]string, len(first))
+		for i := range t.cols {
+			t.cols[i] = fmt.Sprintf("c%d", i+1)
+		}
+	}
+
+	t.affinities = make([]int, len(t.cols))
+	if !t.opts.affinity {
+		for i := range t.affinities {
+			t.affinities[i] = affText
+		}
+		return nil
+	}
+
+	seen := make([]bool, len(t.cols))
+	sample := func(rec []string) {
+		for i := 0; i < len(rec) && i < len(t.cols); i++ {
+			cell := rec[i]
+			if t.opts.hasNull && cell == t.opts.nullvalue {
+				continue
+			}
+			seen[i] = true
+			if t.affinities[i] == affInteger {
+				if _, err := strconv.ParseInt(cell, 10, 64); err == nil {
+					continue
+				}
+				t.affinities[i] = affReal
+			}
+			if t.affinities[i] == affReal {
+				if _, err := strconv.ParseFloat(cell, 64); err == nil {
+					continue
+				}
+				t.affinities[i] = affText
+			}
+		}
+	}
+
+	if !t.opts.header {
+		sample(first)
+	}
+	for n := 0; n < affinitySampleRows; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		sample(rec)
+	}
+
+	// Columns with no non-NULL sample default to TEXT.
+	for i, ok := range seen {
+		if !ok {
+			t.affinities[i] = affText
+		}
+	}
+	return nil
+}
+
+// BestIndex implements vtab.Table. CSV files only support full scans; the
+// cost scales with the file size so SQLite prefers other tables' indexes
+// when joining.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	info.EstimatedCost = float64(t.size/100 + 1000)
+	info.EstimatedRows = t.size/100 + 25
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+type cursor struct {
+	t     *table
+	f     *os.File
+	r     *stdcsv.Reader
+	row   []string
+	rowid int64
+	eof   bool
+}
+
+// Filter implements vtab.Cursor. Each scan streams the file from the start;
+// rows are never materialized beyond the current record.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(c.t.opts.filename)
+	if err != nil {
+		return err
+	}
+
+	c.f = f
+	c.r = c.t.newReader(f)
+	c.rowid = 0
+	c.eof = false
+	if c.t.opts.header {
+		if _, err := c.r.Read(); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	return c.Next()
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	rec, err := c.r.Read()
+	if err == io.EOF {
+		c.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.row = append(c.row[:0], rec...)
+	c.rowid++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.eof }
+
+// Column implements vtab.Cursor. Cells are converted according to the
+// detected affinity of their column; missing trailing cells and cells equal
+// to the configured nullvalue are NULL.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	if col < 0 || col >= len(c.t.cols) || col >= len(c.row) {
+		return nil, nil
+	}
+
+	cell := c.row[col]
+	if c.t.opts.hasNull && cell == c.t.opts.nullvalue {
+		return nil, nil
+	}
+
+	switch c.t.affinities[col] {
+	case affInteger:
+		if v, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			return v, nil
+		}
+	case affReal:
+		if v, err := strconv.ParseFloat(cell, 64); err == nil {
+			return v, nil
+		}
+	}
+	return cell, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.rowid, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error {
+	if c.f == nil {
+		return nil
+	}
+
+	err := c.f.Close()
+	c.f, c.r = nil, nil
+	return err
+}
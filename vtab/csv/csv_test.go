@@ -0,0 +1,132 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv // import "github.com/syralon/sqlite/vtab/csv"
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestCSVTable(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.csv")
+	content := "id,price,name,note\n" +
+		"1,9.50,widget,\n" +
+		"2,12,\"gadget, deluxe\",NA\n" +
+		"3,0.25,\"multi\nline\",x\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", dir+"/csv.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table data using csv(filename=%q, nullvalue='NA')", file)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Affinity detection: id INTEGER, price REAL, name TEXT.
+	var idType, priceType, nameType string
+	if err := db.QueryRow("select typeof(id), typeof(price), typeof(name) from data limit 1").
+		Scan(&idType, &priceType, &nameType); err != nil {
+		t.Fatal(err)
+	}
+
+	if idType != "integer" || priceType != "real" || nameType != "text" {
+		t.Fatalf("got types %s/%s/%s, want integer/real/text", idType, priceType, nameType)
+	}
+
+	// Quoted fields keep embedded delimiters and newlines.
+	var name string
+	if err := db.QueryRow("select name from data where id = 2").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "gadget, deluxe" {
+		t.Fatalf("got name %q, want %q", name, "gadget, deluxe")
+	}
+
+	if err := db.QueryRow("select name from data where id = 3").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "multi\nline" {
+		t.Fatalf("got name %q, want %q", name, "multi\nline")
+	}
+
+	// Configured nullvalue scans as NULL; other empty cells stay text.
+	var nulls int
+	if err := db.QueryRow("select count(*) from data where note is null").Scan(&nulls); err != nil {
+		t.Fatal(err)
+	}
+
+	if nulls != 1 {
+		t.Fatalf("got %d NULL notes, want 1", nulls)
+	}
+
+	var sum float64
+	if err := db.QueryRow("select sum(price) from data").Scan(&sum); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum != 21.75 {
+		t.Fatalf("sum(price) = %v, want 21.75", sum)
+	}
+
+	// Independent cursors stream the file separately.
+	var n int
+	if err := db.QueryRow("select count(*) from data a, data b").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 9 {
+		t.Fatalf("self join count = %d, want 9", n)
+	}
+
+	// header=false synthesizes column names; affinity=false keeps TEXT.
+	plain := filepath.Join(dir, "plain.csv")
+	if err := os.WriteFile(plain, []byte("1;a\n2;b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table plain using csv(filename=%q, header=false, delimiter=';', affinity=false)", plain)); err != nil {
+		t.Fatal(err)
+	}
+
+	var c1 string
+	if err := db.QueryRow("select typeof(c1) from plain limit 1").Scan(&c1); err != nil {
+		t.Fatal(err)
+	}
+
+	if c1 != "text" {
+		t.Fatalf("typeof(c1) = %q, want text", c1)
+	}
+
+	if err := db.QueryRow("select count(*) from plain").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d rows, want 2", n)
+	}
+
+	// Missing filename is rejected.
+	if _, err := db.Exec("create virtual table bad using csv(header=true)"); err == nil {
+		t.Fatal("expected an error for a missing filename")
+	}
+}
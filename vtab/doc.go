@@ -15,8 +15,10 @@ package vtab
 // - Constraint support: Modules that want MATCH/other constraints must call
 //   Context.EnableConstraintSupport from within Create or Connect.
 //
-// - Vtab config: Modules can call Context.Config to pass sqlite3_vtab_config
-//   options (e.g., INNOCUOUS, DIRECTONLY) from within Create or Connect.
+// - Vtab config: Modules can call Context.SetConfig with named ConfigOption
+//   values (ConfigConstraintSupport, ConfigInnocuous, ConfigDirectOnly) from
+//   within Create or Connect; Context.Config remains as the raw
+//   sqlite3_vtab_config escape hatch.
 //
 // - Constraint operators: ConstraintOp includes OpUnknown for operators that
 //   are not recognized. The driver maps common SQLite operators including EQ,
@@ -46,10 +48,23 @@ package vtab
 // - Renamer: Implement Rename(newName string) on your Table to handle xRename.
 //   If unimplemented, rename is treated as a no-op.
 //
-// - Transactional: Implement Begin/Sync/Commit/Rollback/Savepoint/Release/
-//   RollbackTo as needed. Unimplemented methods are treated as no-ops.
-//   These callbacks let writable or advanced modules coordinate with SQLite
-//   transaction boundaries.
+// - Transactioner: Implement Begin/Sync/Commit/Rollback to coordinate with
+//   SQLite transaction boundaries; buffer writes and flush them in Sync to
+//   make the flush atomic with the surrounding transaction. Transactional
+//   extends it with Savepoint/Release/RollbackTo. Unimplemented methods are
+//   treated as no-ops.
+//
+// - FunctionOverloader: Implement FindFunction on your Table to overload SQL
+//   functions — including the function form of MATCH — applied to the
+//   table's columns, wired to xFindFunction.
+//
+// - ShadowNamer: Implement ShadowName(suffix) bool on your Module to declare
+//   shadow table name suffixes (xShadowName); claimed tables are protected
+//   from direct writes in defensive mode.
+//
+// - EponymousModule / EponymousOnlyModule: Marker interfaces on your Module
+//   making the registered name directly usable as a table-valued function;
+//   the -Only variant additionally forbids CREATE VIRTUAL TABLE.
 //
 // Re-entrancy cautions
 // - Avoid executing SQL on the same connection from within vtab methods
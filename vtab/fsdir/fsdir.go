@@ -0,0 +1,297 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fsdir provides a read-only virtual table listing the entries of a
+// filesystem, mirroring the fsdir table of SQLite's fileio extension but for
+// arbitrary io/fs filesystems.
+//
+//	CREATE VIRTUAL TABLE files USING fsdir(dir='/var/log');
+//	SELECT path, size FROM files WHERE path GLOB '*.log' ORDER BY size DESC;
+//
+// Each entry, directories included, becomes one row with its slash-separated
+// path relative to the root, its mode bits, its modification time in unix
+// seconds and its size. The content column carries the file's bytes and is
+// read lazily, only for rows and queries that reference it; for directories
+// it is NULL.
+//
+// An equality constraint on path stats a single entry instead of walking,
+// and the literal prefix of a GLOB or LIKE pattern narrows the walk to the
+// enclosing directory, so path-anchored queries do not traverse the whole
+// tree.
+//
+// RegisterFS serves any fs.FS — an embed.FS, an fstest.MapFS, a zip archive
+// — instead of a directory of the host filesystem.
+package fsdir // import "github.com/syralon/sqlite/vtab/fsdir"
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Module implements vtab.Module for filesystem tables.
+type Module struct {
+	// fsys serves the table's entries. When nil, the dir argument of CREATE
+	// VIRTUAL TABLE names a directory of the host filesystem.
+	fsys fs.FS
+}
+
+// Register registers a module named name listing a directory of the host
+// filesystem. Registration applies to new connections only; see
+// vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, &Module{})
+}
+
+// RegisterFS registers a module named name listing fsys. Tables created
+// through it do not take a dir argument.
+func RegisterFS(db *sql.DB, name string, fsys fs.FS) error {
+	return vtab.RegisterModule(db, name, &Module{fsys: fsys})
+}
+
+// Column order of the declared schema.
+const (
+	colPath = iota
+	colMode
+	colMtime
+	colSize
+	colContent
+)
+
+// Plans chosen by BestIndex.
+const (
+	planScan = iota
+	planEQ
+	planPrefix
+)
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	var dir string
+	for _, a := range args[3:] {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "dir":
+			dir = v
+		default:
+			return nil, fmt.Errorf("fsdir: unknown option %q", k)
+		}
+	}
+
+	fsys := m.fsys
+	switch {
+	case fsys == nil && dir == "":
+		return nil, fmt.Errorf("fsdir: the dir option is required")
+	case fsys != nil && dir != "":
+		return nil, fmt.Errorf("fsdir: a filesystem-backed table takes no dir")
+	case fsys == nil:
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		fsys = os.DirFS(dir)
+	}
+
+	if err := ctx.Declare(`CREATE TABLE x(path TEXT, mode INTEGER, mtime INTEGER,
+		size INTEGER, content BLOB)`); err != nil {
+		return nil, err
+	}
+	return &table{fsys: fsys}, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+type table struct {
+	fsys fs.FS
+}
+
+// BestIndex implements vtab.Table. An equality on path resolves one entry
+// directly; a GLOB or LIKE pattern narrows the walk to the directory named
+// by its literal prefix. Omit stays false so SQLite verifies the pattern
+// itself.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	info.EstimatedCost = 1e6
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Column != colPath {
+			continue
+		}
+		switch c.Op {
+		case vtab.OpEQ:
+			c.ArgIndex = 0
+			info.IdxNum = planEQ
+			info.IdxFlags = vtab.IndexScanUnique
+			info.EstimatedCost = 1
+			info.EstimatedRows = 1
+			return nil
+		case vtab.OpGLOB:
+			c.ArgIndex = 0
+			info.IdxNum = planPrefix
+			info.IdxStr = "*?["
+			info.EstimatedCost = 1e3
+			return nil
+		case vtab.OpLIKE:
+			c.ArgIndex = 0
+			info.IdxNum = planPrefix
+			info.IdxStr = "%_"
+			info.EstimatedCost = 1e3
+			return nil
+		}
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+// entry is one listed filesystem entry; content is loaded on first use.
+type entry struct {
+	path string
+	info fs.FileInfo
+}
+
+type cursor struct {
+	t       *table
+	entries []entry
+	i       int
+
+	// content caches the bytes of the current row once read.
+	content    []byte
+	contentRow int
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.entries = c.entries[:0]
+	c.i = 0
+	c.contentRow = -1
+
+	arg := ""
+	if len(vals) > 0 {
+		switch x := vals[0].(type) {
+		case string:
+			arg = x
+		case []byte:
+			arg = string(x)
+		}
+	}
+
+	switch idxNum {
+	case planEQ:
+		info, err := fs.Stat(c.t.fsys, arg)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) || !fs.ValidPath(arg) {
+				return nil
+			}
+			return err
+		}
+		c.entries = append(c.entries, entry{path: arg, info: info})
+		return nil
+	case planPrefix:
+		return c.walk(walkRoot(arg, idxStr))
+	}
+	return c.walk(".")
+}
+
+// walkRoot returns the directory to walk for a pattern: the parent of the
+// first path element containing one of the wildcard characters.
+func walkRoot(pattern, wildcards string) string {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, wildcards); i >= 0 {
+		prefix = pattern[:i]
+	}
+	dir := "."
+	if i := strings.LastIndexByte(prefix, '/'); i > 0 {
+		dir = prefix[:i]
+	}
+	if !fs.ValidPath(dir) {
+		return "."
+	}
+	return dir
+}
+
+func (c *cursor) walk(root string) error {
+	err := fs.WalkDir(c.t.fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		c.entries = append(c.entries, entry{path: path, info: info})
+		return nil
+	})
+	return err
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= len(c.entries) }
+
+// Column implements vtab.Cursor. The content column reads the file on first
+// access; the other columns come from the directory walk.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	e := &c.entries[c.i]
+	switch col {
+	case colPath:
+		return e.path, nil
+	case colMode:
+		return int64(e.info.Mode()), nil
+	case colMtime:
+		return e.info.ModTime().Unix(), nil
+	case colSize:
+		return e.info.Size(), nil
+	case colContent:
+		if e.info.IsDir() {
+			return nil, nil
+		}
+		if c.contentRow != c.i {
+			b, err := fs.ReadFile(c.t.fsys, e.path)
+			if err != nil {
+				return nil, err
+			}
+			c.content, c.contentRow = b, c.i
+		}
+		return c.content, nil
+	}
+	return nil, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return int64(c.i + 1), nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
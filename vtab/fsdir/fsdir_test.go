@@ -0,0 +1,148 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fsdir // import "github.com/syralon/sqlite/vtab/fsdir"
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestFsdir(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"readme.txt":     "hello",
+		"logs/app.log":   "line one\n",
+		"logs/error.log": "boom\n",
+	} {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", dir+"/fsdir.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "fsdir"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table files using fsdir(dir=%q)", dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The walk lists files and directories; the database file itself is in
+	// dir too.
+	var n int
+	if err := db.QueryRow("select count(*) from files where path like 'logs%'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Fatalf("got %d rows under logs, want 3 (the directory and two files)", n)
+	}
+
+	// Exact path lookup stats a single entry; content and size line up.
+	var content []byte
+	var size int64
+	if err := db.QueryRow("select content, size from files where path = 'readme.txt'").Scan(&content, &size); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "hello" || size != 5 {
+		t.Fatalf("got content %q size %d", content, size)
+	}
+
+	// A missing path is an empty result, not an error.
+	if err := db.QueryRow("select count(*) from files where path = 'nope'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("got %d rows for a missing path", n)
+	}
+
+	// GLOB narrows the walk to the pattern's directory and SQLite applies
+	// the pattern.
+	var path string
+	if err := db.QueryRow("select path from files where path glob 'logs/err*'").Scan(&path); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "logs/error.log" {
+		t.Fatalf("path = %q, want logs/error.log", path)
+	}
+
+	// Directories have NULL content and the directory mode bit set.
+	var mode int64
+	if err := db.QueryRow("select mode from files where path = 'logs' and content is null").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+
+	if mode&int64(os.ModeDir) == 0 {
+		t.Fatalf("mode %o is missing the directory bit", mode)
+	}
+}
+
+func TestFsdirFS(t *testing.T) {
+	mtime := time.Unix(1000000000, 0)
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("alpha"), Mode: 0644, ModTime: mtime},
+		"sub/b.txt": {Data: []byte("beta"), Mode: 0600, ModTime: mtime},
+	}
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/mapfs.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := RegisterFS(db, "fsdir_map", fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table files using fsdir_map"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got int64
+	if err := db.QueryRow("select mtime from files where path = 'sub/b.txt'").Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != mtime.Unix() {
+		t.Fatalf("mtime = %d, want %d", got, mtime.Unix())
+	}
+
+	// The root directory "." is listed too.
+	var n int
+	if err := db.QueryRow("select count(*) from files").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 4 {
+		t.Fatalf("got %d rows, want 4 (., a.txt, sub, sub/b.txt)", n)
+	}
+
+	// A filesystem-backed table takes no dir.
+	if _, err := db.Exec("create virtual table bad using fsdir_map(dir='/tmp')"); err == nil {
+		t.Fatal("expected an error for a dir on a filesystem-backed table")
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonl provides a read-only virtual table over newline-delimited
+// JSON (JSON Lines). Each input line must be one JSON object; rows stream
+// from the file per cursor, so large inputs are not materialized in memory.
+//
+//	CREATE VIRTUAL TABLE logs USING jsonl(filename='app.jsonl');
+//	SELECT * FROM logs WHERE level = 'error';
+//
+// Columns are inferred from the keys of a sample of leading objects, or
+// fixed with an explicit columns argument:
+//
+//	CREATE VIRTUAL TABLE logs USING jsonl(filename='app.jsonl',
+//	    columns='ts,level,msg');
+//
+// Top-level string, number, boolean and null values map to TEXT, numeric and
+// NULL column values; nested objects and arrays are returned as their JSON
+// text. Equality constraints on any column are pushed down and filtered
+// during the scan.
+//
+// Data can also be served from an io.Reader factory registered with
+// RegisterReader, for sources that are not files.
+package jsonl // import "github.com/syralon/sqlite/vtab/jsonl"
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// schemaSampleRows is the number of leading objects inspected when columns
+// are inferred.
+const schemaSampleRows = 100
+
+// Module implements vtab.Module for JSON Lines tables.
+type Module struct {
+	// open returns a fresh reader over the input. When nil, the filename
+	// argument of CREATE VIRTUAL TABLE is used.
+	open func() (io.ReadCloser, error)
+}
+
+// Register registers a file-backed JSON Lines module named name.
+// Registration applies to new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, &Module{})
+}
+
+// RegisterReader registers a JSON Lines module named name serving data from
+// open, which is invoked once per scan and must return a fresh reader over
+// the whole input. Tables created through it do not take a filename
+// argument.
+func RegisterReader(db *sql.DB, name string, open func() (io.ReadCloser, error)) error {
+	return vtab.RegisterModule(db, name, &Module{open: open})
+}
+
+type options struct {
+	filename string
+	columns  []string
+}
+
+func parseOptions(args []string) (options, error) {
+	var opts options
+	for _, a := range args {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "filename":
+			opts.filename = v
+		case "columns":
+			for _, col := range strings.Split(v, ",") {
+				col = strings.TrimSpace(col)
+				if col == "" {
+					return opts, fmt.Errorf("jsonl: empty column name in %q", v)
+				}
+				opts.columns = append(opts.columns, col)
+			}
+		default:
+			return opts, fmt.Errorf("jsonl: unknown option %q", k)
+		}
+	}
+	return opts, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	opts, err := parseOptions(args[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{m: m, opts: opts}
+	if m.open == nil && opts.filename == "" {
+		return nil, fmt.Errorf("jsonl: the filename option is required")
+	}
+	if m.open != nil && opts.filename != "" {
+		return nil, fmt.Errorf("jsonl: a reader-backed table takes no filename")
+	}
+
+	if len(t.opts.columns) == 0 {
+		if err := t.inferColumns(); err != nil {
+			return nil, err
+		}
+	}
+	if len(t.opts.columns) == 0 {
+		return nil, fmt.Errorf("jsonl: no columns could be inferred")
+	}
+
+	decl := make([]string, len(t.opts.columns))
+	for i, col := range t.opts.columns {
+		decl[i] = strconv.Quote(col)
+	}
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(decl, ", "))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct {
+	m    *Module
+	opts options
+}
+
+func (t *table) openInput() (io.ReadCloser, error) {
+	if t.m.open != nil {
+		return t.m.open()
+	}
+	return os.Open(t.opts.filename)
+}
+
+// inferColumns samples leading objects and collects their keys in first-seen
+// order.
+func (t *table) inferColumns() error {
+	r, err := t.openInput()
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	seen := make(map[string]bool)
+	sc := newScanner(r)
+	for n := 0; n < schemaSampleRows && sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// Decode with key order preserved.
+		dec := json.NewDecoder(bytes.NewReader(line))
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("jsonl: line %d: %w", n+1, err)
+		}
+		if tok != json.Delim('{') {
+			return fmt.Errorf("jsonl: line %d: not a JSON object", n+1)
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("jsonl: line %d: %w", n+1, err)
+			}
+			k := key.(string)
+			if !seen[k] {
+				seen[k] = true
+				t.opts.columns = append(t.opts.columns, k)
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("jsonl: line %d: %w", n+1, err)
+			}
+		}
+	}
+	return sc.Err()
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return sc
+}
+
+// BestIndex implements vtab.Table. Equality constraints are passed to Filter
+// and applied during the scan; Omit stays false so SQLite still verifies
+// them, keeping JSON/SQL comparison differences harmless.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	arg := 0
+	var pushed []string
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Op != vtab.OpEQ || c.Column < 0 || c.Column >= len(t.opts.columns) {
+			continue
+		}
+
+		c.ArgIndex = arg
+		pushed = append(pushed, strconv.Itoa(c.Column))
+		arg++
+	}
+
+	info.IdxStr = strings.Join(pushed, ",")
+	info.EstimatedCost = 1e6
+	if arg > 0 {
+		info.EstimatedCost /= float64(arg + 1)
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+type cursor struct {
+	t     *table
+	in    io.ReadCloser
+	sc    *bufio.Scanner
+	row   map[string]json.RawMessage
+	rowid int64
+	eof   bool
+
+	// filter holds the pushed-down equality values by column index.
+	filter map[int]vtab.Value
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+
+	c.filter = nil
+	if idxStr != "" {
+		c.filter = make(map[int]vtab.Value, len(vals))
+		for i, s := range strings.Split(idxStr, ",") {
+			col, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("jsonl: bad idxStr %q", idxStr)
+			}
+			c.filter[col] = vals[i]
+		}
+	}
+
+	in, err := c.t.openInput()
+	if err != nil {
+		return err
+	}
+
+	c.in = in
+	c.sc = newScanner(in)
+	c.rowid = 0
+	c.eof = false
+	return c.Next()
+}
+
+// Next implements vtab.Cursor. Rows not matching the pushed-down equality
+// constraints are skipped during the scan.
+func (c *cursor) Next() error {
+	for {
+		if !c.sc.Scan() {
+			if err := c.sc.Err(); err != nil {
+				return err
+			}
+			c.eof = true
+			return nil
+		}
+
+		c.rowid++
+		line := bytes.TrimSpace(c.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var row map[string]json.RawMessage
+		if err := json.Unmarshal(line, &row); err != nil {
+			return fmt.Errorf("jsonl: line %d: %w", c.rowid, err)
+		}
+
+		c.row = row
+		if c.matches() {
+			return nil
+		}
+	}
+}
+
+func (c *cursor) matches() bool {
+	for col, want := range c.filter {
+		got, err := c.value(col)
+		if err != nil || !valueEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueEqual compares a decoded column value with a constraint value
+// conservatively: rows compare equal only on identical normalized values, and
+// SQLite re-checks the constraint anyway.
+func valueEqual(a, b vtab.Value) bool {
+	if ai, ok := a.(int64); ok {
+		if bf, ok := b.(float64); ok {
+			return float64(ai) == bf
+		}
+	}
+	if af, ok := a.(float64); ok {
+		if bi, ok := b.(int64); ok {
+			return af == float64(bi)
+		}
+	}
+	if ab, ok := a.([]byte); ok {
+		if bs, ok := b.(string); ok {
+			return string(ab) == bs
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bb, ok := b.([]byte); ok {
+			return as == string(bb)
+		}
+	}
+	return a == b
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.eof }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	return c.value(col)
+}
+
+func (c *cursor) value(col int) (vtab.Value, error) {
+	if col < 0 || col >= len(c.t.opts.columns) {
+		return nil, nil
+	}
+
+	raw, ok := c.row[c.t.opts.columns[col]]
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case 't':
+		return int64(1), nil
+	case 'f':
+		return int64(0), nil
+	case '{', '[':
+		// Nested values surface as their JSON text, queryable with the
+		// built-in json functions.
+		return string(raw), nil
+	}
+
+	if i, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return i, nil
+	}
+	var f float64
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.rowid, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error {
+	if c.in == nil {
+		return nil
+	}
+
+	err := c.in.Close()
+	c.in, c.sc = nil, nil
+	return err
+}
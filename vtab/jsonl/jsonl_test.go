@@ -0,0 +1,152 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonl // import "github.com/syralon/sqlite/vtab/jsonl"
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "logs.jsonl")
+	content := `{"ts": 1, "level": "info", "msg": "started", "meta": {"pid": 7}}
+{"ts": 2, "level": "error", "msg": "boom", "code": 500}
+
+{"ts": 3, "level": "info", "msg": "done", "ok": true}
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", dir+"/jsonl.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "jsonl"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table logs using jsonl(filename=%q)", file)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Inferred columns, in first-seen order, include keys from later lines.
+	rows, err := db.Query("select * from logs limit 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "ts,level,msg,meta,code,ok"; strings.Join(cols, ",") != want {
+		t.Fatalf("columns = %v, want %s", cols, want)
+	}
+
+	// EQ pushdown on a top-level field.
+	var msg string
+	var code int
+	if err := db.QueryRow("select msg, code from logs where level = 'error'").Scan(&msg, &code); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg != "boom" || code != 500 {
+		t.Fatalf("got %q/%d, want boom/500", msg, code)
+	}
+
+	// Missing keys are NULL; booleans are integers; nested objects surface
+	// as JSON text usable with the json functions.
+	var n int
+	if err := db.QueryRow("select count(*) from logs where code is null").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d rows with NULL code, want 2", n)
+	}
+
+	var ok int
+	if err := db.QueryRow("select ok from logs where ts = 3").Scan(&ok); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok != 1 {
+		t.Fatalf("ok = %d, want 1", ok)
+	}
+
+	var pid int
+	if err := db.QueryRow("select json_extract(meta, '$.pid') from logs where ts = 1").Scan(&pid); err != nil {
+		t.Fatal(err)
+	}
+
+	if pid != 7 {
+		t.Fatalf("pid = %d, want 7", pid)
+	}
+
+	// Explicit columns override inference.
+	if _, err := db.Exec(fmt.Sprintf("create virtual table slim using jsonl(filename=%q, columns='ts,msg')", file)); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err = db.Query("select * from slim limit 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols, err = rows.Columns()
+	rows.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "ts,msg"; strings.Join(cols, ",") != want {
+		t.Fatalf("columns = %v, want %s", cols, want)
+	}
+}
+
+func TestJSONLinesReader(t *testing.T) {
+	const content = `{"a": 1}
+{"a": 2}
+`
+	db, err := sql.Open("sqlite3", t.TempDir()+"/reader.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := RegisterReader(db, "jsonl_mem", func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table mem using jsonl_mem"); err != nil {
+		t.Fatal(err)
+	}
+
+	var sum int
+	if err := db.QueryRow("select sum(a) from mem").Scan(&sum); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum != 3 {
+		t.Fatalf("sum = %d, want 3", sum)
+	}
+}
@@ -0,0 +1,633 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package parquet provides a read-only virtual table over Apache Parquet
+// files, backed by github.com/parquet-go/parquet-go.
+//
+//	CREATE VIRTUAL TABLE events USING parquet(filename='events.parquet');
+//	SELECT user, count(*) FROM events WHERE status = 'error' GROUP BY user;
+//
+// The table schema is taken from the file: BOOLEAN, INT32 and INT64 columns
+// map to INTEGER, FLOAT and DOUBLE to REAL, and byte arrays to TEXT or BLOB
+// depending on their logical type. Only flat schemas are supported; nested
+// groups and repeated fields are rejected at CREATE time.
+//
+// Scans decode only the columns the query references, using the column-usage
+// mask SQLite computes at plan time, so narrow queries against wide files
+// read a fraction of the data. Equality and range constraints are pushed
+// into the scan and additionally prune whole row groups whose column
+// statistics exclude the constraint.
+package parquet // import "github.com/syralon/sqlite/vtab/parquet"
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Module implements vtab.Module for Parquet tables.
+type Module struct{}
+
+// Register registers the Parquet module named name. Registration applies to
+// new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, &Module{})
+}
+
+type column struct {
+	name string
+	kind pq.Kind
+	// str reports a byte array column carrying the STRING logical type,
+	// exposed as TEXT rather than BLOB.
+	str bool
+}
+
+func parseOptions(args []string) (filename string, err error) {
+	for _, a := range args {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "filename":
+			filename = v
+		default:
+			return "", fmt.Errorf("parquet: unknown option %q", k)
+		}
+	}
+	if filename == "" {
+		return "", fmt.Errorf("parquet: the filename option is required")
+	}
+	return filename, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	filename, err := parseOptions(args[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{filename: filename}
+	f, osf, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer osf.Close()
+
+	decl := make([]string, 0, len(f.Schema().Fields()))
+	for _, field := range f.Schema().Fields() {
+		if !field.Leaf() {
+			return nil, fmt.Errorf("parquet: column %q: nested schemas are not supported", field.Name())
+		}
+
+		typ := field.Type()
+		col := column{name: field.Name(), kind: typ.Kind()}
+		var sqlType string
+		switch col.kind {
+		case pq.Boolean, pq.Int32, pq.Int64:
+			sqlType = "INTEGER"
+		case pq.Float, pq.Double:
+			sqlType = "REAL"
+		case pq.ByteArray, pq.FixedLenByteArray:
+			sqlType = "BLOB"
+			if lt := typ.LogicalType(); lt != nil && lt.UTF8 != nil {
+				col.str = true
+				sqlType = "TEXT"
+			}
+		default:
+			return nil, fmt.Errorf("parquet: column %q: unsupported physical type %s", field.Name(), col.kind)
+		}
+
+		t.cols = append(t.cols, col)
+		decl = append(decl, fmt.Sprintf("%q %s", col.name, sqlType))
+	}
+	if len(t.cols) == 0 {
+		return nil, fmt.Errorf("parquet: %s has no columns", filename)
+	}
+
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(decl, ", "))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct {
+	filename string
+	cols     []column
+}
+
+func (t *table) open() (*pq.File, *os.File, error) {
+	osf, err := os.Open(t.filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := osf.Stat()
+	if err != nil {
+		osf.Close()
+		return nil, nil, err
+	}
+
+	f, err := pq.OpenFile(osf, st.Size())
+	if err != nil {
+		osf.Close()
+		return nil, nil, fmt.Errorf("parquet: open %s: %w", t.filename, err)
+	}
+	return f, osf, nil
+}
+
+// BestIndex implements vtab.Table. Equality and range constraints are passed
+// to Filter, which applies them during the scan and prunes row groups by
+// their column statistics. Omit stays false so SQLite still verifies the
+// constraints, keeping type-comparison differences harmless.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	arg := 0
+	var pushed []string
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Column < 0 || c.Column >= len(t.cols) {
+			continue
+		}
+		switch c.Op {
+		case vtab.OpEQ, vtab.OpGT, vtab.OpGE, vtab.OpLT, vtab.OpLE:
+		default:
+			continue
+		}
+
+		c.ArgIndex = arg
+		pushed = append(pushed, fmt.Sprintf("%d:%d", c.Column, c.Op))
+		arg++
+	}
+
+	info.IdxStr = strings.Join(pushed, ",")
+	info.EstimatedCost = 1e6
+	if arg > 0 {
+		info.EstimatedCost /= float64(arg + 1)
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+// constraint is one pushed-down predicate, decoded from IdxStr.
+type constraint struct {
+	col  int
+	op   vtab.ConstraintOp
+	want vtab.Value
+}
+
+type cursor struct {
+	t       *table
+	colUsed uint64
+
+	osf    *os.File
+	groups []pq.RowGroup
+	// readers holds one value stream per referenced column, indexed by
+	// column; unreferenced columns stay nil and are never decoded.
+	readers []*colReader
+	cons    []constraint
+
+	group     int
+	remaining int64
+	row       []vtab.Value
+	rowid     int64
+	eof       bool
+}
+
+// SetColumnsUsed implements vtab.ColumnUser.
+func (c *cursor) SetColumnsUsed(colUsed uint64) { c.colUsed = colUsed }
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+
+	c.cons = c.cons[:0]
+	if idxStr != "" {
+		for i, s := range strings.Split(idxStr, ",") {
+			colStr, opStr, _ := strings.Cut(s, ":")
+			col, err1 := strconv.Atoi(colStr)
+			op, err2 := strconv.Atoi(opStr)
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("parquet: bad idxStr %q", idxStr)
+			}
+			c.cons = append(c.cons, constraint{col: col, op: vtab.ConstraintOp(op), want: vals[i]})
+		}
+	}
+
+	f, osf, err := c.t.open()
+	if err != nil {
+		return err
+	}
+
+	c.osf = osf
+	c.groups = f.RowGroups()
+	c.readers = make([]*colReader, len(c.t.cols))
+	c.row = make([]vtab.Value, len(c.t.cols))
+	c.group = -1
+	c.remaining = 0
+	c.rowid = 0
+	c.eof = false
+	return c.Next()
+}
+
+// used reports whether column col must be decoded: either the query
+// references it (per the ColUsed mask, where bit 63 covers all further
+// columns) or a pushed-down constraint tests it.
+func (c *cursor) used(col int) bool {
+	bit := col
+	if bit > 63 {
+		bit = 63
+	}
+	if c.colUsed&(1<<bit) != 0 {
+		return true
+	}
+	for _, con := range c.cons {
+		if con.col == col {
+			return true
+		}
+	}
+	return false
+}
+
+// nextGroup advances to the next row group whose column statistics do not
+// exclude the pushed-down constraints, and opens value streams for the
+// referenced columns.
+func (c *cursor) nextGroup() error {
+	for {
+		c.closeReaders()
+		c.group++
+		if c.group >= len(c.groups) {
+			c.eof = true
+			return nil
+		}
+
+		rg := c.groups[c.group]
+		if c.skipGroup(rg) {
+			continue
+		}
+
+		chunks := rg.ColumnChunks()
+		for col := range c.t.cols {
+			if !c.used(col) || col >= len(chunks) {
+				continue
+			}
+			c.readers[col] = &colReader{pages: chunks[col].Pages()}
+		}
+		c.remaining = rg.NumRows()
+		return nil
+	}
+}
+
+// skipGroup reports whether every row of rg can be ruled out by comparing
+// the pushed-down constraints against the min/max column statistics. Files
+// without a column index are never pruned.
+func (c *cursor) skipGroup(rg pq.RowGroup) bool {
+	chunks := rg.ColumnChunks()
+	for _, con := range c.cons {
+		if con.col >= len(chunks) {
+			continue
+		}
+
+		chunk := chunks[con.col]
+		want, ok := parquetValue(chunk.Type().Kind(), con.want)
+		if !ok {
+			continue
+		}
+
+		ci, err := chunk.ColumnIndex()
+		if err != nil {
+			continue
+		}
+
+		var min, max pq.Value
+		seen := false
+		for i := 0; i < ci.NumPages(); i++ {
+			if ci.NullPage(i) {
+				continue
+			}
+			lo, hi := ci.MinValue(i), ci.MaxValue(i)
+			if lo.IsNull() || hi.IsNull() {
+				seen = false
+				break
+			}
+			if !seen {
+				min, max, seen = lo, hi, true
+				continue
+			}
+			if chunk.Type().Compare(lo, min) < 0 {
+				min = lo
+			}
+			if chunk.Type().Compare(hi, max) > 0 {
+				max = hi
+			}
+		}
+		if !seen {
+			continue
+		}
+
+		cmpMin := chunk.Type().Compare(want, min)
+		cmpMax := chunk.Type().Compare(want, max)
+		switch con.op {
+		case vtab.OpEQ:
+			if cmpMin < 0 || cmpMax > 0 {
+				return true
+			}
+		case vtab.OpGT:
+			if cmpMax >= 0 {
+				return true
+			}
+		case vtab.OpGE:
+			if cmpMax > 0 {
+				return true
+			}
+		case vtab.OpLT:
+			if cmpMin <= 0 {
+				return true
+			}
+		case vtab.OpLE:
+			if cmpMin < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Next implements vtab.Cursor. Rows not matching the pushed-down constraints
+// are skipped during the scan.
+func (c *cursor) Next() error {
+	for {
+		if c.remaining == 0 {
+			if err := c.nextGroup(); err != nil {
+				return err
+			}
+			if c.eof {
+				return nil
+			}
+		}
+
+		c.remaining--
+		c.rowid++
+		for col, r := range c.readers {
+			if r == nil {
+				c.row[col] = nil
+				continue
+			}
+
+			v, err := r.next()
+			if err != nil {
+				return fmt.Errorf("parquet: column %q: %w", c.t.cols[col].name, err)
+			}
+			c.row[col] = goValue(v, c.t.cols[col].str)
+		}
+		if c.matches() {
+			return nil
+		}
+	}
+}
+
+func (c *cursor) matches() bool {
+	for _, con := range c.cons {
+		cmp, ok := compareValues(c.row[con.col], con.want)
+		if !ok {
+			return false
+		}
+		switch con.op {
+		case vtab.OpEQ:
+			ok = cmp == 0
+		case vtab.OpGT:
+			ok = cmp > 0
+		case vtab.OpGE:
+			ok = cmp >= 0
+		case vtab.OpLT:
+			ok = cmp < 0
+		case vtab.OpLE:
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.eof }
+
+// Column implements vtab.Cursor. Columns outside the usage mask the query
+// planner reported are not decoded and read as NULL.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	if col < 0 || col >= len(c.row) {
+		return nil, nil
+	}
+	return c.row[col], nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.rowid, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error {
+	c.closeReaders()
+	if c.osf == nil {
+		return nil
+	}
+
+	err := c.osf.Close()
+	c.osf, c.groups = nil, nil
+	return err
+}
+
+func (c *cursor) closeReaders() {
+	for col, r := range c.readers {
+		if r != nil {
+			r.pages.Close()
+			c.readers[col] = nil
+		}
+	}
+}
+
+// colReader streams the values of one column chunk, page by page. For flat
+// schemas each row contributes exactly one value per column, nulls included,
+// so readers advanced in lockstep stay aligned on the same row.
+type colReader struct {
+	pages pq.Pages
+	vals  pq.ValueReader
+	buf   [64]pq.Value
+	i, n  int
+}
+
+func (r *colReader) next() (pq.Value, error) {
+	for {
+		if r.i < r.n {
+			v := r.buf[r.i]
+			r.i++
+			return v, nil
+		}
+
+		if r.vals != nil {
+			n, err := r.vals.ReadValues(r.buf[:])
+			r.i, r.n = 0, n
+			if n > 0 {
+				continue
+			}
+			if err != nil && err != io.EOF {
+				return pq.Value{}, err
+			}
+			r.vals = nil
+		}
+
+		p, err := r.pages.ReadPage()
+		if err != nil {
+			return pq.Value{}, err
+		}
+		r.vals = p.Values()
+	}
+}
+
+// goValue converts a parquet value to the driver value exposed for its
+// column. Booleans become 0/1 integers.
+func goValue(v pq.Value, str bool) vtab.Value {
+	if v.IsNull() {
+		return nil
+	}
+	switch v.Kind() {
+	case pq.Boolean:
+		if v.Boolean() {
+			return int64(1)
+		}
+		return int64(0)
+	case pq.Int32:
+		return int64(v.Int32())
+	case pq.Int64:
+		return v.Int64()
+	case pq.Float:
+		return float64(v.Float())
+	case pq.Double:
+		return v.Double()
+	case pq.ByteArray, pq.FixedLenByteArray:
+		b := v.ByteArray()
+		if str {
+			return string(b)
+		}
+		return append([]byte(nil), b...)
+	}
+	return nil
+}
+
+// parquetValue converts a constraint value to a parquet value of the given
+// physical kind for comparison against column statistics. The bool result is
+// false when the value does not map onto the kind; callers must then fall
+// back to scanning.
+func parquetValue(kind pq.Kind, v vtab.Value) (pq.Value, bool) {
+	switch kind {
+	case pq.Boolean:
+		if i, ok := v.(int64); ok {
+			return pq.BooleanValue(i != 0), true
+		}
+	case pq.Int32:
+		if i, ok := v.(int64); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+			return pq.Int32Value(int32(i)), true
+		}
+	case pq.Int64:
+		if i, ok := v.(int64); ok {
+			return pq.Int64Value(i), true
+		}
+	case pq.Float:
+		switch x := v.(type) {
+		case int64:
+			return pq.FloatValue(float32(x)), true
+		case float64:
+			return pq.FloatValue(float32(x)), true
+		}
+	case pq.Double:
+		switch x := v.(type) {
+		case int64:
+			return pq.DoubleValue(float64(x)), true
+		case float64:
+			return pq.DoubleValue(x), true
+		}
+	case pq.ByteArray, pq.FixedLenByteArray:
+		switch x := v.(type) {
+		case string:
+			return pq.ByteArrayValue([]byte(x)), true
+		case []byte:
+			return pq.ByteArrayValue(x), true
+		}
+	}
+	return pq.Value{}, false
+}
+
+// compareValues orders a row value against a constraint value. The bool
+// result is false when the two are not comparable — including when either is
+// NULL — in which case the row does not match.
+func compareValues(a, b vtab.Value) (int, bool) {
+	if af, ok := asFloat(a); ok {
+		if bf, ok := asFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+	if as, ok := asBytes(a); ok {
+		if bs, ok := asBytes(b); ok {
+			return strings.Compare(as, bs), true
+		}
+	}
+	return 0, false
+}
+
+func asFloat(v vtab.Value) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func asBytes(v vtab.Value) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	}
+	return "", false
+}
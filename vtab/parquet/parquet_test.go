@@ -0,0 +1,197 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parquet // import "github.com/syralon/sqlite/vtab/parquet"
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pq "github.com/parquet-go/parquet-go"
+	_ "github.com/syralon/sqlite"
+)
+
+type event struct {
+	ID     int64   `parquet:"id"`
+	User   *string `parquet:"user,optional"`
+	Score  float64 `parquet:"score"`
+	Active bool    `parquet:"active"`
+}
+
+func writeEvents(t *testing.T, file string, events []event, options ...pq.WriterOption) {
+	t.Helper()
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := pq.NewGenericWriter[event](f, options...)
+	if _, err := w.Write(events); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func str(s string) *string { return &s }
+
+func TestParquet(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "events.parquet")
+	writeEvents(t, file, []event{
+		{ID: 1, User: str("alice"), Score: 1.5, Active: true},
+		{ID: 2, User: str("bob"), Score: 2.5, Active: false},
+		{ID: 3, User: nil, Score: 3.5, Active: true},
+	})
+
+	db, err := sql.Open("sqlite3", dir+"/parquet.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "parquet"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table events using parquet(filename=%q)", file)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Declared column affinities follow the parquet schema.
+	var tid, tuser, tscore string
+	if err := db.QueryRow("select typeof(id), typeof(user), typeof(score) from events where id = 1").
+		Scan(&tid, &tuser, &tscore); err != nil {
+		t.Fatal(err)
+	}
+
+	if tid != "integer" || tuser != "text" || tscore != "real" {
+		t.Fatalf("typeof = %s/%s/%s, want integer/text/real", tid, tuser, tscore)
+	}
+
+	// Equality pushdown on a string column.
+	var id int64
+	if err := db.QueryRow("select id from events where user = 'bob'").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	if id != 2 {
+		t.Fatalf("id = %d, want 2", id)
+	}
+
+	// Range pushdown; booleans read as 0/1 integers; optionals as NULL.
+	var n int
+	if err := db.QueryRow("select count(*) from events where score > 2.0").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d rows with score > 2.0, want 2", n)
+	}
+
+	var active int
+	if err := db.QueryRow("select active from events where id = 3").Scan(&active); err != nil {
+		t.Fatal(err)
+	}
+
+	if active != 1 {
+		t.Fatalf("active = %d, want 1", active)
+	}
+
+	if err := db.QueryRow("select count(*) from events where user is null").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %d rows with NULL user, want 1", n)
+	}
+
+	// Projection: a narrow query over a subset of columns still sees every
+	// row.
+	var sum int64
+	if err := db.QueryRow("select sum(id) from events").Scan(&sum); err != nil {
+		t.Fatal(err)
+	}
+
+	if sum != 6 {
+		t.Fatalf("sum(id) = %d, want 6", sum)
+	}
+}
+
+func TestParquetRowGroupPruning(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "wide.parquet")
+
+	// Four row groups of two rows each, with ids sorted so the column
+	// statistics can exclude groups.
+	var events []event
+	for i := int64(1); i <= 8; i++ {
+		events = append(events, event{ID: i, User: str(fmt.Sprintf("u%d", i)), Score: float64(i)})
+	}
+	writeEvents(t, file, events, pq.MaxRowsPerRowGroup(2))
+
+	db, err := sql.Open("sqlite3", dir+"/prune.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "parquet_prune"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table wide using parquet_prune(filename=%q)", file)); err != nil {
+		t.Fatal(err)
+	}
+
+	var user string
+	if err := db.QueryRow("select user from wide where id = 5").Scan(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	if user != "u5" {
+		t.Fatalf("user = %q, want u5", user)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from wide where id >= 6").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Fatalf("got %d rows with id >= 6, want 3", n)
+	}
+}
+
+func TestParquetErrors(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", dir+"/errors.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "parquet_err"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table bad using parquet_err"); err == nil {
+		t.Fatal("expected an error without a filename")
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table bad using parquet_err(filename=%q)",
+		filepath.Join(dir, "missing.parquet"))); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package prom provides a read-only virtual table over Prometheus metrics,
+// for quick ad-hoc SQL over monitoring data.
+//
+//	CREATE VIRTUAL TABLE metrics USING prom(url='http://localhost:9090/metrics');
+//	SELECT name, labels, value FROM metrics
+//	    WHERE name = 'http_requests_total' ORDER BY value DESC;
+//
+// Each sample becomes one row with the metric name, its type, the label set
+// as a JSON object (queryable with the built-in json functions), the sample
+// value and the optional sample timestamp in milliseconds. Histograms and
+// summaries are flattened the way the text exposition format renders them:
+// _bucket rows carrying an le label, quantile rows, and _sum and _count
+// rows.
+//
+// Tables registered with Register scrape a /metrics endpoint on every scan.
+// RegisterGatherer serves an in-process gatherer instead — a
+// *prometheus.Registry from github.com/prometheus/client_golang satisfies
+// Gatherer directly — so a program can query its own metrics without going
+// through HTTP.
+package prom // import "github.com/syralon/sqlite/vtab/prom"
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Gatherer gathers metric families. It is structurally identical to
+// prometheus.Gatherer from github.com/prometheus/client_golang, so registries
+// from that package satisfy it without this package depending on it.
+type Gatherer interface {
+	Gather() ([]*dto.MetricFamily, error)
+}
+
+// Module implements vtab.Module for Prometheus metric tables.
+type Module struct {
+	gatherer Gatherer
+	client   *http.Client
+}
+
+// Register registers a scraping module named name using http.DefaultClient.
+// Registration applies to new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return RegisterClient(db, name, http.DefaultClient)
+}
+
+// RegisterClient registers a scraping module named name issuing its requests
+// through client.
+func RegisterClient(db *sql.DB, name string, client *http.Client) error {
+	return vtab.RegisterModule(db, name, &Module{client: client})
+}
+
+// RegisterGatherer registers a module named name serving the metrics of g.
+// Tables created through it do not take a url argument.
+func RegisterGatherer(db *sql.DB, name string, g Gatherer) error {
+	return vtab.RegisterModule(db, name, &Module{gatherer: g})
+}
+
+// Column order of the declared schema.
+const (
+	colName = iota
+	colType
+	colLabels
+	colValue
+	colTimestamp
+	colHelp
+)
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	var scrapeURL string
+	for _, a := range args[3:] {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "url":
+			scrapeURL = v
+		default:
+			return nil, fmt.Errorf("prom: unknown option %q", k)
+		}
+	}
+	if m.gatherer == nil && scrapeURL == "" {
+		return nil, fmt.Errorf("prom: the url option is required")
+	}
+	if m.gatherer != nil && scrapeURL != "" {
+		return nil, fmt.Errorf("prom: a gatherer-backed table takes no url")
+	}
+
+	if err := ctx.Declare(`CREATE TABLE x(name TEXT, type TEXT, labels TEXT,
+		value REAL, timestamp INTEGER, help TEXT)`); err != nil {
+		return nil, err
+	}
+	return &table{m: m, url: scrapeURL}, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+type table struct {
+	m   *Module
+	url string
+}
+
+// gather collects the metric families, either from the in-process gatherer
+// or by scraping the endpoint.
+func (t *table) gather() ([]*dto.MetricFamily, error) {
+	if t.m.gatherer != nil {
+		return t.m.gatherer.Gather()
+	}
+
+	resp, err := t.m.client.Get(t.url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prom: GET %s: %s", t.url, resp.Status)
+	}
+
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	byName, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("prom: GET %s: %w", t.url, err)
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(byName))
+	for _, mf := range byName {
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+	return families, nil
+}
+
+// BestIndex implements vtab.Table. An equality constraint on the metric name
+// is passed to Filter and applied while flattening; Omit stays false so
+// SQLite still verifies it.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if c.Usable && c.Op == vtab.OpEQ && c.Column == colName {
+			c.ArgIndex = 0
+			info.IdxNum = 1
+			info.EstimatedCost = 1e3
+			return nil
+		}
+	}
+
+	info.EstimatedCost = 1e6
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+// sample is one flattened row.
+type sample struct {
+	name      string
+	typ       string
+	labels    string
+	value     float64
+	timestamp *int64
+	help      string
+}
+
+type cursor struct {
+	t       *table
+	samples []sample
+	i       int
+}
+
+// Filter implements vtab.Cursor. The metric families are gathered and
+// flattened once per scan.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	nameFilter := ""
+	if idxNum&1 != 0 {
+		switch x := vals[0].(type) {
+		case string:
+			nameFilter = x
+		case []byte:
+			nameFilter = string(x)
+		}
+	}
+
+	families, err := c.t.gather()
+	if err != nil {
+		return err
+	}
+
+	c.samples = c.samples[:0]
+	c.i = 0
+	for _, mf := range families {
+		if nameFilter != "" && !matchesName(mf.GetName(), nameFilter) {
+			continue
+		}
+		c.flatten(mf)
+	}
+	return nil
+}
+
+// matchesName reports whether a family can produce rows named filter: the
+// family name itself or, for histograms and summaries, its _bucket, _sum and
+// _count series.
+func matchesName(family, filter string) bool {
+	if family == filter {
+		return true
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if family+suffix == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten appends the rows of one metric family, expanding histograms and
+// summaries like the text exposition format does.
+func (c *cursor) flatten(mf *dto.MetricFamily) {
+	name := mf.GetName()
+	typ := strings.ToLower(mf.GetType().String())
+	help := mf.GetHelp()
+	for _, m := range mf.GetMetric() {
+		labels := make(map[string]string, len(m.GetLabel()))
+		for _, lp := range m.GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+
+		add := func(name string, extra map[string]string, value float64) {
+			c.samples = append(c.samples, sample{
+				name:      name,
+				typ:       typ,
+				labels:    labelsJSON(labels, extra),
+				value:     value,
+				timestamp: m.TimestampMs,
+				help:      help,
+			})
+		}
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			add(name, nil, m.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			add(name, nil, m.GetGauge().GetValue())
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			for _, q := range s.GetQuantile() {
+				add(name, map[string]string{"quantile": formatFloat(q.GetQuantile())}, q.GetValue())
+			}
+			add(name+"_sum", nil, s.GetSampleSum())
+			add(name+"_count", nil, float64(s.GetSampleCount()))
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			for _, b := range h.GetBucket() {
+				add(name+"_bucket", map[string]string{"le": formatFloat(b.GetUpperBound())},
+					float64(b.GetCumulativeCount()))
+			}
+			add(name+"_sum", nil, h.GetSampleSum())
+			add(name+"_count", nil, float64(h.GetSampleCount()))
+		default:
+			add(name, nil, m.GetUntyped().GetValue())
+		}
+	}
+}
+
+// labelsJSON renders the label set, plus any synthesized labels, as a JSON
+// object with sorted keys.
+func labelsJSON(labels, extra map[string]string) string {
+	merged := labels
+	if len(extra) > 0 {
+		merged = make(map[string]string, len(labels)+len(extra))
+		for k, v := range labels {
+			merged[k] = v
+		}
+		for k, v := range extra {
+			merged[k] = v
+		}
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= len(c.samples) }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	s := &c.samples[c.i]
+	switch col {
+	case colName:
+		return s.name, nil
+	case colType:
+		return s.typ, nil
+	case colLabels:
+		return s.labels, nil
+	case colValue:
+		return s.value, nil
+	case colTimestamp:
+		if s.timestamp == nil {
+			return nil, nil
+		}
+		return *s.timestamp, nil
+	case colHelp:
+		if s.help == "" {
+			return nil, nil
+		}
+		return s.help, nil
+	}
+	return nil, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return int64(c.i + 1), nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
@@ -0,0 +1,166 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prom // import "github.com/syralon/sqlite/vtab/prom"
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	_ "github.com/syralon/sqlite"
+)
+
+const scrapeText = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="get",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"} 3 1395066363000
+# TYPE temperature_celsius gauge
+temperature_celsius 21.5
+# HELP request_duration_seconds A histogram of request durations.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 240
+request_duration_seconds_bucket{le="1"} 290
+request_duration_seconds_bucket{le="+Inf"} 300
+request_duration_seconds_sum 42.5
+request_duration_seconds_count 300
+`
+
+func TestPromScrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, scrapeText)
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/prom.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "prom"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table metrics using prom(url='%s/metrics')", srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counter samples carry their labels as JSON and their timestamp.
+	var value float64
+	var ts int64
+	if err := db.QueryRow(`select value, timestamp from metrics
+		where name = 'http_requests_total'
+		and json_extract(labels, '$.method') = 'get'`).Scan(&value, &ts); err != nil {
+		t.Fatal(err)
+	}
+
+	if value != 1027 || ts != 1395066363000 {
+		t.Fatalf("got value %v timestamp %d, want 1027 1395066363000", value, ts)
+	}
+
+	// Untimestamped samples read as NULL; HELP text is exposed.
+	var n int
+	if err := db.QueryRow(`select count(*) from metrics
+		where name = 'temperature_celsius' and timestamp is null`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %d temperature rows, want 1", n)
+	}
+
+	var help string
+	if err := db.QueryRow("select help from metrics where name = 'http_requests_total' limit 1").Scan(&help); err != nil {
+		t.Fatal(err)
+	}
+
+	if help != "The total number of HTTP requests." {
+		t.Fatalf("help = %q", help)
+	}
+
+	// Histograms flatten into _bucket/_sum/_count rows; the name filter
+	// still finds the expanded series.
+	var count float64
+	if err := db.QueryRow(`select value from metrics
+		where name = 'request_duration_seconds_bucket'
+		and json_extract(labels, '$.le') = '+Inf'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 300 {
+		t.Fatalf("+Inf bucket = %v, want 300", count)
+	}
+
+	if err := db.QueryRow("select value from metrics where name = 'request_duration_seconds_count'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 300 {
+		t.Fatalf("_count = %v, want 300", count)
+	}
+}
+
+// staticGatherer satisfies Gatherer with a fixed family list.
+type staticGatherer []*dto.MetricFamily
+
+func (g staticGatherer) Gather() ([]*dto.MetricFamily, error) { return g, nil }
+
+func strp(s string) *string                  { return &s }
+func floatp(f float64) *float64              { return &f }
+func typep(t dto.MetricType) *dto.MetricType { return &t }
+
+func TestPromGatherer(t *testing.T) {
+	g := staticGatherer{
+		{
+			Name: strp("queue_depth"),
+			Type: typep(dto.MetricType_GAUGE),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{{Name: strp("queue"), Value: strp("mail")}},
+					Gauge: &dto.Gauge{Value: floatp(17)},
+				},
+				{
+					Label: []*dto.LabelPair{{Name: strp("queue"), Value: strp("jobs")}},
+					Gauge: &dto.Gauge{Value: floatp(3)},
+				},
+			},
+		},
+	}
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/gatherer.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := RegisterGatherer(db, "prom_self", g); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table self using prom_self"); err != nil {
+		t.Fatal(err)
+	}
+
+	var queue string
+	if err := db.QueryRow(`select json_extract(labels, '$.queue') from self
+		where name = 'queue_depth' order by value desc limit 1`).Scan(&queue); err != nil {
+		t.Fatal(err)
+	}
+
+	if queue != "mail" {
+		t.Fatalf("deepest queue = %q, want mail", queue)
+	}
+
+	// A gatherer-backed table takes no url.
+	if _, err := db.Exec("create virtual table bad using prom_self(url='http://x')"); err == nil {
+		t.Fatal("expected an error for a url on a gatherer-backed table")
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rest provides a read-only virtual table over a paginated JSON REST
+// endpoint, so external APIs can be joined against local tables.
+//
+//	CREATE VIRTUAL TABLE users USING rest(url='https://api.example.com/users');
+//	SELECT u.name, count(*) FROM orders o JOIN users u ON u.id = o.user_id;
+//
+// The endpoint must return a JSON array of flat objects, or an object whose
+// rows_field member holds that array. Columns are inferred from the keys of
+// the first page, or fixed with an explicit columns argument. Scans request
+// pages by incrementing a page query parameter (page_param, default "page",
+// starting at 1) until the endpoint returns an empty page.
+//
+// Equality constraints are translated into query parameters of the same
+// name, and a LIMIT is forwarded through limit_param (default "limit"), so
+// the endpoint can filter server-side; rows are still verified locally. An
+// auth_header argument of the form 'Name: value' is attached to every
+// request:
+//
+//	CREATE VIRTUAL TABLE users USING rest(url='https://api.example.com/users',
+//	    auth_header='Authorization: Bearer t0ken');
+//
+// RegisterClient registers a module that issues its requests through a
+// caller-supplied *http.Client, for custom transports or timeouts.
+package rest // import "github.com/syralon/sqlite/vtab/rest"
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Module implements vtab.Module for REST-backed tables.
+type Module struct {
+	client *http.Client
+}
+
+// Register registers a REST module named name using http.DefaultClient.
+// Registration applies to new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return RegisterClient(db, name, http.DefaultClient)
+}
+
+// RegisterClient registers a REST module named name issuing its requests
+// through client.
+func RegisterClient(db *sql.DB, name string, client *http.Client) error {
+	return vtab.RegisterModule(db, name, &Module{client: client})
+}
+
+type options struct {
+	url        string
+	columns    []string
+	rowsField  string
+	pageParam  string
+	limitParam string
+	authName   string
+	authValue  string
+}
+
+func parseOptions(args []string) (options, error) {
+	opts := options{pageParam: "page", limitParam: "limit"}
+	for _, a := range args {
+		k, v, _ := strings.Cut(a, "=")
+		k = strings.TrimSpace(k)
+		v = unquote(strings.TrimSpace(v))
+		switch k {
+		case "url":
+			opts.url = v
+		case "columns":
+			for _, col := range strings.Split(v, ",") {
+				col = strings.TrimSpace(col)
+				if col == "" {
+					return opts, fmt.Errorf("rest: empty column name in %q", v)
+				}
+				opts.columns = append(opts.columns, col)
+			}
+		case "rows_field":
+			opts.rowsField = v
+		case "page_param":
+			opts.pageParam = v
+		case "limit_param":
+			opts.limitParam = v
+		case "auth_header":
+			name, value, ok := strings.Cut(v, ":")
+			if !ok {
+				return opts, fmt.Errorf("rest: auth_header must look like 'Name: value'")
+			}
+			opts.authName = strings.TrimSpace(name)
+			opts.authValue = strings.TrimSpace(value)
+		default:
+			return opts, fmt.Errorf("rest: unknown option %q", k)
+		}
+	}
+	if opts.url == "" {
+		return opts, fmt.Errorf("rest: the url option is required")
+	}
+	if _, err := url.Parse(opts.url); err != nil {
+		return opts, fmt.Errorf("rest: invalid url: %w", err)
+	}
+	return opts, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	opts, err := parseOptions(args[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{m: m, opts: opts}
+	if len(t.opts.columns) == 0 {
+		if err := t.inferColumns(); err != nil {
+			return nil, err
+		}
+	}
+	if len(t.opts.columns) == 0 {
+		return nil, fmt.Errorf("rest: no columns could be inferred from %s", opts.url)
+	}
+
+	decl := make([]string, len(t.opts.columns))
+	for i, col := range t.opts.columns {
+		decl[i] = strconv.Quote(col)
+	}
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(decl, ", "))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct {
+	m    *Module
+	opts options
+}
+
+// get requests one page with the given extra query parameters and returns
+// the raw JSON array of rows, unwrapping the rows_field envelope when one is
+// configured.
+func (t *table) get(page int, params url.Values) (json.RawMessage, error) {
+	u, err := url.Parse(t.opts.url)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			q.Set(k, v)
+		}
+	}
+	q.Set(t.opts.pageParam, strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if t.opts.authName != "" {
+		req.Header.Set(t.opts.authName, t.opts.authValue)
+	}
+
+	resp, err := t.m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rest: GET %s: %s", u, resp.Status)
+	}
+
+	raw := json.RawMessage(bytes.TrimSpace(body))
+	if t.opts.rowsField != "" {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("rest: GET %s: %w", u, err)
+		}
+		var ok bool
+		if raw, ok = envelope[t.opts.rowsField]; !ok {
+			return nil, fmt.Errorf("rest: GET %s: response has no %q member", u, t.opts.rowsField)
+		}
+	}
+	return raw, nil
+}
+
+// fetchPage requests one page and returns its decoded rows.
+func (t *table) fetchPage(page int, params url.Values) ([]map[string]json.RawMessage, error) {
+	raw, err := t.get(page, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowsData []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rowsData); err != nil {
+		return nil, fmt.Errorf("rest: GET %s: %w", t.opts.url, err)
+	}
+	return rowsData, nil
+}
+
+// inferColumns fetches the first page and collects the keys of its objects
+// in order of first appearance.
+func (t *table) inferColumns() error {
+	raw, err := t.get(1, nil)
+	if err != nil {
+		return err
+	}
+
+	u := t.opts.url
+
+	// Decode with key order preserved.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("rest: GET %s: %w", u, err)
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("rest: GET %s: response is not a JSON array", u)
+	}
+
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("rest: GET %s: %w", u, err)
+		}
+		if tok != json.Delim('{') {
+			return fmt.Errorf("rest: GET %s: rows are not JSON objects", u)
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("rest: GET %s: %w", u, err)
+			}
+			k := key.(string)
+			if !seen[k] {
+				seen[k] = true
+				t.opts.columns = append(t.opts.columns, k)
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("rest: GET %s: %w", u, err)
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("rest: GET %s: %w", u, err)
+		}
+	}
+	return nil
+}
+
+// BestIndex implements vtab.Table. Equality constraints become query
+// parameters and a LIMIT is forwarded to the endpoint; Omit stays false for
+// the equalities so SQLite still verifies them against endpoints that filter
+// loosely or not at all.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	arg := 0
+	var pushed []string
+	limit := false
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable {
+			continue
+		}
+		switch {
+		case c.Op == vtab.OpEQ && c.Column >= 0 && c.Column < len(t.opts.columns):
+			c.ArgIndex = arg
+			pushed = append(pushed, strconv.Itoa(c.Column))
+			arg++
+		case c.Op == vtab.OpLIMIT && !limit:
+			c.ArgIndex = arg
+			c.Omit = true
+			limit = true
+			arg++
+		}
+	}
+
+	info.IdxStr = strings.Join(pushed, ",")
+	if limit {
+		info.IdxNum = 1
+	}
+	info.EstimatedCost = 1e6
+	if arg > 0 {
+		info.EstimatedCost /= float64(arg + 1)
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t *table) Destroy() error { return nil }
+
+type cursor struct {
+	t *table
+
+	params url.Values
+	filter map[int]vtab.Value
+	// limit is the remaining number of rows to emit, or -1 when unlimited.
+	limit int64
+
+	page  int
+	rows  []map[string]json.RawMessage
+	i     int
+	rowid int64
+	eof   bool
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.params = make(url.Values)
+	c.filter = make(map[int]vtab.Value)
+	c.limit = -1
+
+	cols := strings.Split(idxStr, ",")
+	if idxStr == "" {
+		cols = nil
+	}
+	for i, s := range cols {
+		col, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("rest: bad idxStr %q", idxStr)
+		}
+		c.filter[col] = vals[i]
+		c.params.Set(c.t.opts.columns[col], paramString(vals[i]))
+	}
+	if idxNum&1 != 0 {
+		if n, ok := vals[len(cols)].(int64); ok && n >= 0 {
+			c.limit = n
+			if c.t.opts.limitParam != "" {
+				c.params.Set(c.t.opts.limitParam, strconv.FormatInt(n, 10))
+			}
+		}
+	}
+
+	c.page = 0
+	c.rows = nil
+	c.i = 0
+	c.rowid = 0
+	c.eof = false
+	return c.Next()
+}
+
+func paramString(v vtab.Value) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// Next implements vtab.Cursor. Pages are fetched on demand; rows not
+// matching the pushed-down equalities are skipped.
+func (c *cursor) Next() error {
+	for {
+		if c.limit == 0 {
+			c.eof = true
+			return nil
+		}
+
+		for c.i >= len(c.rows) {
+			c.page++
+			rows, err := c.t.fetchPage(c.page, c.params)
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				c.eof = true
+				return nil
+			}
+			c.rows, c.i = rows, 0
+		}
+
+		row := c.rows[c.i]
+		c.i++
+		c.rowid++
+		if c.matches(row) {
+			if c.limit > 0 {
+				c.limit--
+			}
+			return nil
+		}
+	}
+}
+
+func (c *cursor) matches(row map[string]json.RawMessage) bool {
+	for col, want := range c.filter {
+		got, err := value(row, c.t.opts.columns[col])
+		if err != nil || !valueEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueEqual compares a decoded column value with a constraint value
+// conservatively; SQLite re-checks the constraint anyway.
+func valueEqual(a, b vtab.Value) bool {
+	if ai, ok := a.(int64); ok {
+		if bf, ok := b.(float64); ok {
+			return float64(ai) == bf
+		}
+	}
+	if af, ok := a.(float64); ok {
+		if bi, ok := b.(int64); ok {
+			return af == float64(bi)
+		}
+	}
+	if ab, ok := a.([]byte); ok {
+		if bs, ok := b.(string); ok {
+			return string(ab) == bs
+		}
+	}
+	if as, ok := a.(string); ok {
+		if bb, ok := b.([]byte); ok {
+			return as == string(bb)
+		}
+	}
+	return a == b
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.eof }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	if col < 0 || col >= len(c.t.opts.columns) || c.i == 0 {
+		return nil, nil
+	}
+	return value(c.rows[c.i-1], c.t.opts.columns[col])
+}
+
+// value maps a JSON member to its driver value: strings and numbers map
+// directly, booleans to 0/1, null and missing keys to NULL, and nested
+// objects and arrays to their JSON text.
+func value(row map[string]json.RawMessage, key string) (vtab.Value, error) {
+	raw, ok := row[key]
+	if !ok || string(raw) == "null" || len(raw) == 0 {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case 't':
+		return int64(1), nil
+	case 'f':
+		return int64(0), nil
+	case '{', '[':
+		return string(raw), nil
+	}
+
+	if i, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return i, nil
+	}
+	var f float64
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.rowid, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
@@ -0,0 +1,235 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rest // import "github.com/syralon/sqlite/vtab/rest"
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+type user struct {
+	ID   int64   `json:"id"`
+	Name string  `json:"name"`
+	City *string `json:"city"`
+}
+
+func str(s string) *string { return &s }
+
+// usersHandler serves a paginated, filterable user list and counts requests.
+func usersHandler(users []user, pageSize int, requests *atomic.Int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("X-Token") != "sesame" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		matched := users
+		if name := r.URL.Query().Get("name"); name != "" {
+			matched = nil
+			for _, u := range users {
+				if u.Name == name {
+					matched = append(matched, u)
+				}
+			}
+		}
+
+		size := pageSize
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			if n, err := strconv.Atoi(limit); err == nil && n < size {
+				size = n
+			}
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		lo := (page - 1) * size
+		hi := lo + size
+		if lo > len(matched) {
+			lo = len(matched)
+		}
+		if hi > len(matched) {
+			hi = len(matched)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(matched[lo:hi])
+	}
+}
+
+func TestREST(t *testing.T) {
+	users := []user{
+		{ID: 1, Name: "alice", City: str("Oslo")},
+		{ID: 2, Name: "bob", City: nil},
+		{ID: 3, Name: "carol", City: str("Lima")},
+		{ID: 4, Name: "bob", City: str("Kyiv")},
+		{ID: 5, Name: "erin", City: str("Bern")},
+	}
+	var requests atomic.Int64
+	srv := httptest.NewServer(usersHandler(users, 2, &requests))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/rest.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "rest"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(
+		"create virtual table users using rest(url=%q, auth_header='X-Token: sesame')", srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Columns come from the first page, in order.
+	rows, err := db.Query("select * from users limit 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "id,name,city"; strings.Join(cols, ",") != want {
+		t.Fatalf("columns = %v, want %s", cols, want)
+	}
+
+	// A full scan pages through the whole list.
+	var n int
+	if err := db.QueryRow("select count(*) from users").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 5 {
+		t.Fatalf("got %d users, want 5", n)
+	}
+
+	// An equality constraint becomes a query parameter and the endpoint
+	// filters server-side.
+	var ids []int64
+	rows, err = db.Query("select id from users where name = 'bob' order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 4 {
+		t.Fatalf("got ids %v, want [2 4]", ids)
+	}
+
+	// Missing members are NULL.
+	if err := db.QueryRow("select count(*) from users where city is null").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %d users with NULL city, want 1", n)
+	}
+
+	// A LIMIT stops paging: one page of one row plus the empty page that
+	// ends the scan.
+	requests.Store(0)
+	var name string
+	if err := db.QueryRow("select name from users limit 1").Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "alice" {
+		t.Fatalf("name = %q, want alice", name)
+	}
+
+	if got := requests.Load(); got > 2 {
+		t.Fatalf("LIMIT 1 issued %d requests, want at most 2", got)
+	}
+}
+
+func TestRESTEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, `{"total": 2, "data": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"total": 2, "data": [{"id": 1, "tags": ["a", "b"]}, {"id": 2, "tags": []}]}`)
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/envelope.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "rest_env"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(
+		"create virtual table items using rest_env(url=%q, rows_field='data')", srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nested values surface as JSON text.
+	var tag string
+	if err := db.QueryRow("select json_extract(tags, '$[1]') from items where id = 1").Scan(&tag); err != nil {
+		t.Fatal(err)
+	}
+
+	if tag != "b" {
+		t.Fatalf("tag = %q, want b", tag)
+	}
+}
+
+func TestRESTErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/errors.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "rest_err"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table bad using rest_err"); err == nil {
+		t.Fatal("expected an error without a url")
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table bad using rest_err(url=%q)", srv.URL)); err == nil {
+		t.Fatal("expected an error for a failing endpoint")
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package runtimestats provides virtual tables over the Go runtime of the
+// current process, so diagnostic SQL dashboards can be built on a live
+// program.
+//
+//	CREATE VIRTUAL TABLE memstats USING memstats;
+//	CREATE VIRTUAL TABLE goroutines USING goroutines;
+//	CREATE VIRTUAL TABLE gc_pauses USING gc_pauses;
+//
+//	SELECT value FROM memstats WHERE name = 'HeapAlloc';
+//	SELECT state, count(*) FROM goroutines GROUP BY state;
+//	SELECT max(pause_ns) FROM gc_pauses;
+//
+// The memstats table has one name/value row per scalar runtime.MemStats
+// field, captured at the start of each scan. The goroutines table has one
+// row per goroutine with its id, its state as reported by the scheduler and
+// its full stack trace. The gc_pauses table lists the most recent garbage
+// collection pauses, newest first, with their end time in nanoseconds since
+// the epoch.
+package runtimestats // import "github.com/syralon/sqlite/vtab/runtimestats"
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Register registers the three modules under their default names: memstats,
+// goroutines and gc_pauses. Registration applies to new connections only;
+// see vtab.RegisterModule.
+func Register(db *sql.DB) error {
+	if err := RegisterMemStats(db, "memstats"); err != nil {
+		return err
+	}
+	if err := RegisterGoroutines(db, "goroutines"); err != nil {
+		return err
+	}
+	return RegisterGCPauses(db, "gc_pauses")
+}
+
+// RegisterMemStats registers the runtime.MemStats module named name.
+func RegisterMemStats(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, module{
+		schema: "CREATE TABLE x(name TEXT, value)",
+		rows:   memStatsRows,
+	})
+}
+
+// RegisterGoroutines registers the goroutine module named name.
+func RegisterGoroutines(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, module{
+		schema: "CREATE TABLE x(id INTEGER, state TEXT, stack TEXT)",
+		rows:   goroutineRows,
+	})
+}
+
+// RegisterGCPauses registers the GC pause module named name.
+func RegisterGCPauses(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, module{
+		schema: "CREATE TABLE x(seq INTEGER, end_ns INTEGER, pause_ns INTEGER)",
+		rows:   gcPauseRows,
+	})
+}
+
+// module implements vtab.Module for one runtime table: a fixed schema and a
+// snapshot function invoked at the start of each scan.
+type module struct {
+	schema string
+	rows   func() ([][]vtab.Value, error)
+}
+
+// Create implements vtab.Module.
+func (m module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if len(args) > 3 {
+		return nil, fmt.Errorf("runtimestats: %s takes no arguments", args[0])
+	}
+	if err := ctx.Declare(m.schema); err != nil {
+		return nil, err
+	}
+	return table{rows: m.rows}, nil
+}
+
+// Connect implements vtab.Module.
+func (m module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct {
+	rows func() ([][]vtab.Value, error)
+}
+
+// BestIndex implements vtab.Table.
+func (t table) BestIndex(info *vtab.IndexInfo) error {
+	info.EstimatedCost = 1e3
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t table) Open() (vtab.Cursor, error) { return &cursor{rows: t.rows}, nil }
+
+// Disconnect implements vtab.Table.
+func (t table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (t table) Destroy() error { return nil }
+
+type cursor struct {
+	rows func() ([][]vtab.Value, error)
+	data [][]vtab.Value
+	i    int
+}
+
+// Filter implements vtab.Cursor. The snapshot is taken once per scan.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	data, err := c.rows()
+	if err != nil {
+		return err
+	}
+	c.data, c.i = data, 0
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= len(c.data) }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	row := c.data[c.i]
+	if col < 0 || col >= len(row) {
+		return nil, nil
+	}
+	return row[col], nil
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return int64(c.i + 1), nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
+
+// memStatsRows snapshots runtime.MemStats and emits one row per scalar
+// field, skipping the per-size-class and circular pause buffers, which have
+// tables of their own.
+func memStatsRows() ([][]vtab.Value, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	v := reflect.ValueOf(ms)
+	t := v.Type()
+	var rows [][]vtab.Value
+	for i := 0; i < t.NumField(); i++ {
+		var value vtab.Value
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Uint32, reflect.Uint64:
+			value = int64(f.Uint())
+		case reflect.Float64:
+			value = f.Float()
+		case reflect.Bool:
+			value = int64(0)
+			if f.Bool() {
+				value = int64(1)
+			}
+		default:
+			continue
+		}
+		rows = append(rows, []vtab.Value{t.Field(i).Name, value})
+	}
+	return rows, nil
+}
+
+// goroutineRows captures the stacks of all goroutines and parses the
+// "goroutine N [state]:" header of each into a row.
+func goroutineRows() ([][]vtab.Value, error) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var rows [][]vtab.Value
+	for _, block := range strings.Split(string(buf), "\n\n") {
+		header, _, _ := strings.Cut(block, "\n")
+		rest := strings.TrimPrefix(header, "goroutine ")
+		idStr, state, ok := strings.Cut(rest, " [")
+		if rest == header || !ok {
+			continue
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		state = strings.TrimSuffix(state, ":")
+		state = strings.TrimSuffix(state, "]")
+		rows = append(rows, []vtab.Value{id, state, block})
+	}
+	return rows, nil
+}
+
+// gcPauseRows lists the most recent GC pauses, newest first. seq is the
+// collection number, counting from 1.
+func gcPauseRows() ([][]vtab.Value, error) {
+	var stats debug.GCStats
+	debug.ReadGCStats(&stats)
+
+	var rows [][]vtab.Value
+	for i, pause := range stats.Pause {
+		var end vtab.Value
+		if i < len(stats.PauseEnd) {
+			end = stats.PauseEnd[i].UnixNano()
+		}
+		rows = append(rows, []vtab.Value{stats.NumGC - int64(i), end, pause.Nanoseconds()})
+	}
+	return rows, nil
+}
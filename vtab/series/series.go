@@ -0,0 +1,199 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package series provides a ready-made generate_series virtual table,
+// mirroring the canonical series.c extension. A series table exposes one
+// visible column, value, plus the hidden columns start, stop and step, so it
+// can be queried with table-valued function syntax:
+//
+//	CREATE VIRTUAL TABLE gs USING generate_series;
+//	SELECT value FROM gs(1, 10);        -- 1..10
+//	SELECT value FROM gs(0, 100, 25);   -- 0, 25, 50, 75, 100
+//
+// Constraints on the hidden columns are equivalent to the positional
+// arguments: SELECT value FROM gs WHERE start=1 AND stop=10.
+package series // import "github.com/syralon/sqlite/vtab/series"
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Column indexes of the declared schema.
+const (
+	colValue = iota
+	colStart
+	colStop
+	colStep
+)
+
+// Bits of IdxNum recording which arguments BestIndex arranged to receive.
+const (
+	idxStart = 1 << iota
+	idxStop
+	idxStep
+)
+
+// Module implements vtab.Module for series tables.
+type Module struct{}
+
+// Register registers a generate_series module named name. Registration
+// applies to new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, Module{})
+}
+
+// Create implements vtab.Module.
+func (Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(value, start HIDDEN, stop HIDDEN, step HIDDEN)"); err != nil {
+		return nil, err
+	}
+	return table{}, nil
+}
+
+// Connect implements vtab.Module.
+func (m Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type table struct{}
+
+// BestIndex implements vtab.Table. Equality constraints on the hidden
+// columns become the Filter arguments; a fully bounded series is cheap while
+// an unbounded one carries a deterrent cost, mirroring series.c.
+func (table) BestIndex(info *vtab.IndexInfo) error {
+	var idxNum int64
+	arg := 0
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Op != vtab.OpEQ {
+			continue
+		}
+
+		var bit int64
+		switch c.Column {
+		case colStart:
+			bit = idxStart
+		case colStop:
+			bit = idxStop
+		case colStep:
+			bit = idxStep
+		default:
+			continue
+		}
+		if idxNum&bit != 0 {
+			continue
+		}
+
+		idxNum |= bit
+		c.ArgIndex = arg
+		c.Omit = true
+		arg++
+	}
+
+	info.IdxNum = idxNum
+	if idxNum&idxStart != 0 && idxNum&idxStop != 0 {
+		info.EstimatedCost = 2
+		info.EstimatedRows = 1000
+	} else {
+		// An unbounded series is effectively infinite; make sure any other
+		// plan wins.
+		info.EstimatedCost = 2147483647
+		info.EstimatedRows = 2147483647
+	}
+	return nil
+}
+
+// Open implements vtab.Table.
+func (table) Open() (vtab.Cursor, error) { return &cursor{}, nil }
+
+// Disconnect implements vtab.Table.
+func (table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (table) Destroy() error { return nil }
+
+type cursor struct {
+	value int64
+	stop  int64
+	step  int64
+	n     int64 // rows produced, backs Rowid
+}
+
+// Filter implements vtab.Cursor. Missing arguments default to start 0, stop
+// 4294967295 and step 1, like series.c.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	start, stop, step := int64(0), int64(math.MaxUint32), int64(1)
+	arg := 0
+	next := func() (int64, error) {
+		v, ok := vals[arg].(int64)
+		if !ok {
+			return 0, fmt.Errorf("series: argument is not an integer: %v", vals[arg])
+		}
+		arg++
+		return v, nil
+	}
+
+	var err error
+	if idxNum&idxStart != 0 {
+		if start, err = next(); err != nil {
+			return err
+		}
+	}
+	if idxNum&idxStop != 0 {
+		if stop, err = next(); err != nil {
+			return err
+		}
+	}
+	if idxNum&idxStep != 0 {
+		if step, err = next(); err != nil {
+			return err
+		}
+		if step == 0 {
+			step = 1
+		}
+	}
+
+	c.value, c.stop, c.step, c.n = start, stop, step, 1
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.value += c.step
+	c.n++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool {
+	if c.step > 0 {
+		return c.value > c.stop
+	}
+	return c.value < c.stop
+}
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	switch col {
+	case colValue:
+		return c.value, nil
+	case colStart:
+		return c.value - (c.n-1)*c.step, nil
+	case colStop:
+		return c.stop, nil
+	case colStep:
+		return c.step, nil
+	}
+	return nil, fmt.Errorf("series: no such column: %d", col)
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.n, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
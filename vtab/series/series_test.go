@@ -0,0 +1,92 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package series // import "github.com/syralon/sqlite/vtab/series"
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestGenerateSeries(t *testing.T) {
+	db, err := sql.Open("sqlite3", t.TempDir()+"/series.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "generate_series"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table gs using generate_series"); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(query string, args ...any) []int64 {
+		t.Helper()
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+
+		defer rows.Close()
+
+		var vals []int64
+		for rows.Next() {
+			var v int64
+			if err := rows.Scan(&v); err != nil {
+				t.Fatal(err)
+			}
+			vals = append(vals, v)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return vals
+	}
+
+	// Table-valued function syntax with hidden columns as arguments.
+	if got, want := collect("select value from gs(1, 5)"), []int64{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("gs(1, 5) = %v, want %v", got, want)
+	}
+
+	if got, want := collect("select value from gs(0, 100, 25)"), []int64{0, 25, 50, 75, 100}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("gs(0, 100, 25) = %v, want %v", got, want)
+	}
+
+	// Negative step counts down.
+	if got, want := collect("select value from gs(3, 1, -1)"), []int64{3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("gs(3, 1, -1) = %v, want %v", got, want)
+	}
+
+	// Constraints on the hidden columns are equivalent.
+	if got, want := collect("select value from gs where start = ? and stop = ?", 4, 6), []int64{4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("where start/stop = %v, want %v", got, want)
+	}
+
+	// The hidden columns echo the series parameters.
+	var start, stop, step int64
+	if err := db.QueryRow("select start, stop, step from gs(7, 9) limit 1").Scan(&start, &stop, &step); err != nil {
+		t.Fatal(err)
+	}
+
+	if start != 7 || stop != 9 || step != 1 {
+		t.Fatalf("got start %d stop %d step %d, want 7 9 1", start, stop, step)
+	}
+
+	// Joins drive one series per outer row.
+	var n int64
+	if err := db.QueryRow("select count(*) from gs(1, 3) a, gs(1, 3) b").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 9 {
+		t.Fatalf("cross join count = %d, want 9", n)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package structs exposes in-process Go data as a virtual table, deriving
+// the schema from a struct type by reflection so no cursor plumbing has to
+// be written:
+//
+//	type Person struct {
+//		Name string `sqlite:"name"`
+//		Age  int    `sqlite:"age"`
+//		internal string // unexported fields are skipped
+//	}
+//
+//	people := []Person{{"alice", 30}, {"bob", 40}}
+//	structs.Register(db, "people_mod", people)
+//	db.Exec("CREATE VIRTUAL TABLE people USING people_mod")
+//	db.Query("SELECT name FROM people WHERE age > 35")
+//
+// Column names default to the field name; a `sqlite:"..."` tag overrides it
+// and `sqlite:"-"` skips the field. Integer, bool, float, string and []byte
+// fields map to INTEGER, REAL, TEXT and BLOB columns; pointers to those
+// types scan as NULL when nil.
+//
+// The data is read through reflection on every scan, so changes to the
+// elements of the registered slice are visible to later queries. Instead of
+// a slice, a Source can be registered to serve rows from any backing store.
+package structs // import "github.com/syralon/sqlite/vtab/structs"
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Source serves rows dynamically. Len reports the current number of rows and
+// Row returns the i-th row as a value of the struct type the module was
+// built from (or a pointer to it).
+type Source interface {
+	Len() int
+	Row(i int) any
+}
+
+// Module implements vtab.Module over a slice of structs or a Source.
+type Module struct {
+	slice  reflect.Value // valid unless source is set
+	source Source
+	cols   []column
+}
+
+type column struct {
+	name  string
+	index []int
+	typ   string // declared column type
+}
+
+// NewModule builds a module serving data, which must be a slice of structs
+// (or of pointers to structs). Use NewSourceModule to serve rows from a
+// Source instead.
+func NewModule(data any) (*Module, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("structs: want a slice, got %T", data)
+	}
+
+	cols, err := columnsOf(v.Type().Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &Module{slice: v, cols: cols}, nil
+}
+
+// NewSourceModule builds a module serving the rows of src. prototype fixes
+// the struct type rows will have, so the schema is known even while src is
+// empty.
+func NewSourceModule(src Source, prototype any) (*Module, error) {
+	cols, err := columnsOf(reflect.TypeOf(prototype))
+	if err != nil {
+		return nil, err
+	}
+	return &Module{source: src, cols: cols}, nil
+}
+
+// Register derives a module from data - a struct slice or a Source paired
+// with its prototype is accepted through NewModule/NewSourceModule - and
+// registers it under name. Registration applies to new connections only; see
+// vtab.RegisterModule.
+func Register(db *sql.DB, name string, data any) error {
+	m, err := NewModule(data)
+	if err != nil {
+		return err
+	}
+	return vtab.RegisterModule(db, name, m)
+}
+
+// columnsOf derives the column list from a struct type.
+func columnsOf(t reflect.Type) ([]column, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("structs: want a struct element type, got %s", t)
+	}
+
+	var cols []column
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("sqlite"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+
+		typ, err := columnType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("structs: field %s: %w", f.Name, err)
+		}
+
+		cols = append(cols, column{name: name, index: f.Index, typ: typ})
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("structs: %s has no usable fields", t)
+	}
+	return cols, nil
+}
+
+func columnType(t reflect.Type) (string, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Bool:
+		return "INTEGER", nil
+	case reflect.Float32, reflect.Float64:
+		return "REAL", nil
+	case reflect.String:
+		return "TEXT", nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BLOB", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// Create implements vtab.Module.
+func (m *Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	decl := make([]string, len(m.cols))
+	for i, c := range m.cols {
+		decl[i] = fmt.Sprintf("%q %s", c.name, c.typ)
+	}
+	if err := ctx.Declare(fmt.Sprintf("CREATE TABLE x(%s)", strings.Join(decl, ", "))); err != nil {
+		return nil, err
+	}
+	return table{m: m}, nil
+}
+
+// Connect implements vtab.Module.
+func (m *Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func (m *Module) len() int {
+	if m.source != nil {
+		return m.source.Len()
+	}
+	return m.slice.Len()
+}
+
+func (m *Module) row(i int) reflect.Value {
+	var v reflect.Value
+	if m.source != nil {
+		v = reflect.ValueOf(m.source.Row(i))
+	} else {
+		v = m.slice.Index(i)
+	}
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	return v
+}
+
+type table struct {
+	m *Module
+}
+
+// BestIndex implements vtab.Table.
+func (t table) BestIndex(info *vtab.IndexInfo) error {
+	info.EstimatedCost = float64(t.m.len() + 1)
+	info.EstimatedRows = int64(t.m.len() + 1)
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t table) Open() (vtab.Cursor, error) { return &cursor{m: t.m}, nil }
+
+// Disconnect implements vtab.Table.
+func (table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table.
+func (table) Destroy() error { return nil }
+
+type cursor struct {
+	m *Module
+	i int
+	n int
+}
+
+// Filter implements vtab.Cursor.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.i, c.n = 0, c.m.len()
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= c.n }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	f := c.m.row(c.i).FieldByIndex(c.m.cols[col].index)
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			return nil, nil
+		}
+		f = f.Elem()
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint()), nil
+	case reflect.Bool:
+		if f.Bool() {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case reflect.Float32, reflect.Float64:
+		return f.Float(), nil
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Slice:
+		return f.Bytes(), nil
+	}
+	return nil, fmt.Errorf("structs: unsupported column kind %s", f.Kind())
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return int64(c.i) + 1, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
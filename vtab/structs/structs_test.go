@@ -0,0 +1,155 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package structs // import "github.com/syralon/sqlite/vtab/structs"
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+	"github.com/syralon/sqlite/vtab"
+)
+
+type person struct {
+	Name     string `sqlite:"name"`
+	Age      int    `sqlite:"age"`
+	Score    float64
+	Active   bool
+	Photo    []byte
+	Nick     *string
+	Secret   string `sqlite:"-"`
+	internal int
+}
+
+func TestStructSlice(t *testing.T) {
+	nick := "al"
+	people := []person{
+		{Name: "alice", Age: 30, Score: 9.5, Active: true, Photo: []byte{1, 2}, Nick: &nick, Secret: "x"},
+		{Name: "bob", Age: 40, Score: 7.25},
+	}
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/structs.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "people_mod", people); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table people using people_mod"); err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	var age int
+	var score float64
+	var active int
+	var photo []byte
+	var nickCol *string
+	if err := db.QueryRow("select name, age, Score, Active, Photo, Nick from people where age = 30").
+		Scan(&name, &age, &score, &active, &photo, &nickCol); err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "alice" || age != 30 || score != 9.5 || active != 1 || len(photo) != 2 || nickCol == nil || *nickCol != "al" {
+		t.Fatalf("unexpected row: %v %v %v %v %v %v", name, age, score, active, photo, nickCol)
+	}
+
+	// Nil pointers scan as NULL; the tagged-out column does not exist.
+	if err := db.QueryRow("select Nick from people where name = 'bob'").Scan(&nickCol); err != nil {
+		t.Fatal(err)
+	}
+
+	if nickCol != nil {
+		t.Fatalf("bob's Nick = %v, want NULL", *nickCol)
+	}
+
+	if _, err := db.Query("select Secret from people"); err == nil {
+		t.Fatal("expected an error selecting a skipped column")
+	}
+
+	// Element mutations are visible to later scans.
+	people[1].Age = 41
+	if err := db.QueryRow("select age from people where name = 'bob'").Scan(&age); err != nil {
+		t.Fatal(err)
+	}
+
+	if age != 41 {
+		t.Fatalf("got age %d after mutation, want 41", age)
+	}
+
+	// Affinity comes from the field types.
+	var typ string
+	if err := db.QueryRow("select typeof(age) from people limit 1").Scan(&typ); err != nil {
+		t.Fatal(err)
+	}
+
+	if typ != "integer" {
+		t.Fatalf("typeof(age) = %q, want integer", typ)
+	}
+
+	// Unsupported data is rejected at registration time.
+	if _, err := NewModule(42); err == nil {
+		t.Fatal("expected an error for a non-slice")
+	}
+
+	if _, err := NewModule([]struct{ C chan int }{}); err == nil {
+		t.Fatal("expected an error for an unsupported field type")
+	}
+}
+
+// growingSource serves an adjustable number of rows.
+type growingSource struct {
+	rows []person
+}
+
+func (s *growingSource) Len() int      { return len(s.rows) }
+func (s *growingSource) Row(i int) any { return &s.rows[i] }
+
+var _ Source = (*growingSource)(nil)
+
+func TestSource(t *testing.T) {
+	src := &growingSource{}
+	m, err := NewSourceModule(src, person{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", t.TempDir()+"/source.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := vtab.RegisterModule(db, "people_src", m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table live using people_src"); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from live").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("got %d rows from an empty source, want 0", n)
+	}
+
+	src.rows = append(src.rows, person{Name: "carol", Age: 25})
+	if err := db.QueryRow("select count(*) from live").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("got %d rows after append, want 1", n)
+	}
+}
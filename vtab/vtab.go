@@ -6,6 +6,36 @@ import (
 	"errors"
 )
 
+// Error is an error carrying an explicit SQLite result code. Returning one
+// (possibly wrapped) from a module, table or cursor method makes the bridge
+// report Code to SQLite instead of the generic SQLITE_ERROR, so a writable
+// module can signal a constraint violation from Insert — which combines
+// with ConfigConstraintSupport to give OR IGNORE/OR REPLACE their proper
+// meaning — or a remote-backed module can surface SQLITE_BUSY and let the
+// caller retry. See the Err* constants for common codes.
+type Error struct {
+	Code int
+	Msg  string
+}
+
+// Error implements error.
+func (e *Error) Error() string { return e.Msg }
+
+// Common result codes for Error.Code. The values mirror the corresponding
+// SQLITE_* primary result codes.
+const (
+	ErrError      = 1  // SQLITE_ERROR
+	ErrBusy       = 5  // SQLITE_BUSY
+	ErrNoMem      = 7  // SQLITE_NOMEM
+	ErrReadOnly   = 8  // SQLITE_READONLY
+	ErrIO         = 10 // SQLITE_IOERR
+	ErrNotFound   = 12 // SQLITE_NOTFOUND
+	ErrFull       = 13 // SQLITE_FULL
+	ErrConstraint = 19 // SQLITE_CONSTRAINT
+	ErrMismatch   = 20 // SQLITE_MISMATCH
+	ErrAuth       = 23 // SQLITE_AUTH
+)
+
 // Value is the value type passed to and from virtual table cursors. It
 // aliases database/sql/driver.Value to avoid exposing low-level details to
 // module authors while remaining compatible with the driver.
@@ -54,6 +84,38 @@ func (c Context) Config(op int32, args ...int32) error {
 	return c.config(op, args...)
 }
 
+// ConfigOption names an sqlite3_vtab_config option. The values mirror the
+// SQLITE_VTAB_* constants.
+type ConfigOption int32
+
+const (
+	// ConfigConstraintSupport declares that xUpdate honors ON CONFLICT
+	// resolution (SQLITE_VTAB_CONSTRAINT_SUPPORT); writable modules should
+	// set it to get proper ABORT/IGNORE/FAIL/ROLLBACK semantics.
+	ConfigConstraintSupport ConfigOption = 1
+	// ConfigInnocuous marks the table as safe for use in triggers and views
+	// even with trusted_schema=OFF (SQLITE_VTAB_INNOCUOUS).
+	ConfigInnocuous ConfigOption = 2
+	// ConfigDirectOnly restricts the table to top-level SQL, keeping it out
+	// of triggers and views entirely (SQLITE_VTAB_DIRECTONLY).
+	ConfigDirectOnly ConfigOption = 3
+)
+
+// SetConfig applies the given sqlite3_vtab_config options. Like Config, it
+// must be called from within Create or Connect.
+func (c Context) SetConfig(opts ...ConfigOption) error {
+	for _, op := range opts {
+		var args []int32
+		if op == ConfigConstraintSupport {
+			args = []int32{1}
+		}
+		if err := c.Config(int32(op), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // NewContext is used by the engine to create a Context bound to the current
 // xCreate/xConnect call. External modules should not need to call this.
 func NewContext(declare func(string) error) Context { return Context{declare: declare} }
@@ -107,13 +169,92 @@ type Renamer interface {
 	Rename(newName string) error
 }
 
-// Transactional can be implemented by a Table to handle transaction-related
-// callbacks. Methods are optional; unimplemented methods are treated as no-op.
-type Transactional interface {
+// TableFunction is the implementation installed for an SQL function
+// overloaded through FunctionOverloader.
+type TableFunction func(args []Value) (Value, error)
+
+// ConstraintFunctionOpcode is the smallest FindFunction opcode (mirroring
+// SQLITE_INDEX_CONSTRAINT_FUNCTION) that additionally lets the overloaded
+// function appear in BestIndex as an OpFUNCTION constraint.
+const ConstraintFunctionOpcode = 150
+
+// FunctionOverloader can be implemented by a Table to overload SQL functions
+// applied to its columns, wired to xFindFunction. It is consulted during
+// statement preparation whenever a function — including the function forms
+// of the MATCH, LIKE, GLOB and REGEXP operators — takes a column of the
+// table as an argument. Returning a non-nil implementation with opcode 1
+// installs the overload for that statement; an opcode of
+// ConstraintFunctionOpcode or greater additionally offers the function to
+// BestIndex as a constraint. Return a nil function to leave the original
+// untouched.
+//
+// Overloading MATCH is how FTS-like modules implement their query syntax
+// purely in Go: without it, col MATCH 'expr' on a virtual table is an error
+// unless BestIndex consumes the constraint.
+type FunctionOverloader interface {
+	FindFunction(name string, nArg int) (TableFunction, int)
+}
+
+// EponymousModule can be implemented by a Module to register as eponymous:
+// the registered name is usable directly as a table — including as a
+// table-valued function, SELECT * FROM mod('arg') — in addition to CREATE
+// VIRTUAL TABLE. SQLite requires xCreate and xConnect to be the same
+// function for eponymous modules, so Connect is called for both paths and
+// Create never is. Arguments of a table-valued call reach the module as
+// equality constraints on HIDDEN columns of the declared schema, through
+// BestIndex and Filter.
+type EponymousModule interface {
+	Module
+
+	// Eponymous is a marker; it is never called.
+	Eponymous()
+}
+
+// EponymousOnlyModule can be implemented by a Module to register as
+// eponymous-only (a nil xCreate): the module can only be used directly by
+// its registered name and CREATE VIRTUAL TABLE is an error. See
+// EponymousModule for how table-valued arguments are delivered.
+type EponymousOnlyModule interface {
+	Module
+
+	// EponymousOnly is a marker; it is never called.
+	EponymousOnly()
+}
+
+// ShadowNamer can be implemented by a Module whose virtual tables persist
+// state in real "shadow" tables named <table>_<suffix>, wired to
+// xShadowName. ShadowName reports whether suffix names one of the module's
+// shadow tables; SQLite uses the answer to protect those tables from direct
+// writes when the connection is in defensive mode (see DBConfigDefensive).
+//
+// xShadowName receives only the suffix, so when several registered modules
+// declare shadow names the driver treats a suffix as a shadow table if any
+// of them claims it.
+type ShadowNamer interface {
+	ShadowName(suffix string) bool
+}
+
+// Transactioner can be implemented by a Table to take part in the
+// surrounding SQL transaction, wired to xBegin, xSync, xCommit and
+// xRollback. Writable modules backed by external stores can buffer their
+// writes between Begin and Sync and flush them once in Sync, making the
+// flush atomic with the transaction instead of writing on every statement.
+// Sync is the place to perform the flush: it runs during the first phase of
+// SQLite's two-phase commit, where an error still fails the whole
+// transaction. Methods are optional; a Table may also implement any subset
+// of them individually, and unimplemented methods are treated as no-ops.
+type Transactioner interface {
 	Begin() error
 	Sync() error
 	Commit() error
 	Rollback() error
+}
+
+// Transactional extends Transactioner with the savepoint callbacks
+// xSavepoint, xRelease and xRollbackTo. Methods are optional; unimplemented
+// methods are treated as no-op.
+type Transactional interface {
+	Transactioner
 	Savepoint(i int) error
 	Release(i int) error
 	RollbackTo(i int) error
@@ -142,6 +283,16 @@ type Cursor interface {
 	Close() error
 }
 
+// ColumnUser can be implemented by a Cursor to learn which columns the
+// running query actually references. The bridge records the ColUsed mask
+// chosen at plan time and calls SetColumnsUsed immediately before each
+// Filter call: bit N is set if column N (or, for bit 63, any column >= 63)
+// may be requested. Wide remote-backed tables can use the mask to fetch only
+// the referenced columns instead of smuggling it through IdxStr by hand.
+type ColumnUser interface {
+	SetColumnsUsed(colUsed uint64)
+}
+
 // Updater can be implemented by a Table to support writes via xUpdate.
 //
 // Semantics follow SQLite's xUpdate:
@@ -156,6 +307,74 @@ type Updater interface {
 	Delete(oldRowid int64) error
 }
 
+// PartialUpdater can be implemented alongside Updater by a Table that wants
+// to know which columns an UPDATE statement actually assigned. When a Table
+// implements it, the bridge calls UpdatePartial instead of Updater.Update,
+// passing changed with one entry per column: changed[i] is true if column i
+// appeared in the SET clause and false if SQLite is carrying the old value
+// through unchanged. Unchanged columns are not re-read from the cursor and
+// arrive as nil in cols; consult the mask rather than the values. Modules
+// backed by remote stores can use the mask to issue minimal partial writes
+// instead of full-row replacements.
+//
+// Inserts and deletes are unaffected and still go through Updater (or
+// BatchUpdater).
+type PartialUpdater interface {
+	UpdatePartial(oldRowid int64, cols []Value, changed []bool, newRowid *int64) error
+}
+
+// ConflictMode is the ON CONFLICT resolution mode active for one xUpdate
+// call (sqlite3_vtab_on_conflict). The values mirror SQLITE_ROLLBACK etc.
+type ConflictMode int
+
+const (
+	ConflictRollback ConflictMode = iota + 1
+	ConflictIgnore
+	ConflictFail
+	ConflictAbort
+	ConflictReplace
+)
+
+// ConflictHandler can be implemented by a writable Table to learn the ON
+// CONFLICT resolution mode of each write. The driver calls SetConflictMode
+// immediately before every Insert, Update or Delete, so e.g. an INSERT OR
+// REPLACE can replace a conflicting row instead of failing. The mode only
+// varies per statement when the module also declared
+// ConfigConstraintSupport; without it SQLite rewrites all writes to ABORT
+// semantics itself.
+type ConflictHandler interface {
+	SetConflictMode(mode ConflictMode)
+}
+
+// Row is one buffered row passed to BatchUpdater.InsertBatch. Rowid is the
+// rowid the row was inserted with; rowids synthesized by the bridge for
+// inserts that did not specify one are negative and provisional.
+type Row struct {
+	Rowid int64
+	Cols  []Value
+}
+
+// BatchUpdater can be implemented by a Table instead of calling through
+// Updater row by row. When a Table implements BatchUpdater, the bridge
+// coalesces consecutive xUpdate calls within one statement or transaction and
+// delivers them in bulk: DeleteBatch receives the rowids of all buffered
+// deletes, then InsertBatch receives all buffered inserts. Buffers are
+// flushed at xSync (i.e. when the surrounding transaction commits) and
+// discarded on rollback, so write-heavy modules backed by remote APIs can
+// batch their traffic.
+//
+// An UPDATE statement is delivered as a buffered delete of the old row
+// followed by a buffered insert of the new one, unless the Table also
+// implements Updater, in which case pending buffers are flushed and
+// Updater.Update is called directly.
+//
+// Note that reads within the same transaction do not observe buffered,
+// not-yet-flushed writes.
+type BatchUpdater interface {
+	InsertBatch(rows []Row) error
+	DeleteBatch(rowids []int64) error
+}
+
 // ConstraintOp describes the operator used in a constraint on a virtual
 // table column. It loosely mirrors the op field of sqlite3_index_constraint.
 type ConstraintOp int
@@ -195,8 +414,33 @@ type Constraint struct {
 	// Omit requests SQLite to omit the corresponding constraint from the
 	// parent query if the virtual table fully handles it.
 	Omit bool
+	// InCapable is set by the driver when the constraint is part of an
+	// IN (...) whose whole right-hand side could be delivered to Filter at
+	// once (sqlite3_vtab_in). It is informational unless ProcessIn is set.
+	InCapable bool
+	// ProcessIn asks for the IN list to be processed in a single Filter call
+	// instead of one call per element. It is honored only on constraints
+	// whose InCapable is set and that also set ArgIndex; the corresponding
+	// argv slot then holds an InValues slice with every element of the list.
+	ProcessIn bool
+	// Collation names the collating sequence SQLite would use to evaluate
+	// the constraint ("BINARY", "NOCASE", "RTRIM" or a registered custom
+	// collation). A module should only set Omit on a text constraint it
+	// evaluates with the same collation.
+	Collation string
+	// RHSValue holds the right-hand side of the constraint when it is a
+	// literal known at plan time (sqlite3_vtab_rhs_value), letting BestIndex
+	// pick a plan based on the actual value — e.g. recognizing a LIKE
+	// pattern with a fixed prefix. RHSKnown reports whether it is; bound
+	// parameters and join columns are not known until Filter.
+	RHSKnown bool
+	RHSValue Value
 }
 
+// InValues is the value delivered to Cursor.Filter for a constraint that
+// requested ProcessIn: all elements of the IN (...) right-hand side.
+type InValues []Value
+
 // OrderBy describes a single ORDER BY term for a query involving a virtual
 // table.
 type OrderBy struct {
@@ -221,13 +465,37 @@ type IndexInfo struct {
 	// Set to IndexScanUnique to indicate the plan visits at most one row.
 	IdxFlags        int
 	OrderByConsumed bool
-	EstimatedCost   float64
-	EstimatedRows   int64
+	// EstimatedCost and EstimatedRows arrive in BestIndex pre-filled with
+	// the planner's full-scan defaults; lower them for plans that use the
+	// offered constraints so SQLite can choose between candidate plans.
+	// Values left untouched are passed back unchanged.
+	EstimatedCost float64
+	EstimatedRows int64
 	// ColUsed is a bitmask indicating which columns are used by the query.
 	// Bit N is set if column N is referenced.
 	ColUsed uint64
+	// Distinct hints how the query consumes the rows
+	// (sqlite3_vtab_distinct); see the Distinct* constants. When it is not
+	// DistinctNone the OrderBy terms describe the DISTINCT or GROUP BY
+	// columns and the module may return fewer rows as described, though
+	// returning the full set is always safe.
+	Distinct int
 }
 
+// Values of IndexInfo.Distinct.
+const (
+	// DistinctNone: the query needs every row, in ORDER BY order.
+	DistinctNone = iota
+	// DistinctGroupBy: rows only need to arrive grouped — equal OrderBy
+	// column values adjacent — in any group order.
+	DistinctGroupBy
+	// DistinctDistinct: duplicate rows may be omitted, in any order.
+	DistinctDistinct
+	// DistinctOrdered: duplicates may be omitted, but ORDER BY order is
+	// still required.
+	DistinctOrdered
+)
+
 // Index flag values for IndexInfo.IdxFlags.
 const (
 	// IndexScanUnique mirrors SQLITE_INDEX_SCAN_UNIQUE and indicates that the
@@ -235,8 +503,8 @@ const (
 	IndexScanUnique = 1
 )
 
-// ErrNotImplemented is returned by RegisterModule when the underlying engine
-// has not yet installed a registration hook. External projects can depend on
+// ErrNotImplemented is returned by RegisterModule and UnregisterModule when
+// the underlying engine has not yet installed a registration hook. External projects can depend on
 // the vtab API surface before the low-level bridge to sqlite3_create_module
 // is fully wired; once the engine sets the hook via SetRegisterFunc,
 // RegisterModule will forward calls to it.
@@ -277,3 +545,32 @@ func RegisterModule(db *sql.DB, name string, m Module) error {
 	}
 	return registerHook(name, m)
 }
+
+// unregisterHook is installed by the engine package via SetUnregisterFunc. It
+// is invoked by UnregisterModule to perform the actual module removal.
+var unregisterHook func(name string) error
+
+// SetUnregisterFunc is intended to be called by the engine package to provide
+// the concrete implementation of module removal. External callers should use
+// UnregisterModule instead.
+func SetUnregisterFunc(fn func(name string) error) { unregisterHook = fn }
+
+// UnregisterModule removes a virtual table module previously registered with
+// RegisterModule, freeing the name for re-registration.
+//
+// Like registration, removal applies to new connections only: connections
+// that are already open keep the module installed. To drop the module from a
+// live connection as well, use the driver connection's DropModule method
+// (backed by sqlite3_create_module with a nil module pointer) through
+// sql.Conn.Raw. Unregistering a name that is not currently registered returns
+// an error.
+func UnregisterModule(db *sql.DB, name string) error {
+	_ = db
+	if unregisterHook == nil {
+		return ErrNotImplemented
+	}
+	if name == "" {
+		return errors.New("vtab: module name must be non-empty")
+	}
+	return unregisterHook(name)
+}
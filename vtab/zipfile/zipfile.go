@@ -0,0 +1,441 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zipfile provides a virtual table over the entries of a zip
+// archive, mirroring SQLite's zipfile extension but implemented with
+// archive/zip. Each entry is one row with the columns name, mode, mtime
+// (unix seconds), sz and data:
+//
+//	CREATE VIRTUAL TABLE zip USING zipfile('archive.zip');
+//	SELECT name, sz FROM zip;
+//	INSERT INTO zip(name, data) VALUES ('hello.txt', 'hello');
+//	DELETE FROM zip WHERE name = 'old.txt';
+//
+// Writes are buffered in memory for the duration of the surrounding SQL
+// transaction and the archive is rewritten once, atomically with the
+// transaction commit; a rolled-back transaction leaves the file untouched.
+// Reads on the same connection observe the buffered writes. Inserting into a
+// table whose archive does not exist yet creates it. mode holds
+// io/fs.FileMode bits and defaults to 0644 for new entries, mtime defaults
+// to the current time.
+package zipfile // import "github.com/syralon/sqlite/vtab/zipfile"
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// Column indexes of the declared schema.
+const (
+	colName = iota
+	colMode
+	colMtime
+	colSz
+	colData
+)
+
+// Module implements vtab.Module for zipfile tables.
+type Module struct{}
+
+// Register registers a zipfile module named name. Registration applies to
+// new connections only; see vtab.RegisterModule.
+func Register(db *sql.DB, name string) error {
+	return vtab.RegisterModule(db, name, Module{})
+}
+
+// Create implements vtab.Module. The single module argument is the path of
+// the archive.
+func (Module) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if len(args) < 4 {
+		return nil, fmt.Errorf("zipfile: an archive path argument is required")
+	}
+
+	if err := ctx.Declare("CREATE TABLE x(name TEXT, mode INTEGER, mtime INTEGER, sz INTEGER, data BLOB)"); err != nil {
+		return nil, err
+	}
+	return &table{path: unquote(strings.TrimSpace(args[3]))}, nil
+}
+
+// Connect implements vtab.Module.
+func (m Module) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' && s[len(s)-1] == '\'' || s[0] == '"' && s[len(s)-1] == '"') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// entry is one archive member, fully materialized for rewriting. id is the
+// entry's rowid; it stays stable while buffered writes insert and delete
+// neighbouring entries.
+type entry struct {
+	id    int64
+	name  string
+	mode  int64
+	mtime int64
+	data  []byte
+}
+
+type table struct {
+	path string
+
+	// tx buffers the archive contents while a transaction is writing the
+	// table. It is loaded lazily on the first write, flushed to disk in
+	// Sync when dirty, and dropped at Commit or Rollback.
+	tx     []entry
+	nextID int64
+	dirty  bool
+
+	// savepoints snapshots the buffer state at each xSavepoint, so nested
+	// savepoints and ON CONFLICT clauses can partially roll back buffered
+	// writes.
+	savepoints []savepoint
+}
+
+// savepoint is the buffer state captured when SQLite opened savepoint n.
+type savepoint struct {
+	n      int
+	tx     []entry
+	nextID int64
+	dirty  bool
+}
+
+// readEntries loads the archive. A missing archive reads as empty so a
+// fresh table can be populated with INSERTs.
+func (t *table) readEntries() ([]entry, error) {
+	r, err := zip.OpenReader(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	entries := make([]entry, 0, len(r.File))
+	for i, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry{
+			id:    int64(i) + 1,
+			name:  f.Name,
+			mode:  int64(f.Mode()),
+			mtime: f.Modified.Unix(),
+			data:  data,
+		})
+	}
+	return entries, nil
+}
+
+// writeEntries rewrites the archive atomically.
+func (t *table) writeEntries(entries []entry) error {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{
+			Name:     e.name,
+			Method:   zip.Deflate,
+			Modified: time.Unix(e.mtime, 0),
+		}
+		fh.SetMode(fs.FileMode(e.mode))
+		fw, err := w.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(e.data); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// BestIndex implements vtab.Table.
+func (t *table) BestIndex(info *vtab.IndexInfo) error {
+	info.EstimatedCost = 1000
+	return nil
+}
+
+// Open implements vtab.Table.
+func (t *table) Open() (vtab.Cursor, error) { return &cursor{t: t}, nil }
+
+// Disconnect implements vtab.Table.
+func (t *table) Disconnect() error { return nil }
+
+// Destroy implements vtab.Table. The archive itself is kept.
+func (t *table) Destroy() error { return nil }
+
+// buffer returns the transaction's entry buffer, loading it from the
+// archive on the first write of the transaction.
+func (t *table) buffer() ([]entry, error) {
+	if !t.dirty && t.tx == nil {
+		entries, err := t.readEntries()
+		if err != nil {
+			return nil, err
+		}
+		t.tx = entries
+		t.nextID = int64(len(entries)) + 1
+	}
+	return t.tx, nil
+}
+
+// find returns the buffer index of the entry with the given rowid.
+func (t *table) find(entries []entry, rowid int64) (int, error) {
+	for i := range entries {
+		if entries[i].id == rowid {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("zipfile: rowid %d not found", rowid)
+}
+
+// Begin implements vtab.Transactioner.
+func (t *table) Begin() error { return nil }
+
+// Sync implements vtab.Transactioner. Buffered writes are flushed here, in
+// the first phase of the commit, so a failing rewrite still fails the whole
+// transaction.
+func (t *table) Sync() error {
+	if !t.dirty {
+		return nil
+	}
+	return t.writeEntries(t.tx)
+}
+
+// Commit implements vtab.Transactioner.
+func (t *table) Commit() error {
+	t.tx, t.dirty, t.savepoints = nil, false, nil
+	return nil
+}
+
+// Rollback implements vtab.Transactioner. The buffered writes are discarded
+// and the archive is left untouched.
+func (t *table) Rollback() error {
+	t.tx, t.dirty, t.savepoints = nil, false, nil
+	return nil
+}
+
+// Savepoint implements vtab.Transactional.
+func (t *table) Savepoint(n int) error {
+	t.dropSavepoints(n)
+	t.savepoints = append(t.savepoints, savepoint{
+		n:      n,
+		tx:     append([]entry(nil), t.tx...),
+		nextID: t.nextID,
+		dirty:  t.dirty,
+	})
+	return nil
+}
+
+// Release implements vtab.Transactional. The writes made under the released
+// savepoints are kept and merge into the enclosing transaction.
+func (t *table) Release(n int) error {
+	t.dropSavepoints(n)
+	return nil
+}
+
+// RollbackTo implements vtab.Transactional. The buffer reverts to its state
+// at savepoint n; the savepoint itself stays open.
+func (t *table) RollbackTo(n int) error {
+	for i := len(t.savepoints) - 1; i >= 0; i-- {
+		if sp := &t.savepoints[i]; sp.n == n {
+			t.tx = append([]entry(nil), sp.tx...)
+			t.nextID = sp.nextID
+			t.dirty = sp.dirty
+			t.savepoints = t.savepoints[:i+1]
+			return nil
+		}
+	}
+	return nil
+}
+
+// dropSavepoints discards the snapshots of savepoint n and anything nested
+// below it.
+func (t *table) dropSavepoints(n int) {
+	for len(t.savepoints) > 0 && t.savepoints[len(t.savepoints)-1].n >= n {
+		t.savepoints = t.savepoints[:len(t.savepoints)-1]
+	}
+}
+
+// Insert implements vtab.Updater.
+func (t *table) Insert(cols []vtab.Value, rowid *int64) error {
+	e, err := entryFromCols(cols)
+	if err != nil {
+		return err
+	}
+
+	entries, err := t.buffer()
+	if err != nil {
+		return err
+	}
+
+	for _, have := range entries {
+		if have.name == e.name {
+			return fmt.Errorf("zipfile: duplicate entry %q", e.name)
+		}
+	}
+
+	e.id = t.nextID
+	t.nextID++
+	t.tx = append(entries, e)
+	t.dirty = true
+	*rowid = e.id
+	return nil
+}
+
+// Update implements vtab.Updater.
+func (t *table) Update(oldRowid int64, cols []vtab.Value, newRowid *int64) error {
+	e, err := entryFromCols(cols)
+	if err != nil {
+		return err
+	}
+
+	entries, err := t.buffer()
+	if err != nil {
+		return err
+	}
+
+	i, err := t.find(entries, oldRowid)
+	if err != nil {
+		return err
+	}
+
+	e.id = oldRowid
+	entries[i] = e
+	t.tx = entries
+	t.dirty = true
+	return nil
+}
+
+// Delete implements vtab.Updater.
+func (t *table) Delete(oldRowid int64) error {
+	entries, err := t.buffer()
+	if err != nil {
+		return err
+	}
+
+	i, err := t.find(entries, oldRowid)
+	if err != nil {
+		return err
+	}
+
+	t.tx = append(entries[:i], entries[i+1:]...)
+	t.dirty = true
+	return nil
+}
+
+// entryFromCols builds an entry from xUpdate column values, applying the
+// defaults for unset mode and mtime. The sz column is derived and ignored on
+// writes.
+func entryFromCols(cols []vtab.Value) (entry, error) {
+	e := entry{mode: int64(fs.FileMode(0644)), mtime: time.Now().Unix()}
+	name, ok := cols[colName].(string)
+	if !ok || name == "" {
+		return e, fmt.Errorf("zipfile: the name column must be a non-empty string")
+	}
+
+	e.name = name
+	if v, ok := cols[colMode].(int64); ok {
+		e.mode = v
+	}
+	if v, ok := cols[colMtime].(int64); ok {
+		e.mtime = v
+	}
+	switch v := cols[colData].(type) {
+	case nil:
+	case []byte:
+		e.data = append([]byte(nil), v...)
+	case string:
+		e.data = []byte(v)
+	default:
+		return e, fmt.Errorf("zipfile: unsupported data type %T", v)
+	}
+	return e, nil
+}
+
+type cursor struct {
+	t       *table
+	entries []entry
+	i       int
+}
+
+// Filter implements vtab.Cursor. Scans inside a writing transaction see the
+// buffered entries; the cursor takes its own copy so concurrent mutation of
+// the buffer cannot shift rows mid-scan.
+func (c *cursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	if c.t.dirty {
+		c.entries, c.i = append([]entry(nil), c.t.tx...), 0
+		return nil
+	}
+
+	entries, err := c.t.readEntries()
+	if err != nil {
+		return err
+	}
+
+	c.entries, c.i = entries, 0
+	return nil
+}
+
+// Next implements vtab.Cursor.
+func (c *cursor) Next() error {
+	c.i++
+	return nil
+}
+
+// Eof implements vtab.Cursor.
+func (c *cursor) Eof() bool { return c.i >= len(c.entries) }
+
+// Column implements vtab.Cursor.
+func (c *cursor) Column(col int) (vtab.Value, error) {
+	e := &c.entries[c.i]
+	switch col {
+	case colName:
+		return e.name, nil
+	case colMode:
+		return e.mode, nil
+	case colMtime:
+		return e.mtime, nil
+	case colSz:
+		return int64(len(e.data)), nil
+	case colData:
+		return e.data, nil
+	}
+	return nil, fmt.Errorf("zipfile: no such column: %d", col)
+}
+
+// Rowid implements vtab.Cursor.
+func (c *cursor) Rowid() (int64, error) { return c.entries[c.i].id, nil }
+
+// Close implements vtab.Cursor.
+func (c *cursor) Close() error { return nil }
@@ -0,0 +1,341 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zipfile // import "github.com/syralon/sqlite/vtab/zipfile"
+
+import (
+	"archive/zip"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/syralon/sqlite"
+)
+
+func TestZipfile(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "test.zip")
+
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zw := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"a.txt":     "alpha",
+		"dir/b.txt": "beta",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", dir+"/zip.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "zipfile"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table zip using zipfile(%q)", archive)); err != nil {
+		t.Fatal(err)
+	}
+
+	var data []byte
+	var sz int64
+	if err := db.QueryRow("select data, sz from zip where name = 'a.txt'").Scan(&data, &sz); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "alpha" || sz != 5 {
+		t.Fatalf("got data %q sz %d, want alpha 5", data, sz)
+	}
+
+	// Insert a new entry and verify with archive/zip directly.
+	if _, err := db.Exec("insert into zip(name, data) values ('c.txt', 'gamma')"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range r.File {
+		names[f.Name] = true
+	}
+	r.Close()
+	if len(names) != 3 || !names["c.txt"] {
+		t.Fatalf("archive entries after insert: %v", names)
+	}
+
+	// Update entry content.
+	if _, err := db.Exec("update zip set data = 'ALPHA!' where name = 'a.txt'"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("select data from zip where name = 'a.txt'").Scan(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "ALPHA!" {
+		t.Fatalf("got %q after update, want ALPHA!", data)
+	}
+
+	// Delete an entry.
+	if _, err := db.Exec("delete from zip where name = 'dir/b.txt'"); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from zip").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("got %d entries after delete, want 2", n)
+	}
+
+	// Duplicate names are rejected.
+	if _, err := db.Exec("insert into zip(name, data) values ('c.txt', 'again')"); err == nil {
+		t.Fatal("expected an error inserting a duplicate entry")
+	}
+
+	// Inserting into a table over a missing archive creates it.
+	fresh := filepath.Join(dir, "fresh.zip")
+	if _, err := db.Exec(fmt.Sprintf("create virtual table fresh using zipfile(%q)", fresh)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("select count(*) from fresh").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("missing archive reads as %d rows, want 0", n)
+	}
+
+	if _, err := db.Exec("insert into fresh(name, mode, mtime, data) values ('x', 420, 1000000000, x'00ff')"); err != nil {
+		t.Fatal(err)
+	}
+
+	var mode, mtime int64
+	if err := db.QueryRow("select mode, mtime, data from fresh").Scan(&mode, &mtime, &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if mode != 420 || mtime != 1000000000 || len(data) != 2 {
+		t.Fatalf("got mode %d mtime %d data %x", mode, mtime, data)
+	}
+
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("archive was not created: %v", err)
+	}
+}
+
+func TestZipfileTransactionAtomicity(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "tx.zip")
+
+	db, err := sql.Open("sqlite3", dir+"/tx.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "zipfile_tx"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table zip using zipfile_tx(%q)", archive)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("insert into zip(name, data) values ('base.txt', 'base')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// archiveNames lists the entries currently on disk.
+	archiveNames := func() []string {
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer r.Close()
+
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	// Writes inside an open transaction are visible to reads on the same
+	// connection but not flushed to the file yet.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := tx.Exec("insert into zip(name, data) values (?, 'x')", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	if err := tx.QueryRow("select count(*) from zip").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Fatalf("transaction sees %d entries, want 3", n)
+	}
+
+	if names := archiveNames(); len(names) != 1 {
+		t.Fatalf("archive has %v before commit, want only base.txt", names)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := archiveNames(); len(names) != 3 {
+		t.Fatalf("archive has %v after commit, want 3 entries", names)
+	}
+
+	// A rolled-back transaction leaves the archive untouched.
+	if tx, err = db.Begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.Exec("delete from zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := archiveNames(); len(names) != 3 {
+		t.Fatalf("archive has %v after rollback, want 3 entries", names)
+	}
+
+	if err := db.QueryRow("select count(*) from zip").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Fatalf("table reads %d entries after rollback, want 3", n)
+	}
+}
+
+func TestZipfileSavepoints(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "sp.zip")
+
+	db, err := sql.Open("sqlite3", dir+"/sp.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := Register(db, "zipfile_sp"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("create virtual table zip using zipfile_sp(%q)", archive)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes under a rolled-back savepoint disappear; everything else
+	// commits together.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []string{
+		"insert into zip(name, data) values ('a.txt', 'a')",
+		"savepoint one",
+		"insert into zip(name, data) values ('b.txt', 'b')",
+		"savepoint two",
+		"insert into zip(name, data) values ('c.txt', 'c')",
+		"rollback to two",
+		"insert into zip(name, data) values ('d.txt', 'd')",
+		"release one",
+		"insert into zip(name, data) values ('e.txt', 'e')",
+	}
+	for _, stmt := range steps {
+		if _, err := tx.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"a.txt", "b.txt", "d.txt", "e.txt"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Fatalf("archive has %v, want %v", names, want)
+	}
+
+	// A rollback across an open savepoint discards everything.
+	if tx, err = db.Begin(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range []string{
+		"savepoint s",
+		"delete from zip",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	if err := db.QueryRow("select count(*) from zip").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 4 {
+		t.Fatalf("got %d entries after rollback, want 4", n)
+	}
+}
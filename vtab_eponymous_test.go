@@ -0,0 +1,212 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// seqModule is an eponymous-only table-valued function producing the
+// integers start..stop: SELECT value FROM seq(start, stop).
+type seqModule struct{}
+
+func (seqModule) EponymousOnly() {}
+
+func (seqModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return nil, fmt.Errorf("seq: not createable")
+}
+
+func (seqModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(value, start HIDDEN, stop HIDDEN)"); err != nil {
+		return nil, err
+	}
+	return &seqTable{}, nil
+}
+
+type seqTable struct{}
+
+// BestIndex claims the equality constraints on the hidden parameter columns
+// and records the argv order in IdxStr ("s" for start, "e" for stop).
+func (t *seqTable) BestIndex(info *vtab.IndexInfo) error {
+	next := 0
+	for i := range info.Constraints {
+		c := &info.Constraints[i]
+		if !c.Usable || c.Op != vtab.OpEQ {
+			continue
+		}
+		switch c.Column {
+		case 1:
+			info.IdxStr += "s"
+		case 2:
+			info.IdxStr += "e"
+		default:
+			continue
+		}
+		c.ArgIndex = next
+		c.Omit = true
+		next++
+	}
+	return nil
+}
+
+func (t *seqTable) Open() (vtab.Cursor, error) { return &seqCursor{}, nil }
+func (t *seqTable) Disconnect() error          { return nil }
+func (t *seqTable) Destroy() error             { return nil }
+
+type seqCursor struct {
+	cur, start, stop int64
+}
+
+func (c *seqCursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.start, c.stop = 1, 0
+	for i, which := range idxStr {
+		v, ok := vals[i].(int64)
+		if !ok {
+			return fmt.Errorf("seq: arguments must be integers")
+		}
+		if which == 's' {
+			c.start = v
+		} else {
+			c.stop = v
+		}
+	}
+	c.cur = c.start
+	return nil
+}
+
+func (c *seqCursor) Next() error { c.cur++; return nil }
+func (c *seqCursor) Eof() bool   { return c.cur > c.stop }
+
+func (c *seqCursor) Column(col int) (vtab.Value, error) {
+	switch col {
+	case 0:
+		return c.cur, nil
+	case 1:
+		return c.start, nil
+	case 2:
+		return c.stop, nil
+	}
+	return nil, fmt.Errorf("seq: no such column: %d", col)
+}
+
+func (c *seqCursor) Rowid() (int64, error) { return c.cur, nil }
+func (c *seqCursor) Close() error          { return nil }
+
+// helloModule is eponymous but not eponymous-only: usable both directly and
+// through CREATE VIRTUAL TABLE.
+type helloModule struct{}
+
+func (helloModule) Eponymous() {}
+
+func (m helloModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Connect(ctx, args)
+}
+
+func (helloModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(v)"); err != nil {
+		return nil, err
+	}
+	return helloTable{}, nil
+}
+
+type helloTable struct{}
+
+func (helloTable) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (helloTable) Open() (vtab.Cursor, error)           { return &helloCursor{}, nil }
+func (helloTable) Disconnect() error                    { return nil }
+func (helloTable) Destroy() error                       { return nil }
+
+type helloCursor struct{ i int }
+
+func (c *helloCursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.i = 0
+	return nil
+}
+
+func (c *helloCursor) Next() error                        { c.i++; return nil }
+func (c *helloCursor) Eof() bool                          { return c.i >= 1 }
+func (c *helloCursor) Column(col int) (vtab.Value, error) { return "hello", nil }
+func (c *helloCursor) Rowid() (int64, error)              { return 1, nil }
+func (c *helloCursor) Close() error                       { return nil }
+
+func TestVtabEponymous(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/eponymous.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := vtab.RegisterModule(db, "seq", seqModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vtab.RegisterModule(db, "hello", helloModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The module name works directly as a table-valued function.
+	rows, err := db.Query("select value from seq(3, 5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 || got[0] != 3 || got[2] != 5 {
+		t.Fatalf("got %v, want [3 4 5]", got)
+	}
+
+	var n int64
+	if err := db.QueryRow("select count(*) from seq(1, 100) where value % 2 = 0").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 50 {
+		t.Fatalf("got %d even values, want 50", n)
+	}
+
+	// An eponymous-only module cannot be the target of CREATE VIRTUAL TABLE.
+	if _, err := db.Exec("create virtual table s using seq"); err == nil {
+		t.Fatal("expected CREATE VIRTUAL TABLE on an eponymous-only module to fail")
+	}
+
+	// A plain eponymous module supports both forms.
+	var v string
+	if err := db.QueryRow("select v from hello").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != "hello" {
+		t.Fatalf("got %q, want hello", v)
+	}
+
+	if _, err := db.Exec("create virtual table greeting using hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("select v from greeting").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != "hello" {
+		t.Fatalf("got %q, want hello", v)
+	}
+}
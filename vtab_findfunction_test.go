@@ -0,0 +1,146 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// findFuncModule exposes a fixed word list and overloads functions applied
+// to its column via vtab.FunctionOverloader.
+type findFuncModule struct {
+	words []string
+}
+
+func (m *findFuncModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(w)"); err != nil {
+		return nil, err
+	}
+	return &findFuncTable{m: m}, nil
+}
+
+func (m *findFuncModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type findFuncTable struct {
+	m *findFuncModule
+}
+
+func (t *findFuncTable) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (t *findFuncTable) Open() (vtab.Cursor, error)           { return &findFuncCursor{t: t}, nil }
+func (t *findFuncTable) Disconnect() error                    { return nil }
+func (t *findFuncTable) Destroy() error                       { return nil }
+
+// FindFunction implements vtab.FunctionOverloader. MATCH is overloaded as a
+// substring test and upper is replaced with a tagged variant; everything else
+// keeps its usual meaning.
+func (t *findFuncTable) FindFunction(name string, nArg int) (vtab.TableFunction, int) {
+	switch {
+	case name == "match" && nArg == 2:
+		// X MATCH Y is evaluated as match(Y, X): args[0] is the pattern,
+		// args[1] the column value.
+		return func(args []vtab.Value) (vtab.Value, error) {
+			pattern, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("match pattern must be a string")
+			}
+			w, _ := args[1].(string)
+			if strings.Contains(w, pattern) {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}, 1
+	case name == "upper" && nArg == 1:
+		return func(args []vtab.Value) (vtab.Value, error) {
+			w, _ := args[0].(string)
+			return "custom:" + strings.ToUpper(w), nil
+		}, 1
+	}
+	return nil, 0
+}
+
+type findFuncCursor struct {
+	t *findFuncTable
+	i int
+}
+
+func (c *findFuncCursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error {
+	c.i = 0
+	return nil
+}
+
+func (c *findFuncCursor) Next() error { c.i++; return nil }
+func (c *findFuncCursor) Eof() bool   { return c.i >= len(c.t.m.words) }
+
+func (c *findFuncCursor) Column(col int) (vtab.Value, error) {
+	return c.t.m.words[c.i], nil
+}
+
+func (c *findFuncCursor) Rowid() (int64, error) { return int64(c.i) + 1, nil }
+func (c *findFuncCursor) Close() error          { return nil }
+
+func TestVtabFindFunction(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/findfunc.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	m := &findFuncModule{words: []string{"alpha", "beta", "gamma"}}
+	if err := vtab.RegisterModule(db, "findfunc_test", m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table words using findfunc_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the overload, MATCH against a virtual table column is an error;
+	// with it, it runs the module's substring test.
+	var w string
+	if err := db.QueryRow("select w from words where w match 'lph'").Scan(&w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w != "alpha" {
+		t.Fatalf("got %q, want alpha", w)
+	}
+
+	// The upper overload applies when a column of the table is the argument...
+	if err := db.QueryRow("select upper(w) from words where w = 'beta'").Scan(&w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w != "custom:BETA" {
+		t.Fatalf("got %q, want custom:BETA", w)
+	}
+
+	// ...but not to unrelated expressions, which keep the built-in.
+	if err := db.QueryRow("select upper('beta')").Scan(&w); err != nil {
+		t.Fatal(err)
+	}
+
+	if w != "BETA" {
+		t.Fatalf("got %q, want BETA", w)
+	}
+
+	// Functions FindFunction declines keep their usual meaning on columns too.
+	var n int64
+	if err := db.QueryRow("select length(w) from words where w = 'gamma'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 5 {
+		t.Fatalf("got length %d, want 5", n)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// renameModule records the names xRename reports, and can be told to refuse.
+type renameModule struct {
+	renames []string
+	refuse  bool
+}
+
+func (m *renameModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(v)"); err != nil {
+		return nil, err
+	}
+	return &renameTable{m: m}, nil
+}
+
+func (m *renameModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+type renameTable struct {
+	m *renameModule
+}
+
+func (t *renameTable) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (t *renameTable) Open() (vtab.Cursor, error)           { return renameCursor{}, nil }
+func (t *renameTable) Disconnect() error                    { return nil }
+func (t *renameTable) Destroy() error                       { return nil }
+
+// Rename implements vtab.Renamer.
+func (t *renameTable) Rename(newName string) error {
+	if t.m.refuse {
+		return fmt.Errorf("renames are disabled")
+	}
+	t.m.renames = append(t.m.renames, newName)
+	return nil
+}
+
+type renameCursor struct{}
+
+func (renameCursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error { return nil }
+func (renameCursor) Next() error                                               { return nil }
+func (renameCursor) Eof() bool                                                 { return true }
+func (renameCursor) Column(col int) (vtab.Value, error)                        { return nil, nil }
+func (renameCursor) Rowid() (int64, error)                                     { return 0, nil }
+func (renameCursor) Close() error                                              { return nil }
+
+func TestVtabRename(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/rename.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	m := &renameModule{}
+	if err := vtab.RegisterModule(db, "rename_test", m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("create virtual table old_name using rename_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("alter table old_name rename to new_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.renames) != 1 || m.renames[0] != "new_name" {
+		t.Fatalf("module saw renames %v, want [new_name]", m.renames)
+	}
+
+	// The table answers to its new name and the schema followed.
+	if _, err := db.Exec("select * from new_name"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A refusing module fails the ALTER and keeps the old name.
+	m.refuse = true
+	if _, err := db.Exec("alter table new_name rename to other_name"); err == nil {
+		t.Fatal("expected the refused rename to fail")
+	}
+
+	if _, err := db.Exec("select * from new_name"); err != nil {
+		t.Fatalf("table lost its name after a failed rename: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/syralon/sqlite/vtab"
+)
+
+// shadowModule is an empty virtual table whose instances keep state in
+// <table>_content shadow tables.
+type shadowModule struct{}
+
+func (shadowModule) Create(ctx vtab.Context, args []string) (vtab.Table, error) {
+	if err := ctx.Declare("CREATE TABLE x(v)"); err != nil {
+		return nil, err
+	}
+	return shadowTable{}, nil
+}
+
+func (m shadowModule) Connect(ctx vtab.Context, args []string) (vtab.Table, error) {
+	return m.Create(ctx, args)
+}
+
+// ShadowName implements vtab.ShadowNamer.
+func (shadowModule) ShadowName(suffix string) bool { return suffix == "content" }
+
+type shadowTable struct{}
+
+func (shadowTable) BestIndex(info *vtab.IndexInfo) error { return nil }
+func (shadowTable) Open() (vtab.Cursor, error)           { return shadowCursor{}, nil }
+func (shadowTable) Disconnect() error                    { return nil }
+func (shadowTable) Destroy() error                       { return nil }
+
+type shadowCursor struct{}
+
+func (shadowCursor) Filter(idxNum int, idxStr string, vals []vtab.Value) error { return nil }
+func (shadowCursor) Next() error                                               { return nil }
+func (shadowCursor) Eof() bool                                                 { return true }
+func (shadowCursor) Column(col int) (vtab.Value, error)                        { return nil, nil }
+func (shadowCursor) Rowid() (int64, error)                                     { return 0, nil }
+func (shadowCursor) Close() error                                              { return nil }
+
+func TestVtabShadowName(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := sql.Open(driverName, tempDir+"/shadow.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if err := vtab.RegisterModule(db, "shadow_test", shadowModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	exec := func(sql string) error {
+		_, err := cn.ExecContext(context.Background(), sql)
+		return err
+	}
+	for _, sql := range []string{
+		"create virtual table t using shadow_test",
+		"create table t_content(v)",
+		"create table t_other(v)",
+	} {
+		if err := exec(sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setDefensive := func(enable bool) {
+		t.Helper()
+		if err := cn.Raw(func(driverConn any) error {
+			_, err := driverConn.(interface {
+				DBConfig(op DBConfigOption, enable bool) (bool, error)
+			}).DBConfig(DBConfigDefensive, enable)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Outside defensive mode the shadow table is an ordinary table.
+	if err := exec("insert into t_content values (1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// In defensive mode the claimed suffix is protected; unclaimed suffixes
+	// are not.
+	setDefensive(true)
+	if err := exec("insert into t_content values (2)"); err == nil {
+		t.Fatal("expected writing the shadow table in defensive mode to fail")
+	}
+
+	if err := exec("insert into t_other values (1)"); err != nil {
+		t.Fatal(err)
+	}
+
+	setDefensive(false)
+	if err := exec("insert into t_content values (3)"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"unsafe"
+
+	sqlite3 "github.com/syralon/sqlite/lib"
+	"modernc.org/libc"
+)
+
+// CheckpointMode selects how aggressively Conn.WalCheckpoint transfers WAL
+// content back into the database file.
+type CheckpointMode int32
+
+// Checkpoint modes accepted by Conn.WalCheckpoint, mirroring the
+// SQLITE_CHECKPOINT_* constants.
+const (
+	// CheckpointPassive checkpoints as many frames as possible without
+	// waiting for readers or writers to finish.
+	CheckpointPassive = CheckpointMode(sqlite3.SQLITE_CHECKPOINT_PASSIVE)
+	// CheckpointFull waits for writers to finish, then checkpoints all
+	// frames in the log.
+	CheckpointFull = CheckpointMode(sqlite3.SQLITE_CHECKPOINT_FULL)
+	// CheckpointRestart is like CheckpointFull and additionally waits
+	// until no reader is using the log, so the next writer restarts it.
+	CheckpointRestart = CheckpointMode(sqlite3.SQLITE_CHECKPOINT_RESTART)
+	// CheckpointTruncate is like CheckpointRestart and additionally
+	// truncates the log file to zero bytes.
+	CheckpointTruncate = CheckpointMode(sqlite3.SQLITE_CHECKPOINT_TRUNCATE)
+)
+
+// WalAutoCheckpoint sets the number of WAL frames that triggers an automatic
+// checkpoint on the connection, wrapping sqlite3_wal_autocheckpoint. The
+// compiled-in default is 1000 frames; zero or a negative value disables
+// automatic checkpointing entirely, leaving the log to grow until
+// WalCheckpoint is called.
+//
+// Access it through sql.Conn.Raw, or use the package level SetWalAutoCheckpoint
+// to apply a threshold to every new connection.
+func (c *conn) WalAutoCheckpoint(nFrames int32) error {
+	if rc := sqlite3.Xsqlite3_wal_autocheckpoint(c.tls, c.db, nFrames); rc != sqlite3.SQLITE_OK {
+		return c.errstr(rc)
+	}
+	return nil
+}
+
+// WalCheckpoint runs a checkpoint on the named attached database, wrapping
+// sqlite3_wal_checkpoint_v2. db is usually "main"; pass "" to checkpoint all
+// attached databases. It returns the size of the WAL in frames and the number
+// of frames that were checkpointed, or -1 for each if the database is not in
+// WAL mode.
+//
+// Access it through sql.Conn.Raw.
+func (c *conn) WalCheckpoint(db string, mode CheckpointMode) (logFrames, checkpointedFrames int, err error) {
+	var zDb uintptr
+	if db != "" {
+		if zDb, err = libc.CString(db); err != nil {
+			return 0, 0, err
+		}
+
+		defer c.free(zDb)
+	}
+
+	bp := c.tls.Alloc(8)
+	defer c.tls.Free(8)
+
+	pnLog := bp
+	pnCkpt := bp + 4
+	if rc := sqlite3.Xsqlite3_wal_checkpoint_v2(c.tls, c.db, zDb, int32(mode), pnLog, pnCkpt); rc != sqlite3.SQLITE_OK {
+		return 0, 0, c.errstr(rc)
+	}
+	return int(*(*int32)(unsafe.Pointer(pnLog))), int(*(*int32)(unsafe.Pointer(pnCkpt))), nil
+}
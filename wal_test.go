@@ -0,0 +1,134 @@
+// Copyright 2026 The Sqlite Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite // import "github.com/syralon/sqlite"
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalCheckpoint(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "checkpoint.db") + "?_pragma=journal_mode(wal)"
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	cn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cn.Close()
+
+	if _, err := cn.ExecContext(context.Background(), `
+		create table t(v);
+		insert into t values (1), (2), (3);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	type checkpointer interface {
+		WalCheckpoint(db string, mode CheckpointMode) (logFrames, checkpointedFrames int, err error)
+	}
+	checkpoint := func(db string, mode CheckpointMode) (int, int) {
+		t.Helper()
+		var nLog, nCkpt int
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			nLog, nCkpt, err = driverConn.(checkpointer).WalCheckpoint(db, mode)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return nLog, nCkpt
+	}
+
+	// The writes above left frames in the log; a full checkpoint transfers
+	// all of them.
+	nLog, nCkpt := checkpoint("main", CheckpointFull)
+	if nLog <= 0 || nCkpt != nLog {
+		t.Fatalf("got %d log frames, %d checkpointed, want all of a non-empty log", nLog, nCkpt)
+	}
+
+	// Truncating resets the log to zero frames.
+	if nLog, _ = checkpoint("", CheckpointTruncate); nLog != 0 {
+		t.Fatalf("got %d log frames after truncate, want 0", nLog)
+	}
+}
+
+func TestWalAutoCheckpoint(t *testing.T) {
+	var eagerDriver Driver
+	eagerDriver.SetWalAutoCheckpoint(1)
+	sql.Register("sqlite3_autocheckpoint_test", &eagerDriver)
+
+	type walConfigurer interface {
+		WalAutoCheckpoint(nFrames int32) error
+		WalCheckpoint(db string, mode CheckpointMode) (logFrames, checkpointedFrames int, err error)
+	}
+	logFrames := func(t *testing.T, driver string, auto int32, inserts int) int {
+		t.Helper()
+		dsn := "file:" + filepath.Join(t.TempDir(), "auto.db") + "?_pragma=journal_mode(wal)"
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer db.Close()
+
+		cn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer cn.Close()
+
+		if auto >= 0 {
+			if err := cn.Raw(func(driverConn any) error {
+				return driverConn.(walConfigurer).WalAutoCheckpoint(auto)
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := cn.ExecContext(context.Background(), "create table t(v)"); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < inserts; i++ {
+			if _, err := cn.ExecContext(context.Background(), "insert into t values (?)", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var nLog int
+		if err := cn.Raw(func(driverConn any) error {
+			var err error
+			nLog, _, err = driverConn.(walConfigurer).WalCheckpoint("main", CheckpointPassive)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return nLog
+	}
+
+	const inserts = 20
+
+	// With automatic checkpointing disabled the log accumulates at least one
+	// frame per committed insert.
+	if nLog := logFrames(t, driverName, 0, inserts); nLog < inserts {
+		t.Fatalf("got %d log frames with autocheckpoint disabled, want >= %d", nLog, inserts)
+	}
+
+	// A driver-wide threshold of one frame checkpoints and restarts the log
+	// after every commit, keeping it short.
+	if nLog := logFrames(t, "sqlite3_autocheckpoint_test", -1, inserts); nLog >= inserts {
+		t.Fatalf("got %d log frames with autocheckpoint threshold 1, want < %d", nLog, inserts)
+	}
+}